@@ -495,6 +495,26 @@ func PairingCheck(a []*G1, b []*G2) bool {
 	return ret.IsOne()
 }
 
+// PairingProduct calculates the product of the Optimal Ate pairings of a set of
+// points, sharing a single final exponentiation.
+func PairingProduct(a []*G1, b []*G2) *GT {
+	pool := new(bnPool)
+
+	acc := newGFp12(pool)
+	acc.SetOne()
+
+	for i := 0; i < len(a); i++ {
+		if a[i].p.IsInfinity() || b[i].p.IsInfinity() {
+			continue
+		}
+		acc.Mul(acc, miller(b[i].p, a[i].p, pool), pool)
+	}
+	ret := finalExponentiation(acc, pool)
+	acc.Put(pool)
+
+	return &GT{ret}
+}
+
 // bnPool implements a tiny cache of *big.Int objects that's used to reduce the
 // number of allocations made during processing.
 type bnPool struct {
@@ -69,3 +69,24 @@ func TestPairings(t *testing.T) {
 		t.Error("Pairing mismatch: e(a * 37, b * 27) != e(a, b * 999)")
 	}
 }
+
+func TestPairingProduct(t *testing.T) {
+	a2 := new(G1).ScalarBaseMult(bigFromBase10("2"))
+	a37 := new(G1).ScalarBaseMult(bigFromBase10("37"))
+	b0 := new(G2).ScalarBaseMult(bigFromBase10("0"))
+	b1 := new(G2).ScalarBaseMult(bigFromBase10("1"))
+	b27 := new(G2).ScalarBaseMult(bigFromBase10("27"))
+
+	p := PairingProduct([]*G1{a2, a37, a2}, []*G2{b1, b27, b0})
+	p_2 := new(GT).Add(Pair(a2, b1), Pair(a37, b27))
+	if p.String() != p_2.String() {
+		t.Error("Pairing mismatch: product of pairings != e(a * 2, b) * e(a * 37, b * 27)")
+	}
+	p1001 := Pair(new(G1).ScalarBaseMult(bigFromBase10("1001")), b1)
+	if p.String() != p1001.String() {
+		t.Error("Pairing mismatch: product of pairings != e(a, b) ** 1001")
+	}
+	if !PairingProduct(nil, nil).IsOne() {
+		t.Error("Empty product of pairings is not one")
+	}
+}
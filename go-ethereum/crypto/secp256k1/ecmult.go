@@ -0,0 +1,107 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package secp256k1
+
+import (
+	"errors"
+	"math/big"
+	"unsafe"
+
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+/*
+#include "libsecp256k1/include/secp256k1.h"
+extern int secp256k1_ext_point_add(unsigned char *out, const unsigned char *a, const unsigned char *b);
+extern int secp256k1_ext_ecmult_multi(const secp256k1_context* ctx, unsigned char *out, const unsigned char *points, const unsigned char *scalars, size_t n);
+*/
+import "C"
+
+var (
+	ErrInvalidPoint     = errors.New("invalid point")
+	ErrInvalidScalar    = errors.New("invalid scalar")
+	ErrInvalidMultiExp  = errors.New("invalid point or scalar in multi-exponentiation")
+	ErrMultiExpInputLen = errors.New("different number of points and scalars")
+)
+
+// AddPoints returns the sum of the points (x1, y1) and (x2, y2), computed by
+// libsecp256k1 in variable time. The points may be equal. The point at infinity
+// is represented by nil coordinates, both in the input and in the result.
+func AddPoints(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int, error) {
+	if x1 == nil || y1 == nil {
+		return x2, y2, nil
+	}
+	if x2 == nil || y2 == nil {
+		return x1, y1, nil
+	}
+	a := make([]byte, 64)
+	b := make([]byte, 64)
+	out := make([]byte, 64)
+	math.ReadBits(x1, a[:32])
+	math.ReadBits(y1, a[32:])
+	math.ReadBits(x2, b[:32])
+	math.ReadBits(y2, b[32:])
+	res := C.secp256k1_ext_point_add((*C.uchar)(unsafe.Pointer(&out[0])),
+		(*C.uchar)(unsafe.Pointer(&a[0])), (*C.uchar)(unsafe.Pointer(&b[0])))
+	switch res {
+	case 1:
+		return new(big.Int).SetBytes(out[:32]), new(big.Int).SetBytes(out[32:]), nil
+	case 0:
+		return nil, nil, nil
+	}
+	return nil, nil, ErrInvalidPoint
+}
+
+// MultiScalarMult returns sum_i scalars[i]*(xs[i], ys[i]), computed by the
+// multi-exponentiation of libsecp256k1 in variable time. Scalars are big-endian
+// and must be smaller than the order of the curve. Points at infinity, given by
+// nil coordinates, are skipped, and the point at infinity is returned as nil.
+func MultiScalarMult(xs, ys []*big.Int, scalars [][]byte) (*big.Int, *big.Int, error) {
+	if len(xs) != len(ys) || len(xs) != len(scalars) {
+		return nil, nil, ErrMultiExpInputLen
+	}
+	points := make([]byte, 0, 64*len(xs))
+	packed := make([]byte, 0, 32*len(xs))
+	for i := range xs {
+		if xs[i] == nil || ys[i] == nil {
+			continue
+		}
+		if len(scalars[i]) > 32 {
+			return nil, nil, ErrInvalidScalar
+		}
+		buf := make([]byte, 96)
+		math.ReadBits(xs[i], buf[:32])
+		math.ReadBits(ys[i], buf[32:64])
+		copy(buf[96-len(scalars[i]):], scalars[i])
+		points = append(points, buf[:64]...)
+		packed = append(packed, buf[64:]...)
+	}
+	n := len(points) / 64
+	if n == 0 {
+		return nil, nil, nil
+	}
+	out := make([]byte, 64)
+	res := C.secp256k1_ext_ecmult_multi(context, (*C.uchar)(unsafe.Pointer(&out[0])),
+		(*C.uchar)(unsafe.Pointer(&points[0])), (*C.uchar)(unsafe.Pointer(&packed[0])), C.size_t(n))
+	switch res {
+	case 1:
+		return new(big.Int).SetBytes(out[:32]), new(big.Int).SetBytes(out[32:]), nil
+	case 0:
+		return nil, nil, nil
+	}
+	return nil, nil, ErrInvalidMultiExp
+}
@@ -243,3 +243,95 @@ void commit_rangeproof(zkrp_t *dt) {
     dt->pcommit = pcommit;
 }
 
+
+// secp256k1_ext_load_point parses a 64-byte point, encoded as two 256bit big-endian
+// numbers, and checks that it is on the curve.
+//
+// Returns: 1: the point is valid
+//          0: the coordinates overflow or the point is not on the curve
+static int secp256k1_ext_load_point(secp256k1_ge *ge, const unsigned char *point) {
+	secp256k1_fe feX, feY;
+	if (!secp256k1_fe_set_b32(&feX, point) || !secp256k1_fe_set_b32(&feY, point+32)) {
+		return 0;
+	}
+	secp256k1_ge_set_xy(ge, &feX, &feY);
+	return secp256k1_ge_is_valid_var(ge);
+}
+
+// secp256k1_ext_save_point writes the point r in 64 bytes, unless it is the point
+// at infinity.
+//
+// Returns: 1: the point was written
+//          0: the point is the point at infinity
+static int secp256k1_ext_save_point(unsigned char *point, secp256k1_gej *r) {
+	secp256k1_ge ge;
+	if (secp256k1_gej_is_infinity(r)) {
+		return 0;
+	}
+	secp256k1_ge_set_gej(&ge, r);
+	secp256k1_fe_normalize(&ge.x);
+	secp256k1_fe_normalize(&ge.y);
+	secp256k1_fe_get_b32(point, &ge.x);
+	secp256k1_fe_get_b32(point+32, &ge.y);
+	return 1;
+}
+
+// secp256k1_ext_point_add adds two points in variable time.
+//
+// Returns: 1: the sum was written to out
+//          0: the sum is the point at infinity
+//         -1: one of the points is invalid
+// Args:    out:      the 64-byte sum
+//  In:     a, b:     pointers to 64-byte points, encoded as two 256bit big-endian numbers.
+int secp256k1_ext_point_add(unsigned char *out, const unsigned char *a, const unsigned char *b) {
+	secp256k1_ge ga, gb;
+	secp256k1_gej r;
+	if (!secp256k1_ext_load_point(&ga, a) || !secp256k1_ext_load_point(&gb, b)) {
+		return -1;
+	}
+	secp256k1_gej_set_ge(&r, &ga);
+	secp256k1_gej_add_ge_var(&r, &r, &gb, NULL);
+	return secp256k1_ext_save_point(out, &r);
+}
+
+typedef struct {
+	const unsigned char *points;
+	const unsigned char *scalars;
+} secp256k1_ext_multi_data;
+
+static int secp256k1_ext_multi_callback(secp256k1_scalar *sc, secp256k1_ge *pt, size_t idx, void *cbdata) {
+	secp256k1_ext_multi_data *data = (secp256k1_ext_multi_data *) cbdata;
+	int overflow = 0;
+	secp256k1_scalar_set_b32(sc, data->scalars + 32*idx, &overflow);
+	if (overflow) {
+		return 0;
+	}
+	return secp256k1_ext_load_point(pt, data->points + 64*idx);
+}
+
+// secp256k1_ext_ecmult_multi computes sum_i scalars[i]*points[i] in variable time,
+// using Strauss' or Pippenger's algorithm according to the number of points.
+//
+// Returns: 1: the result was written to out
+//          0: the result is the point at infinity
+//         -1: one of the points or scalars is invalid
+// Args:    ctx:      pointer to a context object built for verification (cannot be NULL)
+//  Out:    out:      the 64-byte result
+//  In:     points:   n 64-byte points, encoded as two 256bit big-endian numbers
+//          scalars:  n 32-byte big-endian scalars, which must be reduced
+int secp256k1_ext_ecmult_multi(const secp256k1_context* ctx, unsigned char *out, const unsigned char *points, const unsigned char *scalars, size_t n) {
+	secp256k1_ext_multi_data data;
+	secp256k1_scratch *scratch;
+	secp256k1_gej r;
+	int ret;
+	data.points = points;
+	data.scalars = scalars;
+	// The scratch space is allocated per call, so that concurrent calls are safe
+	scratch = secp256k1_scratch_create(&ctx->error_callback, 4 * 1024 * 1024);
+	ret = secp256k1_ecmult_multi_var(&ctx->ecmult_ctx, scratch, &r, NULL, secp256k1_ext_multi_callback, &data, n);
+	secp256k1_scratch_destroy(scratch);
+	if (!ret) {
+		return -1;
+	}
+	return secp256k1_ext_save_point(out, &r);
+}
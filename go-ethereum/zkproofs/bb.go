@@ -49,7 +49,7 @@ func keygen() (keypair, error) {
 		return kp, e
 	}
 	kp.pubk, res = new(bn256.G1).Unmarshal(new(bn256.G1).ScalarBaseMult(kp.privk).Marshal())
-	if res {
+	if !res {
 		return kp, errors.New("Could not compute scalar multiplication.")
 	}
 	return kp, e
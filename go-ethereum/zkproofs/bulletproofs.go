@@ -24,52 +24,67 @@ Asiacrypt 2008
 package zkproofs
 
 import (
-	"bytes"
-	"math"
 	"math/big"
-	"crypto/rand"
-	"crypto/sha256"
-	"github.com/ing-bank/zkproofs/go-ethereum/byteconversion"
 	"errors"
 	"encoding/json"
-	"io/ioutil"
 )
 
 var (
 	ORDER = CURVE.N 
 	SEEDH = "BulletproofsDoesNotNeedTrustedSetupH"
 	SEEDU = "BulletproofsDoesNotNeedTrustedSetupU"
-	SAVE = true
 )
 
 /*
-Bulletproofs parameters.
+Bulletproofs parameters. They are not modified after Setup: the state of each
+proof, such as the generators h', is kept in a copy of Zkip, while the inner
+product of the inner product argument is passed as an argument. Then the same parameters may be shared by
+concurrent calls of Prove and Verify.
 */
 type bp struct {
 	N int64
-	G *p256
-	H *p256
-	Gg []*p256
-	Hh []*p256
+	A int64
+	B int64
+	// Group over which the proofs are computed, secp256k1 if nil
+	Group Group `json:"-"`
+	G Element
+	H Element
+	Gg []Element
+	Hh []Element
 	Zkip bip
 }
 
 /*
-Bulletproofs proof.
+Bulletproofs proof that V commits to a secret x in the interval [a,b).
+Following the same approach as in CCS08, it is composed by two proofs that 
+x-b+2^N and x-a belong to [0,2^N). The commitments to these values are 
+computed by the verifier from V.
 */
 type proofBP struct {
-	V *p256
-	A *p256
-	S *p256
-	T1 *p256
-	T2 *p256
+	V Element
+	P1 proofBPUL
+	P2 proofBPUL
+}
+
+/*
+Bulletproofs proof that a committed value belongs to the interval [0,2^N).
+*/
+type proofBPUL struct {
+	A Element
+	S Element
+	T1 Element
+	T2 Element
 	Taux *big.Int
 	Mu *big.Int
 	Tprime *big.Int
 	Proofip proofBip
-	Commit *p256
 }
 
+/*
+The JSON encoding below is the format of the files written by DirStore. Points
+are encoded by their coordinates, then it only supports secp256k1. The canonical
+binary encoding of encoding.go supports every group.
+*/
 type (
 	pstring struct {
 		X string
@@ -82,31 +97,126 @@ type (
 		N int64
 		A string
 		B string
-		U pstring
-		P pstring
-		Gg pstring
-		Hh pstring
 		Ls []pstring
 		Rs []pstring
 	}
 )
 
+/*
+jsonPoint returns the point of secp256k1 of the element, or an error if the
+element belongs to another group.
+*/
+func jsonPoint(e Element) (*p256, error) {
+	p, ok := e.(*p256)
+	if !ok {
+		return nil, errors.New("JSON encoding is only supported for secp256k1.")
+	}
+	return p, nil
+}
+
+/*
+toPstring returns the coordinates of the element in base 10.
+*/
+func toPstring(e Element) (pstring, error) {
+	p, err := jsonPoint(e)
+	if err != nil {
+		return pstring{}, err
+	}
+	return pstring{X: p.X.String(), Y: p.Y.String()}, nil
+}
+
+/*
+fromPstring returns the point of secp256k1 with the coordinates in base 10.
+*/
+func fromPstring(s pstring) (*p256) {
+	x, _ := new(big.Int).SetString(s.X, 10)
+	y, _ := new(big.Int).SetString(s.Y, 10)
+	return &p256{X: x, Y: y}
+}
+
+/*
+toPstrings returns the coordinates of the elements in base 10.
+*/
+func toPstrings(es []Element) ([]pstring, error) {
+	var (
+		i int
+		err error
+	)
+	result := make([]pstring, len(es))
+	for i=0; i<len(es); i++ {
+		result[i], err = toPstring(es[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+/*
+fromPstrings returns the points of secp256k1 with the coordinates in base 10.
+*/
+func fromPstrings(s []pstring) ([]Element) {
+	var (
+		i int
+	)
+	result := make([]Element, len(s))
+	for i=0; i<len(s); i++ {
+		result[i] = fromPstring(s[i])
+	}
+	return result
+}
+
 func (p *proofBP) MarshalJSON() ([]byte, error) {
 	type Alias proofBP
-	var iLs []pstring
-	var iRs []pstring
-	var i int
-	logn := len(p.Proofip.Ls)
-	iLs = make([]pstring, logn)
-	iRs = make([]pstring, logn)
-	i = 0
-	for i < logn {
-		iLs[i] = pstring{X: p.Proofip.Ls[i].X.String(), Y: p.Proofip.Ls[i].Y.String()} 
-		iRs[i] = pstring{X: p.Proofip.Rs[i].X.String(), Y: p.Proofip.Rs[i].Y.String()} 
-		i = i + 1;
+	V, err := toPstring(p.V)
+	if err != nil {
+		return nil, err
 	}
 	return json.Marshal(&struct {
 		V pstring `json:"V"`
+		*Alias
+	}{
+		V: V,
+		Alias:    (*Alias)(p),
+	})
+}
+
+func (p *proofBP) UnmarshalJSON(data []byte) error {
+	type Alias proofBP
+	aux := &struct {
+		V pstring `json:"V"`
+		*Alias
+	}{
+		Alias: (*Alias)(p),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}	
+	p.V = fromPstring(aux.V)
+	return p.validate(SECP256K1)
+}
+
+func (p *proofBPUL) MarshalJSON() ([]byte, error) {
+	type Alias proofBPUL
+	var (
+		points [4]pstring
+		err error
+	)
+	for i, e := range []Element{p.A, p.S, p.T1, p.T2} {
+		points[i], err = toPstring(e)
+		if err != nil {
+			return nil, err
+		}
+	}
+	iLs, err := toPstrings(p.Proofip.Ls)
+	if err != nil {
+		return nil, err
+	}
+	iRs, err := toPstrings(p.Proofip.Rs)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&struct {
 		A pstring `json:"A"`
 		S pstring `json:"S"`
 		T1 pstring `json:"T1"`
@@ -114,27 +224,20 @@ func (p *proofBP) MarshalJSON() ([]byte, error) {
 		Taux string `json:"Taux"`
 		Mu string `json:"Mu"`
 		Tprime string `json:"Tprime"`
-		Commit pstring `json:"Commit"`
 		Proofip ipstring `json:"Proofip"`
 		*Alias
 	}{
-		V: pstring{X: p.V.X.String(), Y: p.V.Y.String()},
-		A: pstring{X: p.A.X.String(), Y: p.A.Y.String()},
-		S: pstring{X: p.S.X.String(), Y: p.S.Y.String()},
-		T1: pstring{X: p.T1.X.String(), Y: p.T1.Y.String()},
-		T2: pstring{X: p.T2.X.String(), Y: p.T2.Y.String()},
+		A: points[0],
+		S: points[1],
+		T1: points[2],
+		T2: points[3],
 		Mu: p.Mu.String(),
 		Taux: p.Taux.String(),
 		Tprime: p.Tprime.String(),
-		Commit: pstring{X: p.Commit.X.String(), Y: p.Commit.Y.String()},
 		Proofip: ipstring{
 			N: p.Proofip.N,
 			A: p.Proofip.A.String(),
 			B: p.Proofip.B.String(),
-			U: pstring{X: p.Proofip.U.X.String(), Y: p.Proofip.U.Y.String()},
-			P: pstring{X: p.Proofip.P.X.String(), Y: p.Proofip.P.Y.String()},
-			Gg: pstring{X: p.Proofip.Gg.X.String(), Y: p.Proofip.Gg.Y.String()},
-			Hh: pstring{X: p.Proofip.Hh.X.String(), Y: p.Proofip.Hh.Y.String()},
 			Ls: iLs,
 			Rs: iRs,
 		},
@@ -143,10 +246,9 @@ func (p *proofBP) MarshalJSON() ([]byte, error) {
 }
 
 
-func (p *proofBP) UnmarshalJSON(data []byte) error {
-	type Alias proofBP
+func (p *proofBPUL) UnmarshalJSON(data []byte) error {
+	type Alias proofBPUL
 	aux := &struct {
-		V pstring `json:"V"`
 		A pstring `json:"A"`
 		S pstring `json:"S"`
 		T1 pstring `json:"T1"`
@@ -154,7 +256,6 @@ func (p *proofBP) UnmarshalJSON(data []byte) error {
 		Taux string `json:"Taux"`
 		Mu string `json:"Mu"`
 		Tprime string `json:"Tprime"`
-		Commit pstring `json:"Commit"`
 		Proofip ipstring `json:"Proofip"`
 		*Alias
 	}{
@@ -163,102 +264,21 @@ func (p *proofBP) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}	
-	valVX, _ := new(big.Int).SetString(aux.V.X, 10)
-	valVY, _ := new(big.Int).SetString(aux.V.Y, 10)
-	valAX, _ := new(big.Int).SetString(aux.A.X, 10)
-	valAY, _ := new(big.Int).SetString(aux.A.Y, 10)
-	valSX, _ := new(big.Int).SetString(aux.S.X, 10)
-	valSY, _ := new(big.Int).SetString(aux.S.Y, 10)
-	valT1X, _ := new(big.Int).SetString(aux.T1.X, 10)
-	valT1Y, _ := new(big.Int).SetString(aux.T1.Y, 10)
-	valT2X, _ := new(big.Int).SetString(aux.T2.X, 10)
-	valT2Y, _ := new(big.Int).SetString(aux.T2.Y, 10)
-	valCommitX, _ := new(big.Int).SetString(aux.Commit.X, 10)
-	valCommitY, _ := new(big.Int).SetString(aux.Commit.Y, 10)
-	valN := aux.Proofip.N
-	valA, _ := new(big.Int).SetString(aux.Proofip.A, 10)
-	valB, _ := new(big.Int).SetString(aux.Proofip.B, 10)
-	valUx, _ := new(big.Int).SetString(aux.Proofip.U.X, 10)
-	valUy, _ := new(big.Int).SetString(aux.Proofip.U.Y, 10)
-	valPx, _ := new(big.Int).SetString(aux.Proofip.P.X, 10)
-	valPy, _ := new(big.Int).SetString(aux.Proofip.P.Y, 10)
-	valGgx, _ := new(big.Int).SetString(aux.Proofip.Gg.X, 10)
-	valGgy, _ := new(big.Int).SetString(aux.Proofip.Gg.Y, 10)
-	valHhx, _ := new(big.Int).SetString(aux.Proofip.Hh.X, 10)
-	valHhy, _ := new(big.Int).SetString(aux.Proofip.Hh.Y, 10)
-	p.V = &p256{
-		X: valVX,
-		Y: valVY,
-	}
-	p.A = &p256{
-		X: valAX,
-		Y: valAY,
-	}
-	p.S = &p256{
-		X: valSX,
-		Y: valSY,
-	}
-	p.T1 = &p256{
-		X: valT1X,
-		Y: valT1Y,
-	}
-	p.T2 = &p256{
-		X: valT2X,
-		Y: valT2Y,
-	}
-	p.Commit = &p256{
-		X: valCommitX,
-		Y: valCommitY,
-	}
-	valU := &p256{
-		X: valUx,
-		Y: valUy,
-	}
-	valP := &p256{
-		X: valPx,
-		Y: valPy,
-	}
-	valGg := &p256{
-		X: valGgx,
-		Y: valGgy,
-	}
-	valHh := &p256{
-		X: valHhx,
-		Y: valHhy,
-	}
+	p.A = fromPstring(aux.A)
+	p.S = fromPstring(aux.S)
+	p.T1 = fromPstring(aux.T1)
+	p.T2 = fromPstring(aux.T2)
 	p.Taux, _ = new(big.Int).SetString(aux.Taux, 10)
 	p.Mu, _ = new(big.Int).SetString(aux.Mu, 10)
 	p.Tprime, _ = new(big.Int).SetString(aux.Tprime, 10)
-	logn := len(aux.Proofip.Ls)
-	valLs := make([]*p256, logn)
-	valRs := make([]*p256, logn)
-	var (
-		i int
-		valLsx *big.Int
-		valLsy *big.Int
-		valRsx *big.Int
-		valRsy *big.Int
-	)
-	i = 0
-	for (i < logn) {
-		valLsx, _ = new(big.Int).SetString(aux.Proofip.Ls[i].X, 10)
-		valLsy, _ = new(big.Int).SetString(aux.Proofip.Ls[i].Y, 10)
-		valLs[i] = &p256{X: valLsx, Y: valLsy}
-		valRsx, _ = new(big.Int).SetString(aux.Proofip.Rs[i].X, 10)
-		valRsy, _ = new(big.Int).SetString(aux.Proofip.Rs[i].Y, 10)
-		valRs[i] = &p256{X: valRsx, Y: valRsy}
-		i = i + 1
-	}
+	valA, _ := new(big.Int).SetString(aux.Proofip.A, 10)
+	valB, _ := new(big.Int).SetString(aux.Proofip.B, 10)
 	p.Proofip = proofBip{
-		N: valN,
+		N: aux.Proofip.N,
 		A: valA,
 		B: valB,
-		U: valU,
-		P: valP,
-		Gg: valGg,
-		Hh: valHh,
-		Ls: valLs,
-		Rs: valRs,
+		Ls: fromPstrings(aux.Proofip.Ls),
+		Rs: fromPstrings(aux.Proofip.Rs),
 	}
 	return nil
 }
@@ -266,43 +286,72 @@ func (p *proofBP) UnmarshalJSON(data []byte) error {
 type (
 	ipgenstring struct {
 		N int64
-		Cc string
 		Uu pstring
 		H pstring
 		Gg []pstring
 		Hh []pstring
-		P pstring
 	}
 )
 
 
 func (s *bp) MarshalJSON() ([]byte, error) {
 	type Alias bp
-	var iHh []pstring
-	var iGg []pstring
-
-	var i int
-	n := len(s.Gg)
-	iGg = make([]pstring, n)
-	iHh = make([]pstring, n)
-	i = 0
-	for i < n {
-		iGg[i] = pstring{X: s.Zkip.Gg[i].X.String(), Y: s.Zkip.Gg[i].Y.String()} 
-		iHh[i] = pstring{X: s.Zkip.Hh[i].X.String(), Y: s.Zkip.Hh[i].Y.String()} 
-		i = i + 1;
+	var (
+		err error
+		i int
+		G, H *p256
+		Uu, iH pstring
+		iGg, iHh []pstring
+	)
+	Gg := make([]*p256, len(s.Gg))
+	Hh := make([]*p256, len(s.Hh))
+	for i=0; i<len(s.Gg); i++ {
+		Gg[i], err = jsonPoint(s.Gg[i])
+		if err != nil {
+			return nil, err
+		}
+		Hh[i], err = jsonPoint(s.Hh[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	G, err = jsonPoint(s.G)
+	if err == nil {
+		H, err = jsonPoint(s.H)
+	}
+	if err == nil {
+		Uu, err = toPstring(s.Zkip.Uu)
+	}
+	if err == nil {
+		iH, err = toPstring(s.Zkip.H)
+	}
+	if err == nil {
+		iGg, err = toPstrings(s.Zkip.Gg)
+	}
+	if err == nil {
+		iHh, err = toPstrings(s.Zkip.Hh)
+	}
+	if err != nil {
+		return nil, err
 	}
 	return json.Marshal(&struct {
+		G *p256 `json:"G"`
+		H *p256 `json:"H"`
+		Gg []*p256 `json:"Gg"`
+		Hh []*p256 `json:"Hh"`
 		Zkip ipgenstring `json:"Zkip"`
 		*Alias
 	}{
+		G: G,
+		H: H,
+		Gg: Gg,
+		Hh: Hh,
 		Zkip: ipgenstring{
 			N: s.N,
-			Cc: s.Zkip.Cc.String(),
-			Uu: pstring{X: s.Zkip.Uu.X.String(), Y: s.Zkip.Uu.Y.String()},
-			H: pstring{X: s.Zkip.H.X.String(), Y: s.Zkip.H.Y.String()},
+			Uu: Uu,
+			H: iH,
 			Gg: iGg,
 			Hh: iHh,
-			P: pstring{X: s.Zkip.P.X.String(), Y: s.Zkip.P.Y.String()},
 		},
 		Alias:    (*Alias)(s),
 	})
@@ -311,6 +360,10 @@ func (s *bp) MarshalJSON() ([]byte, error) {
 func (s *bp) UnmarshalJSON(data []byte) error {
 	type Alias bp
 	aux := &struct {
+		G *p256 `json:"G"`
+		H *p256 `json:"H"`
+		Gg []*p256 `json:"Gg"`
+		Hh []*p256 `json:"Hh"`
 		Zkip ipgenstring `json:"Zkip"`
 		*Alias
 	}{
@@ -319,56 +372,23 @@ func (s *bp) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}	
-	n := aux.N
-	valGg := make([]*p256, n)
-	valHh := make([]*p256, n)
-	var (
-		i int64
-		valGgx *big.Int
-		valGgy *big.Int
-		valHhx *big.Int
-		valHhy *big.Int
-	)
-	i = 0
-	for (i < n) {
-		valGgx, _ = new(big.Int).SetString(aux.Zkip.Gg[i].X, 10)
-		valGgy, _ = new(big.Int).SetString(aux.Zkip.Gg[i].Y, 10)
-		valGg[i] = &p256{X: valGgx, Y: valGgy}
-		valHhx, _ = new(big.Int).SetString(aux.Zkip.Hh[i].X, 10)
-		valHhy, _ = new(big.Int).SetString(aux.Zkip.Hh[i].Y, 10)
-		valHh[i] = &p256{X: valHhx, Y: valHhy}
-		i = i + 1
-	}
-	valN := aux.N
-	valCc, _ := new(big.Int).SetString(aux.Zkip.Cc, 10)
-	valUux, _ := new(big.Int).SetString(aux.Zkip.Uu.X, 10)
-	valUuy, _ := new(big.Int).SetString(aux.Zkip.Uu.Y, 10)
-	valHx, _ := new(big.Int).SetString(aux.Zkip.H.X, 10)
-	valHy, _ := new(big.Int).SetString(aux.Zkip.H.Y, 10)
-	valPx, _ := new(big.Int).SetString(aux.Zkip.P.X, 10)
-	valPy, _ := new(big.Int).SetString(aux.Zkip.P.Y, 10)
-	valUu := &p256{
-		X: valUux,
-		Y: valUuy,
-	}
-	valH := &p256{
-		X: valHx,
-		Y: valHy,
-	}
-	valP := &p256{
-		X: valPx,
-		Y: valPy,
+	if aux.G == nil || aux.H == nil || int64(len(aux.Zkip.Gg)) != aux.N || int64(len(aux.Zkip.Hh)) != aux.N {
+		return errors.New("Missing generators in the parameters.")
 	}
+	s.Group = SECP256K1
+	s.G = aux.G
+	s.H = aux.H
+	s.Gg = Elements(aux.Gg)
+	s.Hh = Elements(aux.Hh)
 	s.Zkip = bip{
-		N: valN,
-		Cc: valCc,
-		Uu: valUu,
-		H: valH,
-		Gg: valGg,
-		Hh: valHh,
-		P: valP,
+		N: aux.N,
+		Uu: fromPstring(aux.Zkip.Uu),
+		H: fromPstring(aux.Zkip.H),
+		Gg: fromPstrings(aux.Zkip.Gg),
+		Hh: fromPstrings(aux.Zkip.Hh),
+		Group: SECP256K1,
 	}
-	return nil
+	return s.Validate()
 }
  
 /*
@@ -515,7 +535,7 @@ VectorECMul computes vector EC addition componentwisely.
 */
 func VectorECAdd(a,b []*p256) ([]*p256, error) {
 	var (
-		result []*p256
+		result []jacobian
 		i,n,m int64
 	)
 	n = int64(len(a))
@@ -523,13 +543,16 @@ func VectorECAdd(a,b []*p256) ([]*p256, error) {
 	if (n != m) {
 		return nil, errors.New("Size of first argument is different from size of second argument.")
 	}
-	result = make([]*p256, n)
+	va := affineVector(a)
+	vb := affineVector(b)
+	result = make([]jacobian, n)
 	i = 0
 	for i<n {
-		result[i] = new(p256).Multiply(a[i], b[i])	
+		result[i].setAffine(&va[i])
+		result[i].addAffine(&result[i], &vb[i])
 		i = i + 1
 	}
-	return result, nil
+	return p256Vector(batchToAffine(result)), nil
 }
 /*
 ScalarProduct return the inner product between a and b.
@@ -559,22 +582,7 @@ func ScalarProduct(a, b []*big.Int) (*big.Int, error) {
 VectorExp computes Prod_i^n{a[i]^b[i]}.
 */
 func VectorExp(a []*p256, b []*big.Int) (*p256, error) {
-	var (
-		result *p256
-		i,n,m int64
-	)
-	n = int64(len(a))
-	m = int64(len(b))
-	if (n != m) {
-		return nil, errors.New("Size of first argument is different from size of second argument.")
-	}
-	i = 0
-	result = new(p256).SetInfinity()
-	for i<n {
-		result.Multiply(result, new(p256).ScalarMult(a[i], b[i]))	
-		i = i + 1
-	}
-	return result, nil
+	return MultiExp(a, b)
 }
 
 /*
@@ -582,17 +590,17 @@ VectorScalarExp computes a[i]^b for each i.
 */
 func VectorScalarExp(a []*p256, b *big.Int) ([]*p256, error) {
 	var (
-		result []*p256
+		scalars []scalar
 		i,n int64
 	)
 	n = int64(len(a))
-	result = make([]*p256, n)
+	scalars = make([]scalar, n)
 	i = 0
 	for i<n {
-		result[i] = new(p256).ScalarMult(a[i], b)	
+		scalars[i].setBig(b)
 		i = i + 1
 	}
-	return result, nil
+	return p256Vector(mulEach(affineVector(a), scalars)), nil
 }
 
 /*
@@ -639,126 +647,54 @@ func ComputeAR(x []int64) ([]int64, error) {
 }
 
 /*
-Hash is responsible for the computing a Zp element given elements from GT and G1.
+commitVector computes H^alpha.g^aL.h^aR over the group grp. Since the vectors and
+alpha are usually secret, the commitment is computed in constant time.
 */
-func HashBP(A, S *p256) (*big.Int, *big.Int, error) {
-
-	digest1 := sha256.New()
-	var buffer bytes.Buffer
-	buffer.WriteString(A.X.String())
-	buffer.WriteString(A.Y.String())
-	buffer.WriteString(S.X.String())
-	buffer.WriteString(S.Y.String())
-	digest1.Write([]byte(buffer.String()))
-	output1 := digest1.Sum(nil)
-	tmp1 := output1[0: len(output1)]
-	result1 := new(big.Int).SetBytes(tmp1)
-	
-	digest2 := sha256.New()
-	var buffer2 bytes.Buffer
-	buffer2.WriteString(A.X.String())
-	buffer2.WriteString(A.Y.String())
-	buffer2.WriteString(S.X.String())
-	buffer2.WriteString(S.Y.String())
-	buffer2.WriteString(result1.String())
-	digest2.Write([]byte(buffer2.String()))
-	output2 := digest2.Sum(nil)
-	tmp2 := output2[0: len(output2)]
-	result2 := new(big.Int).SetBytes(tmp2)
-	
-	return result1, result2, nil
+func commitVector(grp Group, H Element, g,h []Element, aL,aR []*big.Int, alpha *big.Int) (Element, error) {
+	var (
+		points []Element
+		scalars []*big.Int
+	)
+	points = append(points, g...)
+	points = append(points, h...)
+	points = append(points, H)
+	scalars = append(scalars, aL...)
+	scalars = append(scalars, aR...)
+	scalars = append(scalars, alpha)
+	return grp.MultiExpCT(points, scalars)
 }
 
 /*
-Commitvector computes a commitment to the bit of the secret. 
+Commitvector computes a commitment to the bit of the secret. Since the bits and
+alpha are secret, the commitment is computed in constant time.
 */
 func CommitVector(aL,aR []int64, alpha *big.Int, G,H *p256, g,h []*p256, n int64) (*p256, error) {
-	var (
-		i int64
-		R *p256
-	)
-	// Compute h^alpha.vg^aL.vh^aR
-	R = new(p256).ScalarMult(H, alpha)
-	i = 0
-	for i<n {
-		gaL := new(p256).ScalarMult(g[i], new(big.Int).SetInt64(aL[i]))
-		haR := new(p256).ScalarMult(h[i], new(big.Int).SetInt64(aR[i]))
-		R.Multiply(R, gaL)
-		R.Multiply(R, haR)
-		i = i + 1
-	}
-	return R, nil
+	naL, _ := VectorConvertToBig(aL, n)
+	naR, _ := VectorConvertToBig(aR, n)
+	return CommitVectorBig(naL, naR, alpha, G, H, g, h, n)
 }
 
 /*
-
+CommitVectorBig is the same as CommitVector, but the vectors contain big integers.
 */
 func CommitVectorBig(aL,aR []*big.Int, alpha *big.Int, G,H *p256, g,h []*p256, n int64) (*p256, error) {
-	var (
-		i int64
-		R *p256
-	)
 	// Compute h^alpha.vg^aL.vh^aR
-	R = new(p256).ScalarMult(H, alpha)
-	i = 0
-	for i<n {
-		R.Multiply(R, new(p256).ScalarMult(g[i], aL[i]))
-		R.Multiply(R, new(p256).ScalarMult(h[i], aR[i]))
-		i = i + 1
-	}
-	return R, nil
-}
-
-/*
-SaveToDisk is responsible for saving the generator to disk, such it is possible
-to then later.
-*/
-func (zkrp *bp) SaveToDisk(s string, p *proofBP) (error) {
-        data, err := json.Marshal(zkrp)
-	errw := ioutil.WriteFile(s, data, 0644)
-	if p != nil {
-		datap, errp := json.Marshal(p)
-		errpw := ioutil.WriteFile("proof.dat", datap, 0644)
-		if errp != nil || errpw != nil {
-			return errors.New("proof not saved to disk.")
-		}
-	}
-	if err != nil || errw != nil {
-		return errors.New("parameters not saved to disk.")
-	}
-	return nil
-}
-
-/*
-LoadGenFromDisk reads the generator from a file. 
-*/
-func LoadParamFromDisk(s string) (*bp, error) {
-	var result bp
-	c, err := ioutil.ReadFile(s)
+	C, err := commitVector(SECP256K1, H, Elements(g[:n]), Elements(h[:n]), aL[:n], aR[:n], alpha)
 	if err != nil {
 		return nil, err
 	}
-	if len(c) > 0 {
-		json.Unmarshal(c, &result)
-		return &result, nil
-	}
-	return nil, errors.New("Could not load generators.")
+	return toP256(C), nil
 }
 
 /*
-LoadProofFromDisk reads the generator from a file. 
+group returns the group of the parameters, which is secp256k1 unless another
+group was chosen before calling Setup.
 */
-func LoadProofFromDisk(s string) (*proofBP, error) {
-	var result proofBP
-	c, err := ioutil.ReadFile(s)
-	if err != nil {
-		return nil, err
-	}
-	if len(c) > 0 {
-		json.Unmarshal(c, &result)
-		return &result, nil
+func (zkrp *bp) group() (Group) {
+	if zkrp.Group == nil {
+		return SECP256K1
 	}
-	return nil, errors.New("Could not load proof.")
+	return zkrp.Group
 }
 
 /*
@@ -768,209 +704,428 @@ func (zkrp *bp) Delta(y, z *big.Int) (*big.Int, error) {
 	var (
 		result *big.Int
 	)
+	f := field(zkrp.group())
+	q := f.Order()
 	// delta(y,z) = (z-z^2) . < 1^n, y^n > - z^3 . < 1^n, 2^n >
 	z2 := Multiply(z, z)
-	z2 = Mod(z2, ORDER) 
+	z2 = Mod(z2, q) 
 	z3 := Multiply(z2, z)
-	z3 = Mod(z3, ORDER)
+	z3 = Mod(z3, q)
 
 	// < 1^n, y^n >
 	v1, _ := VectorCopy(new(big.Int).SetInt64(1), zkrp.N)
-	vy, _ := PowerOf(y, zkrp.N) 
-	sp1y, _ := ScalarProduct(v1, vy)
+	vy := f.powerOf(y, zkrp.N) 
+	sp1y, _ := f.scalarProduct(v1, vy)
 
 	// < 1^n, 2^n >
-	p2n, _ := PowerOf(new(big.Int).SetInt64(2), zkrp.N)
-	sp12, _ := ScalarProduct(v1, p2n)
+	p2n := f.powerOf(new(big.Int).SetInt64(2), zkrp.N)
+	sp12, _ := f.scalarProduct(v1, p2n)
 
 	result = Sub(z, z2)
-	result = Mod(result, ORDER)
+	result = Mod(result, q)
 	result = Multiply(result, sp1y)
-	result = Mod(result, ORDER)
+	result = Mod(result, q)
 	result = Sub(result, Multiply(z3, sp12))
-	result = Mod(result, ORDER)
+	result = Mod(result, q)
 
 	return result, nil
 }
 
 /* 
-SetupPre is responsible for computing the common parameters. 
+SetupPre is responsible for computing the common parameters from the precomputed 
+parameters saved in the file s, which are checked against the derivation of the 
+generators when loaded. The precomputed generators may be more than needed for the 
+interval [a,b), in which case only the first N generators are used.
 */
-func (zkrp *bp) SetupPre(a,b int64) {
-	res, _ := LoadParamFromDisk("setup.dat")
-	zkrp = res
+func (zkrp *bp) SetupPre(a,b int64, s string) (error) {
+	if a >= b {
+		return errors.New("a must be less than b")
+	}
+	res, err := LoadParamFromDisk(s)
+	if err != nil {
+		return err
+	}
+	n := rangeSize(a, b)
+	if res.N < n {
+		return errors.New("Not enough precomputed generators for the interval.")
+	}
+	zkrp.A = a
+	zkrp.B = b
+	zkrp.N = n
+	zkrp.Group = res.group()
+	zkrp.G = res.G
+	zkrp.H = res.H
+	zkrp.Gg = res.Gg[:n]
+	zkrp.Hh = res.Hh[:n]
+
 	// Setup Inner Product
-	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh, new(big.Int).SetInt64(0))
+	zkrp.Zkip.Group = zkrp.Group
+	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	return nil
+}
+
+/*
+rangeSize returns the smallest power of 2, N, such that b-a <= 2^N.
+*/
+func rangeSize(a,b int64) (int64) {
+	var (
+		n int64
+	)
+	ba := Sub(new(big.Int).SetInt64(b), new(big.Int).SetInt64(a))
+	n = 1
+	for new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(n)).Cmp(ba) < 0 {
+		n = 2 * n
+	}
+	return n
 }
 
 /* 
 Setup is responsible for computing the common parameters. 
+The range proof is done over the interval [a,b), then N is the smallest power 
+of 2 such that b-a <= 2^N. The upper bound b is excluded, e.g. the ages from 18
+to 65 are proven with Setup(18, 66). The proofs are computed over zkrp.Group, which may
+be set before calling Setup, and otherwise is secp256k1.
 */
-func (zkrp *bp) Setup(a,b int64) {
+func (zkrp *bp) Setup(a,b int64) (error) {
 	var (
-		i int64
+		err error
 	)
-
-	zkrp.G = new(p256).ScalarBaseMult(new(big.Int).SetInt64(1))
-	zkrp.H, _ = MapToGroup(SEEDH)
-	zkrp.N = int64(math.Log2(float64(b)))
-	zkrp.Gg = make([]*p256, zkrp.N)
-	zkrp.Hh = make([]*p256, zkrp.N)
-	i = 0
-	for i<zkrp.N {
-		zkrp.Gg[i], _ = MapToGroup(SEEDH+"g"+string(i)); 
-		zkrp.Hh[i], _ = MapToGroup(SEEDH+"h"+string(i)); 
-		i = i + 1
+	if a >= b {
+		return errors.New("a must be less than b")
+	}
+	grp := zkrp.group()
+	zkrp.A = a
+	zkrp.B = b
+	zkrp.N = rangeSize(a, b)
+	zkrp.Group = grp
+	zkrp.G = grp.Generator()
+	zkrp.H, _, err = groupHU(grp)
+	if err != nil {
+		return err
+	}
+	zkrp.Gg, zkrp.Hh, err = groupGenerators(grp, zkrp.N)
+	if err != nil {
+		return err
 	}
-	//zkrp.SaveToDisk("setup.dat", nil)
 
 	// Setup Inner Product
-	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh, new(big.Int).SetInt64(0))
-	zkrp.SaveToDisk("setup.dat", nil)
+	zkrp.Zkip.Group = grp
+	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	return nil
+}
+
+/*
+ShiftCommitment computes the commitments to x-b+2^N and x-a, given the commitment 
+V to x, namely V.g^(2^N-b) and V.g^(-a). 
+*/
+func (zkrp *bp) ShiftCommitment(V Element) (Element, Element) {
+	grp := zkrp.group()
+	ul := new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N))
+	ulb := Sub(ul, new(big.Int).SetInt64(zkrp.B))
+	Vb := grp.Add(V, grp.ScalarMult(zkrp.G, ulb))
+	ma := Sub(new(big.Int).SetInt64(0), new(big.Int).SetInt64(zkrp.A))
+	Va := grp.Add(V, grp.ScalarMult(zkrp.G, ma))
+	return Vb, Va
+}
+
+/*
+newTranscript returns the transcript of the range proof, which binds the interval
+[a,b), N and the generators, so that the challenges depend on the statement. The
+generators also bind the group.
+*/
+func (zkrp *bp) newTranscript() (*Transcript) {
+	t := NewTranscript("Bulletproofs range proof")
+	t.AppendInt64("a", zkrp.A)
+	t.AppendInt64("b", zkrp.B)
+	t.AppendInt64("N", zkrp.N)
+	t.AppendPoint("G", zkrp.G)
+	t.AppendPoint("H", zkrp.H)
+	t.AppendPoint("U", zkrp.Zkip.Uu)
+	t.AppendElements("Gg", zkrp.Gg)
+	t.AppendElements("Hh", zkrp.Hh)
+	return t
+}
+
+/*
+commit computes the Pedersen commitment g^x.h^r in constant time.
+*/
+func (zkrp *bp) commit(x, r *big.Int) (Element, error) {
+	return zkrp.group().MultiExpCT([]Element{zkrp.G, zkrp.H}, []*big.Int{x, r})
+}
+
+/*
+Commit computes the commitment V = g^secret.h^gamma with a random gamma, and returns
+both V and gamma. It allows a third party, e.g. the issuer of an attribute, to
+commit to the secret and to hand the opening (secret, gamma) to the prover.
+*/
+func (zkrp *bp) Commit(secret *big.Int) (Element, *big.Int, error) {
+	gamma, err := zkrp.group().Scalar().Random()
+	if err != nil {
+		return nil, nil, err
+	}
+	V, err := zkrp.commit(secret, gamma)
+	if err != nil {
+		return nil, nil, err
+	}
+	return V, gamma, nil
 }
 
 /* 
-Prove computes the ZK proof. 
+Prove computes the ZK proof that secret belongs to the interval [a,b). 
 */
 func (zkrp *bp) Prove(secret *big.Int) (proofBP, error) {
+	// commitment to v and gamma
+	gamma, _ := zkrp.group().Scalar().Random()
+	return zkrp.ProveOpening(secret, gamma)
+}
+
+/*
+ProveOpening computes the ZK proof that the commitment V = g^secret.h^gamma commits
+to an element of the interval [a,b), where gamma is chosen by the caller.
+*/
+func (zkrp *bp) ProveOpening(secret, gamma *big.Int) (proofBP, error) {
+	V, _ := zkrp.commit(secret, gamma)
+	return zkrp.ProveCommitment(V, secret, gamma)
+}
+
+/*
+ProveCommitment computes the ZK proof that the existing commitment V commits to an
+element of the interval [a,b), given its opening (secret, gamma).
+*/
+func (zkrp *bp) ProveCommitment(V Element, secret, gamma *big.Int) (proofBP, error) {
+	var (
+		proof proofBP
+	)
+	C, _ := zkrp.commit(secret, gamma)
+	if V == nil || !C.Equals(V) {
+		return proof, errors.New("Commitment does not match the opening.")
+	}
+
+	// Both proofs share the same transcript
+	t := zkrp.newTranscript()
+	Vb, Va := zkrp.ShiftCommitment(V)
+
+	// x - b + 2^N
+	ul := new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N))
+	xb := Sub(secret, new(big.Int).SetInt64(zkrp.B))
+	xb = Add(xb, ul)
+	first, err := zkrp.proveUL(t, xb, gamma, Vb)
+	if err != nil {
+		return proof, err
+	}
+
+	// x - a
+	xa := Sub(secret, new(big.Int).SetInt64(zkrp.A))
+	second, err := zkrp.proveUL(t, xa, gamma, Va)
+	if err != nil {
+		return proof, err
+	}
+
+	proof.V = V
+	proof.P1 = first
+	proof.P2 = second
+
+	return proof, nil
+}
+
+/* 
+Verify returns true if and only if the proof is valid, i.e. if V commits to 
+an element of the interval [a,b).
+*/
+func (zkrp *bp) Verify(proof proofBP) (bool, error) {
+	return zkrp.VerifyCommitment(proof.V, proof)
+}
+
+/*
+VerifyCommitment returns true if and only if the proof shows that the commitment V,
+which is given by the verifier instead of being taken from the proof, commits to
+an element of the interval [a,b).
+*/
+func (zkrp *bp) VerifyCommitment(V Element, proof proofBP) (bool, error) {
+	if err := zkrp.validateVerify(V, &proof.P1, &proof.P2); err != nil {
+		return false, err
+	}
+	t := zkrp.newTranscript()
+	Vb, Va := zkrp.ShiftCommitment(V)
+	first, _ := zkrp.verifyUL(t, Vb, proof.P1)
+	second, _ := zkrp.verifyUL(t, Va, proof.P2)
+	return first && second, nil
+}
+
+/* 
+ProveUL computes the ZK proof that secret belongs to the interval [0,2^N), 
+where gamma is the randomness used to commit to secret. 
+*/
+func (zkrp *bp) ProveUL(secret, gamma *big.Int) (proofBPUL, error) {
+	V, _ := zkrp.commit(secret, gamma)
+	return zkrp.proveUL(zkrp.newTranscript(), secret, gamma, V)
+}
+
+/*
+proveUL computes the proof of ProveUL, where V is the commitment to secret and
+the challenges are drawn from the transcript t.
+*/
+func (zkrp *bp) proveUL(t *Transcript, secret, gamma *big.Int, V Element) (proofBPUL, error) {
 	var (
 		i int64
 		sL []*big.Int
 		sR []*big.Int
-		proof proofBP
+		proof proofBPUL
 	)
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
 	//////////////////////////////////////////////////////////////////////////////
 	// First phase
 	//////////////////////////////////////////////////////////////////////////////
 	
-	// commitment to v and gamma
-	gamma, _ := rand.Int(rand.Reader, ORDER)
-	V, _ := CommitG1(secret, gamma, zkrp.H) 
-
 	// aL, aR and commitment: (A, alpha)
 	aL, _ := Decompose(secret, 2, zkrp.N)	
 	aR, _ := ComputeAR(aL)
-	alpha, _ := rand.Int(rand.Reader, ORDER)
-	A, _ := CommitVector(aL, aR, alpha, zkrp.G, zkrp.H, zkrp.Gg, zkrp.Hh, zkrp.N) 
+	naL, _ := VectorConvertToBig(aL, zkrp.N)
+	naR, _ := VectorConvertToBig(aR, zkrp.N)
+	alpha, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	A, err := commitVector(grp, zkrp.H, zkrp.Gg, zkrp.Hh, naL, naR, alpha) 
+	if err != nil {
+		return proof, err
+	}
 
 	// sL, sR and commitment: (S, rho)
-	rho, _ := rand.Int(rand.Reader, ORDER)
+	rho, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
 	sL = make([]*big.Int, zkrp.N)
 	sR = make([]*big.Int, zkrp.N)
 	i = 0
 	for i<zkrp.N {
-		sL[i], _ = rand.Int(rand.Reader, ORDER)
-		sR[i], _ = rand.Int(rand.Reader, ORDER)
+		if sL[i], err = f.Random(); err != nil {
+			return proof, err
+		}
+		if sR[i], err = f.Random(); err != nil {
+			return proof, err
+		}
 		i = i + 1
 	}
-	S, _ := CommitVectorBig(sL, sR, rho, zkrp.G, zkrp.H, zkrp.Gg, zkrp.Hh, zkrp.N) 
+	S, err := commitVector(grp, zkrp.H, zkrp.Gg, zkrp.Hh, sL, sR, rho) 
+	if err != nil {
+		return proof, err
+	}
 
 	// Fiat-Shamir heuristic to compute challenges y, z
-	y, z, _ := HashBP(A, S)
+	t.AppendPoint("V", V)
+	t.AppendPoint("A", A)
+	t.AppendPoint("S", S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
 
 	//////////////////////////////////////////////////////////////////////////////
 	// Second phase
 	//////////////////////////////////////////////////////////////////////////////
-	tau1, _ := rand.Int(rand.Reader, ORDER) // page 20 from eprint version
-	tau2, _ := rand.Int(rand.Reader, ORDER)
+	tau1, err := f.Random() // page 20 from eprint version
+	if err != nil {
+		return proof, err
+	}
+	tau2, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
 	
 	// compute t1: < aL - z.1^n, y^n . sR > + < sL, y^n . (aR + z . 1^n) > 
 	vz, _ := VectorCopy(z, zkrp.N)
-	vy, _ := PowerOf(y, zkrp.N) 
+	vy := f.powerOf(y, zkrp.N) 
 
 	// aL - z.1^n
-	naL, _ := VectorConvertToBig(aL, zkrp.N)
-	aLmvz, _ := VectorSub(naL, vz)
+	aLmvz, _ := f.vectorSub(naL, vz)
 	
 	// y^n .sR
-	ynsR, _ := VectorMul(vy, sR) 	
+	ynsR, _ := f.vectorMul(vy, sR) 	
 
 	// scalar prod: < aL - z.1^n, y^n . sR >
-	sp1, _ := ScalarProduct(aLmvz, ynsR)
+	sp1, _ := f.scalarProduct(aLmvz, ynsR)
 
 	// scalar prod: < sL, y^n . (aR + z . 1^n) >
-	naR, _ := VectorConvertToBig(aR, zkrp.N)
-	aRzn, _ := VectorAdd(naR, vz)
-	ynaRzn, _ := VectorMul(vy, aRzn) 
+	aRzn, _ := f.vectorAdd(naR, vz)
+	ynaRzn, _ := f.vectorMul(vy, aRzn) 
 
 	// Add z^2.2^n to the result
 	// z^2 . 2^n
-	p2n, _ := PowerOf(new(big.Int).SetInt64(2), zkrp.N)
+	p2n := f.powerOf(new(big.Int).SetInt64(2), zkrp.N)
 	zsquared := Multiply(z, z)
-	z22n, _ := VectorScalarMul(p2n, zsquared)
-	ynaRzn, _ = VectorAdd(ynaRzn, z22n)
-	sp2, _ := ScalarProduct(sL, ynaRzn)
+	z22n := f.vectorScalarMul(p2n, zsquared)
+	ynaRzn, _ = f.vectorAdd(ynaRzn, z22n)
+	sp2, _ := f.scalarProduct(sL, ynaRzn)
 	
 	// sp1 + sp2
 	t1 := Add(sp1, sp2)
-	t1 = Mod(t1, ORDER)
+	t1 = Mod(t1, q)
 	
 
 	// compute t2: < sL, y^n . sR >
-	t2, _ := ScalarProduct(sL, ynsR)
-	t2 = Mod(t2, ORDER)
+	t2, _ := f.scalarProduct(sL, ynsR)
+	t2 = Mod(t2, q)
 
 	// compute T1
-	T1, _ := CommitG1(t1, tau1, zkrp.H)
+	T1, _ := zkrp.commit(t1, tau1)
 
 	// compute T2
-	T2, _ := CommitG1(t2, tau2, zkrp.H)
+	T2, _ := zkrp.commit(t2, tau2)
 
 	// Fiat-Shamir heuristic to compute 'random' challenge x
-	x, _, _ := HashBP(T1, T2)
+	t.AppendPoint("T1", T1)
+	t.AppendPoint("T2", T2)
+	x := t.ChallengeScalar("x", q)
 
 	//////////////////////////////////////////////////////////////////////////////
 	// Third phase                                                              //
 	//////////////////////////////////////////////////////////////////////////////
 
 	// compute bl
-	sLx, _ := VectorScalarMul(sL, x)
-	bl, _ := VectorAdd(aLmvz, sLx)
+	sLx := f.vectorScalarMul(sL, x)
+	bl, _ := f.vectorAdd(aLmvz, sLx)
 
 	// compute br
 	// y^n . ( aR + z.1^n + sR.x )
-	sRx, _ := VectorScalarMul(sR, x)
-	aRzn, _ = VectorAdd(aRzn, sRx)
-	ynaRzn, _ = VectorMul(vy, aRzn) 
+	sRx := f.vectorScalarMul(sR, x)
+	aRzn, _ = f.vectorAdd(aRzn, sRx)
+	ynaRzn, _ = f.vectorMul(vy, aRzn) 
 	// y^n . ( aR + z.1^n sR.x ) + z^2 . 2^n
-	br, _ := VectorAdd(ynaRzn, z22n)
+	br, _ := f.vectorAdd(ynaRzn, z22n)
 
 	// Compute t` = < bl, br >
-	tprime, _ := ScalarProduct(bl, br)
+	tprime, _ := f.scalarProduct(bl, br)
 
 	// Compute taux = tau2 . x^2 + tau1 . x + z^2 . gamma
 	taux := Multiply(tau2, Multiply(x, x))
 	taux = Add(taux, Multiply(tau1, x)) 
 	taux = Add(taux, Multiply(Multiply(z, z), gamma))
-	taux = Mod(taux, ORDER) 
+	taux = Mod(taux, q) 
 
 	// Compute mu = alpha + rho.x
 	mu := Multiply(rho, x)
 	mu = Add(mu, alpha)
-	mu = Mod(mu, ORDER) 
+	mu = Mod(mu, q) 
 
 	// Inner Product over (g, h', P.h^-mu, tprime)
 	// Compute h'
-	hprime := make([]*p256, zkrp.N)
-	// Switch generators
-	yinv := ModInverse(y, ORDER)
-	expy := yinv
-	hprime[0] = zkrp.Hh[0]	
-	i = 1
-	for i<zkrp.N {
-		hprime[i] = new(p256).ScalarMult(zkrp.Hh[i], expy)	
-		expy = Multiply(expy, yinv)
-		i = i + 1
-	}
+	hprime := zkrp.HPrime(y)
 
-	// Update Inner Product Proof Setup
-	zkrp.Zkip.Hh = hprime
-	zkrp.Zkip.Cc = tprime
+	// Update the Inner Product Proof Setup in a copy, which keeps zkrp unchanged
+	zkip := zkrp.Zkip
+	zkip.Hh = hprime
 
-	commit, _ := CommitInnerProduct(zkrp.Gg, hprime, bl, br)
-	proofip, _ := zkrp.Zkip.Prove(bl, br, commit)	
+	// The commitment P = g^bl.h'^br is determined by the transcript
+	t.AppendScalar("taux", taux)
+	t.AppendScalar("mu", mu)
+	t.AppendScalar("tprime", tprime)
+	proofip, err := zkip.prove(t, bl, br)
+	if err != nil {
+		return proof, err
+	}
 
-	proof.V = V
 	proof.A = A
 	proof.S = S
 	proof.T1 = T1
@@ -979,117 +1134,107 @@ func (zkrp *bp) Prove(secret *big.Int) (proofBP, error) {
  	proof.Mu = mu
 	proof.Tprime = tprime
 	proof.Proofip = proofip
-	proof.Commit = commit
 
-	zkrp.SaveToDisk("setup.dat", &proof)
 	return proof, nil
 }
 
+/*
+HPrime computes the generators h'[i] = h[i]^(y^-i).
+*/
+func (zkrp *bp) HPrime(y *big.Int) ([]Element) {
+	grp := zkrp.group()
+	f := field(grp)
+	return mulElements(grp, zkrp.Hh, f.powerOf(f.Inverse(y), int64(len(zkrp.Hh))))
+}
+
 /* 
-Verify returns true if and only if the proof is valid.
+VerifyUL returns true if and only if the proof is valid, i.e. if V commits to 
+an element of the interval [0,2^N).
 */
-func (zkrp *bp) Verify (proof proofBP) (bool, error) {
+func (zkrp *bp) VerifyUL(V Element, proof proofBPUL) (bool, error) {
+	if err := zkrp.validateVerify(V, &proof); err != nil {
+		return false, err
+	}
+	return zkrp.verifyUL(zkrp.newTranscript(), V, proof)
+}
+
+/*
+verifyUL verifies the proof of VerifyUL, drawing the challenges from the transcript t.
+*/
+func (zkrp *bp) verifyUL(t *Transcript, V Element, proof proofBPUL) (bool, error) {
 	var (
 		i int64
-		hprime []*p256
 	)
-	hprime = make([]*p256, zkrp.N)
-	y, z, _ := HashBP(proof.A, proof.S)
-	x, _, _ := HashBP(proof.T1, proof.T2)
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	t.AppendPoint("V", V)
+	t.AppendPoint("A", proof.A)
+	t.AppendPoint("S", proof.S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+	t.AppendPoint("T1", proof.T1)
+	t.AppendPoint("T2", proof.T2)
+	x := t.ChallengeScalar("x", q)
 
 	// Switch generators
-	yinv := ModInverse(y, ORDER)
-	expy := yinv
-	hprime[0] = zkrp.Hh[0]	
-	i = 1
-	for i<zkrp.N {
-		hprime[i] = new(p256).ScalarMult(zkrp.Hh[i], expy)	
-		expy = Multiply(expy, yinv)
-		i = i + 1
-	}
+	hprime := zkrp.HPrime(y)
 
 	//////////////////////////////////////////////////////////////////////////////
 	// Check that tprime  = t(x) = t0 + t1x + t2x^2  ----------  Condition (65) //
 	//////////////////////////////////////////////////////////////////////////////
 	
 	// Compute left hand side
-	lhs, _ := CommitG1(proof.Tprime, proof.Taux, zkrp.H)
+	lhs, _ := grp.MultiExp([]Element{zkrp.G, zkrp.H}, []*big.Int{proof.Tprime, proof.Taux})
 	
-	// Compute right hand side
+	// Compute right hand side: V^(z^2).g^delta.T1^x.T2^(x^2)
 	z2 := Multiply(z, z)
-	z2 = Mod(z2, ORDER) 
+	z2 = Mod(z2, q) 
 	x2 := Multiply(x, x)
-	x2 = Mod(x2, ORDER) 
-
-	rhs := new(p256).ScalarMult(proof.V, z2)
-
+	x2 = Mod(x2, q) 
 	delta, _ := zkrp.Delta(y,z)
+	rhs, _ := grp.MultiExp([]Element{V, zkrp.G, proof.T1, proof.T2}, []*big.Int{z2, delta, x, x2})
 
-	gdelta := new(p256).ScalarBaseMult(delta)
-
-	rhs.Multiply(rhs, gdelta)
-
-	T1x := new(p256).ScalarMult(proof.T1, x) 
-	T2x2 := new(p256).ScalarMult(proof.T2, x2) 
+	c65 := lhs.Equals(rhs) // Condition (65), page 20, from eprint version
 
-	rhs.Multiply(rhs, T1x)
-	rhs.Multiply(rhs, T2x2)
-
-	// Subtract lhs and rhs and compare with poitn at infinity
-	lhs.Neg(lhs)
-	rhs.Multiply(rhs, lhs)
-	c65 := rhs.IsZero() // Condition (65), page 20, from eprint version
-
-	// Compute P - lhs  #################### Condition (66) ######################
-
-	// S^x
-	Sx := new(p256).ScalarMult(proof.S, x)
-	// A.S^x
-	ASx := new(p256).Add(proof.A, Sx)
-
-	// g^-z
-	mz := Sub(ORDER, z)
-	vmz, _ := VectorCopy(mz, zkrp.N)
-	gpmz, _ := VectorExp(zkrp.Gg, vmz)
-	//fmt.Println("############## gpmz ###############")
-	//fmt.Println(gpmz);
+	// Compute A.S^x.g^-z.h'^(z.y^n + z^2.2^n) ########### Condition (66) #######
 
 	// z.y^n
 	vz, _ := VectorCopy(z, zkrp.N)
-	vy, _ := PowerOf(y, zkrp.N) 
-	zyn, _ := VectorMul(vy, vz) 
+	vy := f.powerOf(y, zkrp.N) 
+	zyn, _ := f.vectorMul(vy, vz) 
 
-	p2n, _ := PowerOf(new(big.Int).SetInt64(2), zkrp.N)
+	p2n := f.powerOf(new(big.Int).SetInt64(2), zkrp.N)
 	zsquared := Multiply(z, z)
-	z22n, _ := VectorScalarMul(p2n, zsquared)
+	z22n := f.vectorScalarMul(p2n, zsquared)
 
 	// z.y^n + z^2.2^n
-	zynz22n, _ := VectorAdd(zyn, z22n) 
-	
-	lP := new(p256)
-	lP.Add(ASx, gpmz)
-	
-	// h'^(z.y^n + z^2.2^n)
-	hprimeexp, _ := VectorExp(hprime, zynz22n)
-
-	lP.Add(lP, hprimeexp)
-
-	// Compute P - rhs  #################### Condition (67) ######################
-
-	// h^mu
-	rP := new(p256).ScalarMult(zkrp.H, proof.Mu)
-	rP.Multiply(rP, proof.Commit)
-
-	// Subtract lhs and rhs and compare with poitn at infinity
-	lP = lP.Neg(lP)
-	rP.Add(rP, lP)
-	c67 := rP.IsZero() 
-
+	zynz22n, _ := f.vectorAdd(zyn, z22n) 
+
+	// Compute P = A.S^x.g^-z.h'^(z.y^n + z^2.2^n).h^-mu, which must be equal to
+	// g^l.h'^r ##### Condition (67)
+	// This is not sent by the prover, instead it is the input of the inner product
+	// verification, then Condition (67) holds if the inner product proof is valid.
+	points := []Element{proof.A, proof.S, zkrp.H}
+	scalars := []*big.Int{new(big.Int).SetInt64(1), x, Sub(q, proof.Mu)}
+	mz := Sub(q, z)
+	i = 0
+	for i<zkrp.N {
+		points = append(points, zkrp.Gg[i], hprime[i])
+		scalars = append(scalars, mz, zynz22n[i])
+		i = i + 1
+	}
+	rP, _ := grp.MultiExp(points, scalars)
 
 	// Verify Inner Product Proof ################################################
-	ok, _ := zkrp.Zkip.Verify(proof.Proofip)
+	zkip := zkrp.Zkip
+	zkip.Hh = hprime
+	t.AppendScalar("taux", proof.Taux)
+	t.AppendScalar("mu", proof.Mu)
+	t.AppendScalar("tprime", proof.Tprime)
+	ok, _ := zkip.verifyMultiExp(t, rP, proof.Tprime, proof.Proofip)
 
-	result := c65 && c67 && ok
+	result := c65 && ok
 
 	return result, nil
 }
@@ -1097,105 +1242,127 @@ func (zkrp *bp) Verify (proof proofBP) (bool, error) {
 //////////////////////////////////// Inner Product ////////////////////////////////////
 
 /*
-Base struct for the Inner Product Argument.
+Base struct for the Inner Product Argument. As with bp, the parameters are not
+modified after Setup, then they may be shared by concurrent calls of Prove and
+Verify. The inner product c is not a parameter, it is passed to Prove and Verify
+together with the commitment P.
 */
 type bip struct {
 	N int64
-	Cc *big.Int
-	Uu *p256
-	H *p256
-	Gg []*p256  
-	Hh []*p256  
-	P *p256
+	Uu Element
+	H Element
+	Gg []Element  
+	Hh []Element  
+	// Group of the generators, secp256k1 if nil
+	Group Group
 }
 
 /*
 Struct that contains the Inner Product Proof.
 */
 type proofBip struct {
-	Ls []*p256
-	Rs []*p256
-	U *p256
-	P *p256
-	Gg *p256
-	Hh *p256
+	Ls []Element
+	Rs []Element
 	A *big.Int
 	B *big.Int
 	N int64
 }
 
 /*
-HashIP is responsible for the computing a Zp element given elements from GT and G1.
+group returns the group of the generators.
 */
-func HashIP(g,h []*p256, P *p256, c *big.Int, n int64) (*big.Int, error) {
-	var (
-		i int64
-	)
-
-	digest := sha256.New()
-	digest.Write([]byte(P.String()))
-	
-	i = 0
-	for i<n {
-		digest.Write([]byte(g[i].String()))
-		digest.Write([]byte(h[i].String()))
-		i = i + 1
+func (zkip *bip) group() (Group) {
+	if zkip.Group == nil {
+		return SECP256K1
 	}
-	
-	digest.Write([]byte(c.String()))
-	output := digest.Sum(nil)
-	tmp := output[0: len(output)]
-	result, err := byteconversion.FromByteArray(tmp)
-	
-	return result, err
+	return zkip.Group
 }
 
 /*
-CommitInnerProduct is responsible for calculating g^a.h^b.
+CommitInnerProduct is responsible for calculating g^a.h^b, where the generators
+belong to secp256k1.
 */
-func CommitInnerProduct(g,h []*p256, a,b []*big.Int) (*p256, error) {
+func CommitInnerProduct(g,h []Element, a,b []*big.Int) (Element, error) {
 	var (
-		result *p256
+		points []Element
+		scalars []*big.Int
 	)
+	points = append(points, g...)
+	points = append(points, h...)
+	scalars = append(scalars, a...)
+	scalars = append(scalars, b...)
+	return SECP256K1.MultiExp(points, scalars)
+}
 
-	ga, _ := VectorExp(g, a)
-	hb, _ := VectorExp(h, b)
-	result = new(p256).Multiply(ga, hb)
-	return result, nil
+/*
+Commit computes g^a.h^b with the generators of the inner product argument.
+*/
+func (zkip *bip) Commit(a,b []*big.Int) (Element, error) {
+	var (
+		points []Element
+		scalars []*big.Int
+	)
+	points = append(points, zkip.Gg...)
+	points = append(points, zkip.Hh...)
+	scalars = append(scalars, a...)
+	scalars = append(scalars, b...)
+	return zkip.group().MultiExp(points, scalars)
 }
 
 /*
 Setup is responsible for computing the inner product basic parameters that are common to both
 Prove and Verify algorithms.
 */
-func (zkip *bip) Setup(H *p256, g,h []*p256, c *big.Int) (bip, error) {
+func (zkip *bip) Setup(H Element, g,h []Element) (bip, error) {
 	var (
 		params bip
+		err error
 	)
 	
-	zkip.Gg = make([]*p256, zkip.N)
-	zkip.Hh = make([]*p256, zkip.N)
-	zkip.Uu, _ = MapToGroup(SEEDU)
+	zkip.N = int64(len(g))
+	_, zkip.Uu, err = groupHU(zkip.group())
 	zkip.H = H
 	zkip.Gg = g
 	zkip.Hh = h
-	zkip.Cc = c
-	zkip.P = new(p256).SetInfinity();
 
-	return params, nil
+	return params, err
+}
+
+/*
+newTranscript returns the transcript of a standalone inner product argument, which
+binds the generators, the commitment P = g^a.h^b and the inner product c.
+*/
+func (zkip *bip) newTranscript(P Element, c *big.Int) (*Transcript) {
+	t := NewTranscript("Bulletproofs inner product")
+	t.AppendInt64("N", zkip.N)
+	t.AppendPoint("U", zkip.Uu)
+	t.AppendElements("Gg", zkip.Gg)
+	t.AppendElements("Hh", zkip.Hh)
+	t.AppendPoint("P", P)
+	t.AppendScalar("c", c)
+	return t
 }
 
+/*
+Prove is responsible for the generation of the Inner Product Proof, where P is
+the commitment g^a.h^b and c the inner product <a,b>.
+*/
+func (zkip *bip) Prove(a,b []*big.Int, P Element, c *big.Int) (proofBip, error) {
+	return zkip.prove(zkip.newTranscript(P, c), a, b)
+}
 
 /*
-Prove is responsible for the generation of the Inner Product Proof.
+prove generates the Inner Product Proof drawing the challenges from the transcript
+t, which must already determine the commitment and the inner product.
 */
-func (zkip *bip) Prove(a,b []*big.Int, P *p256) (proofBip, error) {
+func (zkip *bip) prove(t *Transcript, a,b []*big.Int) (proofBip, error) {
 	var (
 		proof proofBip
 		n,m int64
-		Ls []*p256 
-		Rs []*p256 
+		Ls []Element 
+		Rs []Element 
 	)
+	grp := zkip.group()
 	
 	n = int64(len(a))
 	m = int64(len(b))
@@ -1203,42 +1370,40 @@ func (zkip *bip) Prove(a,b []*big.Int, P *p256) (proofBip, error) {
 		return proof, errors.New("Size of first array argument must be equal to the second")
 	} else {
 		// Fiat-Shamir:
-		// x = Hash(g,h,P,c)
-		x, _ := HashIP(zkip.Gg, zkip.Hh, P, zkip.Cc, zkip.N)
-		// Pprime = P.u^(x.c)		
-		ux := new(p256).ScalarMult(zkip.Uu, x)  
-		uxc := new(p256).ScalarMult(ux, zkip.Cc)  
-		PP := new(p256).Multiply(P, uxc)
+		// w = Hash(transcript)
+		w := t.ChallengeScalar("w", grp.Scalar().Order())
+		ux := grp.ScalarMult(zkip.Uu, w)  
 		// Execute Protocol 2 recursively
-		zkip.P = PP
-		proof, err := BIP(a, b, zkip.Gg, zkip.Hh, ux, zkip.P, n, Ls, Rs)
-		proof.P = PP
+		proof, err := bipFold(grp, t, a, b, zkip.Gg, zkip.Hh, ux, n, Ls, Rs)
 		return proof, err
 	}
-		
-	return proof, nil
 }
 
 /*
-BIP is the main recursive function that will be used to compute the inner product argument.
+BIP is the main recursive function that will be used to compute the inner product
+argument over secp256k1.
+*/
+func BIP(t *Transcript, a,b []*big.Int, g,h []*p256, u *p256, n int64, Ls,Rs []*p256) (proofBip, error) {
+	return bipFold(SECP256K1, t, a, b, Elements(g), Elements(h), u, n, Elements(Ls), Elements(Rs))
+}
+
+/*
+bipFold computes the rounds of the inner product argument over the group grp.
 */
-func BIP(a,b []*big.Int, g,h []*p256, u,P *p256, n int64, Ls,Rs []*p256) (proofBip, error) {
+func bipFold(grp Group, t *Transcript, a,b []*big.Int, g,h []Element, u Element, n int64, Ls,Rs []Element) (proofBip, error) {
 	var (
 		proof proofBip
-		cL, cR, x, xinv, x2, x2inv *big.Int
-		L, R, Lh, Rh, Pprime *p256
-		gprime, hprime, gprime2, hprime2 []*p256
-		aprime, bprime, aprime2, bprime2 []*big.Int
+		cL, cR, x, xinv *big.Int
+		L, R Element
+		gprime, hprime []Element
+		aprime, bprime []*big.Int
 	)
+	f := field(grp)
 
 	if (n == 1) {
 		// recursion end
 		proof.A = a[0]
 		proof.B = b[0]
-		proof.Gg = g[0]
-		proof.Hh = h[0]
-		proof.P = P
-		proof.U = u
 		proof.Ls = Ls
 		proof.Rs = Rs
 
@@ -1249,108 +1414,195 @@ func BIP(a,b []*big.Int, g,h []*p256, u,P *p256, n int64, Ls,Rs []*p256) (proofB
 		nprime := n / 2
 
 		// Compute cL = < a[:n'], b[n':] >
-		cL, _ = ScalarProduct(a[:nprime], b[nprime:])
+		cL, _ = f.scalarProduct(a[:nprime], b[nprime:])
 		// Compute cR = < a[n':], b[:n'] >
-		cR, _ = ScalarProduct(a[nprime:], b[:nprime])
+		cR, _ = f.scalarProduct(a[nprime:], b[:nprime])
+		// a and b depend on the witness, then L and R are computed in constant time
 		// Compute L = g[n':]^(a[:n']).h[:n']^(b[n':]).u^cL
-		L, _ = VectorExp(g[nprime:],a[:nprime])
-		Lh, _ = VectorExp(h[:nprime], b[nprime:])
-		L.Multiply(L, Lh)
-		L.Multiply(L, new(p256).ScalarMult(u, cL))
+		L, _ = innerProductExp(grp, g[nprime:], h[:nprime], u, a[:nprime], b[nprime:], cL)
 		
 		// Compute R = g[:n']^(a[n':]).h[n':]^(b[:n']).u^cR
-		R, _ = VectorExp(g[:nprime],a[nprime:]) 
-		Rh, _ = VectorExp(h[nprime:], b[:nprime])
-		R.Multiply(R, Rh)
-		R.Multiply(R, new(p256).ScalarMult(u, cR))
+		R, _ = innerProductExp(grp, g[:nprime], h[nprime:], u, a[nprime:], b[:nprime], cR)
 
 		// Fiat-Shamir:
-		x, _, _ = HashBP(L, R)
-		xinv = ModInverse(x, ORDER)
+		t.AppendPoint("L", L)
+		t.AppendPoint("R", R)
+		x = t.ChallengeScalar("x", f.Order())
+		xinv = f.Inverse(x)
 
 		// Compute g' = g[:n']^(x^-1) * g[n':]^(x)
-		gprime, _ = VectorScalarExp(g[:nprime], xinv)
-		gprime2, _ = VectorScalarExp(g[nprime:], x)
-		gprime, _ = VectorECAdd(gprime, gprime2)
+		gprime = foldElements(grp, g[:nprime], g[nprime:], xinv, x)
 		// Compute h' = h[:n']^(x)    * h[n':]^(x^-1)
-		hprime, _ = VectorScalarExp(h[:nprime], x)
-		hprime2, _ = VectorScalarExp(h[nprime:], xinv)
-		hprime, _ = VectorECAdd(hprime, hprime2)
-
-		// Compute P' = L^(x^2).P.R^(x^-2)
-		x2 = Mod(Multiply(x,x), ORDER)
-		x2inv = ModInverse(x2, ORDER)
-		Pprime = new(p256).ScalarMult(L, x2)
-		Pprime.Multiply(Pprime, P)
-		Pprime.Multiply(Pprime, new(p256).ScalarMult(R, x2inv))
+		hprime = foldElements(grp, h[:nprime], h[nprime:], x, xinv)
 
 		// Compute a' = a[:n'].x      + a[n':].x^(-1)
-		aprime, _ = VectorScalarMul(a[:nprime], x)
-		aprime2, _ = VectorScalarMul(a[nprime:], xinv)
-		aprime, _ = VectorAdd(aprime, aprime2)
+		aprime, _ = f.vectorAdd(f.vectorScalarMul(a[:nprime], x), f.vectorScalarMul(a[nprime:], xinv))
 		// Compute b' = b[:n'].x^(-1) + b[n':].x
-		bprime, _ = VectorScalarMul(b[:nprime], xinv)
-		bprime2, _ = VectorScalarMul(b[nprime:], x)
-		bprime, _ = VectorAdd(bprime, bprime2)
+		bprime, _ = f.vectorAdd(f.vectorScalarMul(b[:nprime], xinv), f.vectorScalarMul(b[nprime:], x))
 
 		Ls = append(Ls, L)
 		Rs = append(Rs, R)
-		// recursion BIP(g',h',u,P'; a', b')
-		proof, _ = BIP(aprime, bprime, gprime, hprime, u, Pprime, nprime, Ls, Rs)
+		// recursion BIP(g',h',u; a', b')
+		proof, _ = bipFold(grp, t, aprime, bprime, gprime, hprime, u, nprime, Ls, Rs)
 	}
 	proof.N = n
 	return proof, nil
 }
 
+/*
+innerProductExp computes g^a.h^b.u^c in constant time with respect to a, b and c.
+*/
+func innerProductExp(grp Group, g,h []Element, u Element, a,b []*big.Int, c *big.Int) (Element, error) {
+	points := append(append(append([]Element{}, g...), h...), u)
+	scalars := append(append(append([]*big.Int{}, a...), b...), c)
+	return grp.MultiExpCT(points, scalars)
+}
+
 /* 
-Verify is responsible for the verification of the Inner Product Proof. 
+Verify is responsible for the verification of the Inner Product Proof, where P is
+the commitment g^a.h^b computed by the verifier and c the inner product <a,b>.
 */
-func (zkip *bip) Verify(proof proofBip) (bool, error) {
-	
+func (zkip *bip) Verify(P Element, c *big.Int, proof proofBip) (bool, error) {
+	if err := zkip.validateVerify(P, c, &proof); err != nil {
+		return false, err
+	}
+	return zkip.verify(zkip.newTranscript(P, c), P, c, proof)
+}
+
+/*
+verify checks the Inner Product Proof drawing the challenges from the transcript t.
+*/
+func (zkip *bip) verify(t *Transcript, P Element, c *big.Int, proof proofBip) (bool, error) {
 	logn := len(proof.Ls)
 	var (
 		i int64
 		x, xinv, x2, x2inv *big.Int
-		ngprime, nhprime, ngprime2, nhprime2 []*p256
+		gprime, hprime []Element
 	)
+	grp := zkip.group()
+	f := field(grp)
+	q := f.Order()
+
+	nprime := int64(len(zkip.Gg))
+	if int64(1) << uint(logn) != nprime || len(proof.Rs) != logn {
+		return false, errors.New("Number of rounds does not match the size of the generators.")
+	}
+
+	// Fiat-Shamir:
+	// w = Hash(transcript)
+	w := t.ChallengeScalar("w", q)
+	// Pprime = P.u^(w.c)
+	ux := grp.ScalarMult(zkip.Uu, w)
+	Pprime := grp.Add(P, grp.ScalarMult(ux, c))
 
 	i = 0
-	gprime := zkip.Gg
-	hprime := zkip.Hh
-	Pprime := zkip.P
-	nprime := proof.N 
+	gprime = zkip.Gg
+	hprime = zkip.Hh
 	for i < int64(logn) {
 		nprime = nprime / 2
-		x, _, _ = HashBP(proof.Ls[i], proof.Rs[i])
-		xinv = ModInverse(x, ORDER)
+		t.AppendPoint("L", proof.Ls[i])
+		t.AppendPoint("R", proof.Rs[i])
+		x = t.ChallengeScalar("x", q)
+		xinv = f.Inverse(x)
 		// Compute g' = g[:n']^(x^-1) * g[n':]^(x)
-		ngprime, _ = VectorScalarExp(gprime[:nprime], xinv)
-		ngprime2, _ = VectorScalarExp(gprime[nprime:], x)
-		gprime, _ = VectorECAdd(ngprime, ngprime2)
+		gprime = foldElements(grp, gprime[:nprime], gprime[nprime:], xinv, x)
 		// Compute h' = h[:n']^(x)    * h[n':]^(x^-1)
-		nhprime, _ = VectorScalarExp(hprime[:nprime], x)
-		nhprime2, _ = VectorScalarExp(hprime[nprime:], xinv)
-		hprime, _ = VectorECAdd(nhprime, nhprime2)
+		hprime = foldElements(grp, hprime[:nprime], hprime[nprime:], x, xinv)
 		// Compute P' = L^(x^2).P.R^(x^-2)
-		x2 = Mod(Multiply(x,x), ORDER)
-		x2inv = ModInverse(x2, ORDER)
-		Pprime.Multiply(Pprime, new(p256).ScalarMult(proof.Ls[i], x2))
-		Pprime.Multiply(Pprime, new(p256).ScalarMult(proof.Rs[i], x2inv))
+		x2 = Mod(Multiply(x,x), q)
+		x2inv = f.Inverse(x2)
+		Pprime = grp.Add(Pprime, grp.ScalarMult(proof.Ls[i], x2))
+		Pprime = grp.Add(Pprime, grp.ScalarMult(proof.Rs[i], x2inv))
 		i = i + 1
 	}
 
 	// c == a*b
 	ab := Multiply(proof.A, proof.B)
-	ab = Mod(ab, ORDER)
+	ab = Mod(ab, q)
+
+	rhs, _ := grp.MultiExp([]Element{gprime[0], hprime[0], ux}, []*big.Int{proof.A, proof.B, ab})
+	return rhs.Equals(Pprime), nil
+}
+
+/*
+IPScalars computes the vector s such that, at the end of the inner product
+argument, the folded generators are equal to prod g[i]^s[i] and prod h[i]^(s[i]^-1).
+Namely, s[i] = prod_j x[j]^b(i,j), where b(i,j) is 1 if the j-th most significant
+bit of i is set and -1 otherwise.
+*/
+func IPScalars(x []*big.Int, n int64) ([]*big.Int, error) {
+	return secpField.ipScalars(x, n)
+}
+
+/*
+VerifyMultiExp is responsible for the verification of the Inner Product Proof,
+as described in Section 3.1 of the paper. Instead of folding the generators in
+each round, it computes the exponents s[i] of the final generators from the
+challenges and checks the argument using a single multi-exponentiation of size
+2n+2log(n)+1:
+P.u^(w.c) == g^(a.s).h^(b.s^-1).u^(w.a.b).prod(L[j]^(-x[j]^2).R[j]^(-x[j]^-2))
+It receives the same input as Verify.
+*/
+func (zkip *bip) VerifyMultiExp(P Element, c *big.Int, proof proofBip) (bool, error) {
+	if err := zkip.validateVerify(P, c, &proof); err != nil {
+		return false, err
+	}
+	return zkip.verifyMultiExp(zkip.newTranscript(P, c), P, c, proof)
+}
+
+/*
+verifyMultiExp checks the Inner Product Proof with a single multi-exponentiation,
+drawing the challenges from the transcript t.
+*/
+func (zkip *bip) verifyMultiExp(t *Transcript, P Element, c *big.Int, proof proofBip) (bool, error) {
+	var (
+		i int64
+		j int
+		points []Element
+		scalars []*big.Int
+	)
+	grp := zkip.group()
+	f := field(grp)
+	q := f.Order()
+	logn := len(proof.Ls)
+	n := int64(len(zkip.Gg))
+	if int64(1) << uint(logn) != n || len(proof.Rs) != logn || int64(len(zkip.Hh)) != n {
+		return false, errors.New("Number of rounds does not match the size of the generators.")
+	}
 
-	rhs := new(p256).ScalarMult(gprime[0], proof.A)
-	hb := new(p256).ScalarMult(hprime[0], proof.B)
-	rhs.Multiply(rhs, hb)
-	rhs.Multiply(rhs, new(p256).ScalarMult(proof.U, ab))
+	// Fiat-Shamir:
+	// w = Hash(transcript)
+	w := t.ChallengeScalar("w", q)
+	x := make([]*big.Int, logn)
+	for j=0; j<logn; j++ {
+		t.AppendPoint("L", proof.Ls[j])
+		t.AppendPoint("R", proof.Rs[j])
+		x[j] = t.ChallengeScalar("x", q)
+	}
+	s, _ := f.ipScalars(x, n)
 
-	nP := Pprime.Neg(Pprime)
-	nP.Multiply(nP, rhs)
-	c := nP.IsZero() 
+	points = make([]Element, 0, 2*n + 2*int64(logn) + 1)
+	scalars = make([]*big.Int, 0, 2*n + 2*int64(logn) + 1)
+	i = 0
+	for i<n {
+		// g[i]^(a.s[i]) and h[i]^(b.s[i]^-1)
+		points = append(points, zkip.Gg[i], zkip.Hh[i])
+		scalars = append(scalars, Mod(Multiply(proof.A, s[i]), q))
+		scalars = append(scalars, Mod(Multiply(proof.B, f.Inverse(s[i])), q))
+		i = i + 1
+	}
+	for j=0; j<logn; j++ {
+		// L[j]^(-x[j]^2) and R[j]^(-x[j]^-2)
+		x2 := Mod(Multiply(x[j], x[j]), q)
+		points = append(points, proof.Ls[j], proof.Rs[j])
+		scalars = append(scalars, Sub(q, x2))
+		scalars = append(scalars, Sub(q, f.Inverse(x2)))
+	}
+	// u^(w.(a.b-c))
+	ab := Mod(Multiply(proof.A, proof.B), q)
+	points = append(points, zkip.Uu)
+	scalars = append(scalars, Mod(Multiply(w, Sub(ab, c)), q))
 
-	return c, nil
+	rhs, _ := grp.MultiExp(points, scalars)
+	return rhs.Equals(P), nil
 }
@@ -0,0 +1,497 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the implementation of the aggregated range proof from
+Section 4.3 of the paper:
+Bulletproofs: Short Proofs for Confidential Transactions and More
+Benedikt Bunz, Jonathan Bootle, Dan Boneh, Andrew Poelstra, Pieter Wuille and Greg Maxwell
+It allows to prove that m committed values belong to the interval [0,2^N)
+using a single inner product argument, thus the proof size only grows by
+O(log m) when compared to the proof for a single value.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+)
+
+/*
+Aggregated Bulletproofs parameters.
+M is the number of values supported by the parameters, which must be a power of 2.
+*/
+type bpAgg struct {
+	N int64
+	M int64
+	// Group over which the proofs are computed, secp256k1 if nil
+	Group Group `json:"-"`
+	G Element
+	H Element
+	Gg []Element
+	Hh []Element
+	Zkip bip
+}
+
+/*
+Aggregated Bulletproofs proof.
+*/
+type proofBPAgg struct {
+	V []Element
+	A Element
+	S Element
+	T1 Element
+	T2 Element
+	Taux *big.Int
+	Mu *big.Int
+	Tprime *big.Int
+	Proofip proofBip
+}
+
+/*
+Setup is responsible for computing the common parameters to prove that m values
+belong to the interval [0,2^n). The inner product argument requires the size of
+the vectors to be a power of 2, then m is rounded up to the next power of 2.
+*/
+func (zkrp *bpAgg) Setup(n, m int64) (error) {
+	var (
+		err error
+	)
+	if n <= 0 || n & (n-1) != 0 {
+		return errors.New("n must be a power of 2")
+	}
+	if m <= 0 {
+		return errors.New("m must be positive")
+	}
+	grp := zkrp.group()
+	zkrp.N = n
+	zkrp.M = 1
+	for zkrp.M < m {
+		zkrp.M = 2 * zkrp.M
+	}
+	mn := zkrp.M * zkrp.N
+	zkrp.Group = grp
+	zkrp.G = grp.Generator()
+	zkrp.H, _, err = groupHU(grp)
+	if err != nil {
+		return err
+	}
+	zkrp.Gg, zkrp.Hh, err = groupGenerators(grp, mn)
+	if err != nil {
+		return err
+	}
+
+	// Setup Inner Product
+	zkrp.Zkip.Group = grp
+	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	return nil
+}
+
+/*
+group returns the group of the parameters, which defaults to secp256k1.
+*/
+func (zkrp *bpAgg) group() (Group) {
+	if zkrp.Group == nil {
+		return SECP256K1
+	}
+	return zkrp.Group
+}
+
+/*
+commit computes the Pedersen commitment g^x.h^r in constant time.
+*/
+func (zkrp *bpAgg) commit(x, r *big.Int) (Element, error) {
+	return zkrp.group().MultiExpCT([]Element{zkrp.G, zkrp.H}, []*big.Int{x, r})
+}
+
+/*
+newTranscript returns the transcript of the aggregated range proof, which binds
+N, M and the generators.
+*/
+func (zkrp *bpAgg) newTranscript() (*Transcript) {
+	t := NewTranscript("Bulletproofs aggregated range proof")
+	t.AppendInt64("N", zkrp.N)
+	t.AppendInt64("M", zkrp.M)
+	t.AppendPoint("G", zkrp.G)
+	t.AppendPoint("H", zkrp.H)
+	t.AppendPoint("U", zkrp.Zkip.Uu)
+	t.AppendElements("Gg", zkrp.Gg)
+	t.AppendElements("Hh", zkrp.Hh)
+	return t
+}
+
+/*
+Z2N returns the vector that concatenates z^(j+2).2^n, for j = 0..M-1.
+*/
+func (zkrp *bpAgg) Z2N(z *big.Int) ([]*big.Int, error) {
+	var (
+		j int64
+		result []*big.Int
+	)
+	f := field(zkrp.group())
+	q := f.Order()
+	p2n := f.powerOf(new(big.Int).SetInt64(2), zkrp.N)
+	zj := Mod(Multiply(z, z), q)
+	result = make([]*big.Int, 0, zkrp.M * zkrp.N)
+	j = 0
+	for j<zkrp.M {
+		result = append(result, f.vectorScalarMul(p2n, zj)...)
+		zj = Mod(Multiply(zj, z), q)
+		j = j + 1
+	}
+	return result, nil
+}
+
+/*
+delta(y,z) = (z-z^2) . < 1^mn, y^mn > - sum_j z^(j+3) . < 1^n, 2^n >
+*/
+func (zkrp *bpAgg) Delta(y, z *big.Int) (*big.Int, error) {
+	var (
+		j int64
+		result *big.Int
+	)
+	f := field(zkrp.group())
+	q := f.Order()
+	mn := zkrp.M * zkrp.N
+	z2 := Mod(Multiply(z, z), q)
+
+	// < 1^mn, y^mn >
+	v1, _ := VectorCopy(new(big.Int).SetInt64(1), mn)
+	vy := f.powerOf(y, mn)
+	sp1y, _ := f.scalarProduct(v1, vy)
+
+	// < 1^n, 2^n > = 2^n - 1
+	sp12 := Sub(new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N)), new(big.Int).SetInt64(1))
+
+	result = Mod(Sub(z, z2), q)
+	result = Mod(Multiply(result, sp1y), q)
+	zj := Mod(Multiply(z2, z), q)
+	j = 0
+	for j<zkrp.M {
+		result = Sub(result, Multiply(zj, sp12))
+		result = Mod(result, q)
+		zj = Mod(Multiply(zj, z), q)
+		j = j + 1
+	}
+	return result, nil
+}
+
+/*
+Prove computes the aggregated ZK proof that each secret belongs to the interval
+[0,2^N). The number of secrets must not exceed M.
+*/
+func (zkrp *bpAgg) Prove(secrets []*big.Int) (proofBPAgg, error) {
+	var (
+		i, j, m int64
+		err error
+		aL []int64
+		sL []*big.Int
+		sR []*big.Int
+		gamma []*big.Int
+		proof proofBPAgg
+	)
+	m = int64(len(secrets))
+	if m == 0 || m > zkrp.M {
+		return proof, errors.New("Number of secrets must be between 1 and M.")
+	}
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	mn := zkrp.M * zkrp.N
+
+	//////////////////////////////////////////////////////////////////////////////
+	// First phase
+	//////////////////////////////////////////////////////////////////////////////
+
+	// commitments to v and gamma, the missing values are padded with zeros,
+	// which means that both the secret and gamma are equal to 0
+	proof.V = make([]Element, m)
+	gamma = make([]*big.Int, zkrp.M)
+	aL = make([]int64, 0, mn)
+	j = 0
+	for j<zkrp.M {
+		gamma[j] = new(big.Int).SetInt64(0)
+		v := new(big.Int).SetInt64(0)
+		if j < m {
+			if gamma[j], err = f.Random(); err != nil {
+				return proof, err
+			}
+			v = secrets[j]
+			if proof.V[j], err = zkrp.commit(v, gamma[j]); err != nil {
+				return proof, err
+			}
+		}
+		bits, _ := Decompose(v, 2, zkrp.N)
+		aL = append(aL, bits...)
+		j = j + 1
+	}
+
+	// aL, aR and commitment: (A, alpha)
+	aR, _ := ComputeAR(aL)
+	naL, _ := VectorConvertToBig(aL, mn)
+	naR, _ := VectorConvertToBig(aR, mn)
+	alpha, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	A, err := commitVector(grp, zkrp.H, zkrp.Gg, zkrp.Hh, naL, naR, alpha)
+	if err != nil {
+		return proof, err
+	}
+
+	// sL, sR and commitment: (S, rho)
+	rho, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	sL = make([]*big.Int, mn)
+	sR = make([]*big.Int, mn)
+	i = 0
+	for i<mn {
+		if sL[i], err = f.Random(); err != nil {
+			return proof, err
+		}
+		if sR[i], err = f.Random(); err != nil {
+			return proof, err
+		}
+		i = i + 1
+	}
+	S, err := commitVector(grp, zkrp.H, zkrp.Gg, zkrp.Hh, sL, sR, rho)
+	if err != nil {
+		return proof, err
+	}
+
+	// Fiat-Shamir heuristic to compute challenges y, z
+	t := zkrp.newTranscript()
+	t.AppendInt64("m", m)
+	t.AppendElements("V", proof.V)
+	t.AppendPoint("A", A)
+	t.AppendPoint("S", S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Second phase
+	//////////////////////////////////////////////////////////////////////////////
+	tau1, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	tau2, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+
+	vz, _ := VectorCopy(z, mn)
+	vy := f.powerOf(y, mn)
+	zs, _ := zkrp.Z2N(z)
+
+	// l0 = aL - z.1^mn
+	l0, _ := f.vectorSub(naL, vz)
+
+	// r0 = y^mn . (aR + z.1^mn) + sum_j z^(j+2).2^n
+	aRzn, _ := f.vectorAdd(naR, vz)
+	r0, _ := f.vectorMul(vy, aRzn)
+	r0, _ = f.vectorAdd(r0, zs)
+
+	// r1 = y^mn . sR
+	r1, _ := f.vectorMul(vy, sR)
+
+	// t1 = < l0, r1 > + < sL, r0 >
+	sp1, _ := f.scalarProduct(l0, r1)
+	sp2, _ := f.scalarProduct(sL, r0)
+	t1 := Mod(Add(sp1, sp2), q)
+
+	// t2 = < sL, r1 >
+	t2, _ := f.scalarProduct(sL, r1)
+
+	T1, err := zkrp.commit(t1, tau1)
+	if err != nil {
+		return proof, err
+	}
+	T2, err := zkrp.commit(t2, tau2)
+	if err != nil {
+		return proof, err
+	}
+
+	// Fiat-Shamir heuristic to compute 'random' challenge x
+	t.AppendPoint("T1", T1)
+	t.AppendPoint("T2", T2)
+	x := t.ChallengeScalar("x", q)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Third phase                                                              //
+	//////////////////////////////////////////////////////////////////////////////
+
+	// bl = l0 + sL.x
+	bl, _ := f.vectorAdd(l0, f.vectorScalarMul(sL, x))
+
+	// br = r0 + r1.x
+	br, _ := f.vectorAdd(r0, f.vectorScalarMul(r1, x))
+
+	// Compute t` = < bl, br >
+	tprime, _ := f.scalarProduct(bl, br)
+
+	// Compute taux = tau2 . x^2 + tau1 . x + sum_j z^(j+2) . gamma_j
+	taux := Multiply(tau2, Multiply(x, x))
+	taux = Add(taux, Multiply(tau1, x))
+	zj := Mod(Multiply(z, z), q)
+	j = 0
+	for j<zkrp.M {
+		taux = Add(taux, Multiply(zj, gamma[j]))
+		zj = Mod(Multiply(zj, z), q)
+		j = j + 1
+	}
+	taux = Mod(taux, q)
+
+	// Compute mu = alpha + rho.x
+	mu := Multiply(rho, x)
+	mu = Add(mu, alpha)
+	mu = Mod(mu, q)
+
+	// Inner Product over (g, h', P.h^-mu, tprime)
+	hprime := zkrp.HPrime(y)
+
+	zkip := zkrp.Zkip
+	zkip.Hh = hprime
+
+	// The commitment P = g^bl.h'^br is determined by the transcript
+	t.AppendScalar("taux", taux)
+	t.AppendScalar("mu", mu)
+	t.AppendScalar("tprime", tprime)
+	proofip, err := zkip.prove(t, bl, br)
+	if err != nil {
+		return proof, err
+	}
+
+	proof.A = A
+	proof.S = S
+	proof.T1 = T1
+	proof.T2 = T2
+	proof.Taux = taux
+	proof.Mu = mu
+	proof.Tprime = tprime
+	proof.Proofip = proofip
+
+	return proof, nil
+}
+
+/*
+HPrime computes the generators h'[i] = h[i]^(y^-i).
+*/
+func (zkrp *bpAgg) HPrime(y *big.Int) ([]Element) {
+	grp := zkrp.group()
+	f := field(grp)
+	mn := zkrp.M * zkrp.N
+	return mulElements(grp, zkrp.Hh[:mn], f.powerOf(f.Inverse(y), mn))
+}
+
+/*
+Verify returns true if and only if the proof is valid, i.e. if every commitment
+in proof.V commits to a value in the interval [0,2^N).
+*/
+func (zkrp *bpAgg) Verify(proof proofBPAgg) (bool, error) {
+	var (
+		i, j, m int64
+	)
+	grp := zkrp.group()
+	if err := zkrp.Validate(); err != nil {
+		return false, err
+	}
+	if err := proof.validate(grp); err != nil {
+		return false, err
+	}
+	if err := checkRounds(&proof.Proofip, zkrp.N * zkrp.M); err != nil {
+		return false, err
+	}
+	m = int64(len(proof.V))
+	if m == 0 || m > zkrp.M {
+		return false, errors.New("Number of commitments must be between 1 and M.")
+	}
+	f := field(grp)
+	q := f.Order()
+	mn := zkrp.M * zkrp.N
+	t := zkrp.newTranscript()
+	t.AppendInt64("m", m)
+	t.AppendElements("V", proof.V)
+	t.AppendPoint("A", proof.A)
+	t.AppendPoint("S", proof.S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+	t.AppendPoint("T1", proof.T1)
+	t.AppendPoint("T2", proof.T2)
+	x := t.ChallengeScalar("x", q)
+	hprime := zkrp.HPrime(y)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Check that tprime  = t(x) = t0 + t1x + t2x^2  ----------  Condition (72) //
+	//////////////////////////////////////////////////////////////////////////////
+
+	// Compute left hand side
+	lhs, _ := grp.MultiExp([]Element{zkrp.G, zkrp.H}, []*big.Int{proof.Tprime, proof.Taux})
+
+	// Compute right hand side: V^(z^2.z^m).g^delta.T1^x.T2^(x^2)
+	x2 := Mod(Multiply(x, x), q)
+	delta, _ := zkrp.Delta(y, z)
+	points := []Element{zkrp.G, proof.T1, proof.T2}
+	scalars := []*big.Int{delta, x, x2}
+	zj := Mod(Multiply(z, z), q)
+	j = 0
+	for j<m {
+		points = append(points, proof.V[j])
+		scalars = append(scalars, zj)
+		zj = Mod(Multiply(zj, z), q)
+		j = j + 1
+	}
+	rhs, _ := grp.MultiExp(points, scalars)
+
+	c72 := lhs.Equals(rhs)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Compute P = A.S^x.g^-z.h'^(z.y^mn + sum_j z^(j+2).2^n)  -- Condition (66) //
+	//////////////////////////////////////////////////////////////////////////////
+
+	// z.y^mn + sum_j z^(j+2).2^n
+	vz, _ := VectorCopy(z, mn)
+	vy := f.powerOf(y, mn)
+	zyn, _ := f.vectorMul(vy, vz)
+	zs, _ := zkrp.Z2N(z)
+	zynzs, _ := f.vectorAdd(zyn, zs)
+
+	// P = A.S^x.g^-z.h'^(z.y^mn + sum_j z^(j+2).2^n).h^-mu, which is verified by
+	// the inner product ## Condition (67)
+	points = []Element{proof.A, proof.S, zkrp.H}
+	scalars = []*big.Int{new(big.Int).SetInt64(1), x, Sub(q, proof.Mu)}
+	mz := Sub(q, z)
+	i = 0
+	for i<mn {
+		points = append(points, zkrp.Gg[i], hprime[i])
+		scalars = append(scalars, mz, zynzs[i])
+		i = i + 1
+	}
+	rP, _ := grp.MultiExp(points, scalars)
+
+	// Verify Inner Product Proof ################################################
+	zkip := zkrp.Zkip
+	zkip.Hh = hprime
+	t.AppendScalar("taux", proof.Taux)
+	t.AppendScalar("mu", proof.Mu)
+	t.AppendScalar("tprime", proof.Tprime)
+	ok, _ := zkip.verifyMultiExp(t, rP, proof.Tprime, proof.Proofip)
+
+	return c72 && ok, nil
+}
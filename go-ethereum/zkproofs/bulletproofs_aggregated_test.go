@@ -0,0 +1,95 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+/*
+Test the TRUE case of the aggregated range proof, where the number of values
+is not a power of 2.
+*/
+func TestTrueAggregatedBulletproofs(t *testing.T) {
+	var (
+		zkrp bpAgg
+	)
+	zkrp.Setup(8, 3)
+	secrets := []*big.Int{big.NewInt(42), big.NewInt(0), big.NewInt(255)}
+	proof, _ := zkrp.Prove(secrets)
+	ok, _ := zkrp.Verify(proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	// log2(M.N) = log2(4.8)
+	if len(proof.Proofip.Ls) != 5 {
+		t.Errorf("Assert failure: expected 5 rounds, actual: %d", len(proof.Proofip.Ls))
+	}
+}
+
+/*
+Test the FALSE case of the aggregated range proof, where only one of the values
+is out of the interval.
+*/
+func TestFalseAggregatedBulletproofs(t *testing.T) {
+	var (
+		zkrp bpAgg
+	)
+	zkrp.Setup(8, 2)
+	secrets := []*big.Int{big.NewInt(42), big.NewInt(256)}
+	proof, _ := zkrp.Prove(secrets)
+	ok, _ := zkrp.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
+
+/*
+Test that the proof is rejected when the commitments are swapped.
+*/
+func TestSwappedAggregatedBulletproofs(t *testing.T) {
+	var (
+		zkrp bpAgg
+	)
+	zkrp.Setup(8, 2)
+	secrets := []*big.Int{big.NewInt(42), big.NewInt(7)}
+	proof, _ := zkrp.Prove(secrets)
+	proof.V[0], proof.V[1] = proof.V[1], proof.V[0]
+	ok, _ := zkrp.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
+
+func BenchmarkAggregatedBulletproofs(b *testing.B) {
+	var (
+		zkrp bpAgg
+		proof proofBPAgg
+		ok bool
+	)
+	zkrp.Setup(32, 4)
+	secrets := []*big.Int{big.NewInt(18), big.NewInt(4294967295), big.NewInt(65535), big.NewInt(0)}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proof, _ = zkrp.Prove(secrets)
+		ok, _ = zkrp.Verify(proof)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
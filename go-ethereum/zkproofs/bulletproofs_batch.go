@@ -0,0 +1,207 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the batch verification of Bulletproofs. Every equation that
+is checked by Verify is written as a product of exponentiations equal to the
+point at infinity. Then the equations of all the proofs are combined using random
+weights, such that they are checked with a single multi-exponentiation.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+	"crypto/rand"
+)
+
+/*
+batchBP accumulates the bases and exponents of the multi-exponentiation. The
+exponents of the generators that are common to every proof are kept apart, such
+that each of these generators appears only once in the multi-exponentiation.
+*/
+type batchBP struct {
+	g *big.Int
+	h *big.Int
+	u *big.Int
+	gg []*big.Int
+	hh []*big.Int
+	points []Element
+	scalars []*big.Int
+	q *big.Int
+}
+
+/*
+add appends P^e to the multi-exponentiation.
+*/
+func (batch *batchBP) add(P Element, e *big.Int) {
+	batch.points = append(batch.points, P)
+	batch.scalars = append(batch.scalars, Mod(e, batch.q))
+}
+
+/*
+addUL adds to the batch the equations verified by VerifyUL for the commitment V
+and proof, weighted by random exponents. The challenges are drawn from the
+transcript t in the same order as in verifyUL.
+*/
+func (zkrp *bp) addUL(t *Transcript, batch *batchBP, V Element, proof proofBPUL) (error) {
+	var (
+		i int64
+		j int
+	)
+	if err := zkrp.validateUL(&proof); err != nil {
+		return err
+	}
+	f := field(zkrp.group())
+	q := f.Order()
+	w65, _ := rand.Int(rand.Reader, q)
+	wip, _ := rand.Int(rand.Reader, q)
+
+	t.AppendPoint("V", V)
+	t.AppendPoint("A", proof.A)
+	t.AppendPoint("S", proof.S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+	t.AppendPoint("T1", proof.T1)
+	t.AppendPoint("T2", proof.T2)
+	x := t.ChallengeScalar("x", q)
+	t.AppendScalar("taux", proof.Taux)
+	t.AppendScalar("mu", proof.Mu)
+	t.AppendScalar("tprime", proof.Tprime)
+	w := t.ChallengeScalar("w", q)
+	z2 := Mod(Multiply(z, z), q)
+	x2 := Mod(Multiply(x, x), q)
+	delta, _ := zkrp.Delta(y, z)
+
+	// Condition (65): g^(tprime-delta).h^taux.V^(-z^2).T1^(-x).T2^(-x^2) == 1
+	batch.g = Add(batch.g, Multiply(w65, Sub(proof.Tprime, delta)))
+	batch.h = Add(batch.h, Multiply(w65, proof.Taux))
+	batch.add(V, Multiply(w65, Sub(q, z2)))
+	batch.add(proof.T1, Multiply(w65, Sub(q, x)))
+	batch.add(proof.T2, Multiply(w65, Sub(q, x2)))
+
+	// Inner product: P.u^(w.(c-a.b)).prod(L^(x^2).R^(x^-2)).g^(-a.s).h'^(-b.s^-1) == 1
+	// where P = A.S^x.g^-z.h'^(z.y^n + z^2.2^n).h^-mu and h'[i] = h[i]^(y^-i)
+	logn := len(proof.Proofip.Ls)
+	xip := make([]*big.Int, logn)
+	batch.add(proof.A, wip)
+	batch.add(proof.S, Multiply(wip, x))
+	batch.h = Sub(batch.h, Multiply(wip, proof.Mu))
+	for j=0; j<logn; j++ {
+		t.AppendPoint("L", proof.Proofip.Ls[j])
+		t.AppendPoint("R", proof.Proofip.Rs[j])
+		xip[j] = t.ChallengeScalar("x", q)
+		xj2 := Mod(Multiply(xip[j], xip[j]), q)
+		batch.add(proof.Proofip.Ls[j], Multiply(wip, xj2))
+		batch.add(proof.Proofip.Rs[j], Multiply(wip, f.Inverse(xj2)))
+	}
+	s, _ := f.ipScalars(xip, zkrp.N)
+	ab := Multiply(proof.Proofip.A, proof.Proofip.B)
+	batch.u = Add(batch.u, Multiply(Multiply(wip, w), Sub(proof.Tprime, ab)))
+	vyinv := f.powerOf(f.Inverse(y), zkrp.N)
+	p2n := f.powerOf(new(big.Int).SetInt64(2), zkrp.N)
+	i = 0
+	for i<zkrp.N {
+		// g[i]^(-z-a.s[i])
+		as := Mod(Multiply(proof.Proofip.A, s[i]), q)
+		batch.gg[i] = Sub(batch.gg[i], Multiply(wip, Add(z, as)))
+		// h[i]^(z + (z^2.2^i - b.s[i]^-1).y^-i)
+		bs := Multiply(proof.Proofip.B, f.Inverse(s[i]))
+		e := Mod(Multiply(Sub(Multiply(z2, p2n[i]), bs), vyinv[i]), q)
+		batch.hh[i] = Add(batch.hh[i], Multiply(wip, Add(z, e)))
+		i = i + 1
+	}
+	return nil
+}
+
+/*
+verifyBatch returns true if and only if the combination of the equations of all
+the proofs holds.
+*/
+func (zkrp *bp) verifyBatch(proofs []proofBP) (bool, error) {
+	var (
+		i int64
+		batch batchBP
+	)
+	batch.q = zkrp.group().Scalar().Order()
+	batch.g = new(big.Int).SetInt64(0)
+	batch.h = new(big.Int).SetInt64(0)
+	batch.u = new(big.Int).SetInt64(0)
+	batch.gg, _ = VectorCopy(new(big.Int).SetInt64(0), zkrp.N)
+	batch.hh, _ = VectorCopy(new(big.Int).SetInt64(0), zkrp.N)
+	for k := range proofs {
+		if err := checkElement(zkrp.group(), proofs[k].V, "V"); err != nil {
+			return false, err
+		}
+		t := zkrp.newTranscript()
+		Vb, Va := zkrp.ShiftCommitment(proofs[k].V)
+		e1 := zkrp.addUL(t, &batch, Vb, proofs[k].P1)
+		e2 := zkrp.addUL(t, &batch, Va, proofs[k].P2)
+		if e1 != nil {
+			return false, e1
+		}
+		if e2 != nil {
+			return false, e2
+		}
+	}
+	batch.add(zkrp.G, batch.g)
+	batch.add(zkrp.H, batch.h)
+	batch.add(zkrp.Zkip.Uu, batch.u)
+	i = 0
+	for i<zkrp.N {
+		batch.add(zkrp.Gg[i], batch.gg[i])
+		batch.add(zkrp.Hh[i], batch.hh[i])
+		i = i + 1
+	}
+	result, err := zkrp.group().MultiExp(batch.points, batch.scalars)
+	if err != nil {
+		return false, err
+	}
+	return result.IsZero(), nil
+}
+
+/*
+VerifyBatch verifies several proofs using a single multi-exponentiation. It returns
+true if and only if every proof is valid. Otherwise, it also returns the indexes
+of the invalid proofs, which are found by recursively splitting the batch. The
+malformed proofs, which are rejected by Validate, are reported as invalid.
+*/
+func (zkrp *bp) VerifyBatch(proofs []proofBP) (bool, []int, error) {
+	var (
+		invalid []int
+	)
+	if len(proofs) == 0 {
+		return true, nil, nil
+	}
+	if err := zkrp.Validate(); err != nil {
+		return false, nil, err
+	}
+	ok, _ := zkrp.verifyBatch(proofs)
+	if ok {
+		return true, nil, nil
+	}
+	if len(proofs) == 1 {
+		return false, []int{0}, nil
+	}
+	half := len(proofs) / 2
+	_, left, _ := zkrp.VerifyBatch(proofs[:half])
+	_, right, _ := zkrp.VerifyBatch(proofs[half:])
+	invalid = append(invalid, left...)
+	for _, k := range right {
+		invalid = append(invalid, k + half)
+	}
+	return false, invalid, nil
+}
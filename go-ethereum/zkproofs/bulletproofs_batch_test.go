@@ -0,0 +1,77 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+/*
+Test the batch verification when every proof is valid.
+*/
+func TestTrueVerifyBatch(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 66)
+	proofs := make([]proofBP, 4)
+	for i := range proofs {
+		proofs[i], _ = zkrp.Prove(new(big.Int).SetInt64(int64(18 + 15*i)))
+	}
+	ok, invalid, _ := zkrp.VerifyBatch(proofs)
+	if ok != true || len(invalid) != 0 {
+		t.Errorf("Assert failure: expected true, actual: %t, invalid: %v", ok, invalid)
+	}
+}
+
+/*
+Test that the batch verification reports the invalid proofs.
+*/
+func TestFalseVerifyBatch(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 66)
+	values := []int64{20, 17, 30, 40, 50, 70}
+	proofs := make([]proofBP, len(values))
+	for i := range values {
+		proofs[i], _ = zkrp.Prove(new(big.Int).SetInt64(values[i]))
+	}
+	ok, invalid, _ := zkrp.VerifyBatch(proofs)
+	if ok != false || len(invalid) != 2 || invalid[0] != 1 || invalid[1] != 5 {
+		t.Errorf("Assert failure: expected false and [1 5], actual: %t, %v", ok, invalid)
+	}
+}
+
+func BenchmarkVerifyBatch(b *testing.B) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(0, 4294967296)
+	proofs := make([]proofBP, 16)
+	for i := range proofs {
+		proofs[i], _ = zkrp.Prove(new(big.Int).SetInt64(int64(65535 * i)))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ok, _, _ := zkrp.VerifyBatch(proofs)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
@@ -0,0 +1,754 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the implementation of the arithmetic circuit proof from
+Section 5 of the paper:
+Bulletproofs: Short Proofs for Confidential Transactions and More
+Benedikt Bunz, Jonathan Bootle, Dan Boneh, Andrew Poelstra, Pieter Wuille and Greg Maxwell
+A circuit is given by n multiplication gates aL[i].aR[i] = aO[i] and Q linear
+constraints WL.aL + WR.aR + WO.aO = WV.v + c, where v are the values committed
+in V[j] = g^v[j].h^gamma[j]. The circuit is described by a constraint system,
+which is built in the same way by the prover, that knows the values of the
+variables, and by the verifier, that only knows the commitments.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+const (
+	// Kinds of variables of the constraint system
+	varOne = iota
+	varCommitted
+	varLeft
+	varRight
+	varOutput
+)
+
+/*
+Bulletproofs arithmetic circuit parameters.
+N is the maximum number of multiplication gates, which must be a power of 2.
+*/
+type bpCircuit struct {
+	N int64
+	// Group over which the proofs are computed, secp256k1 if nil
+	Group Group `json:"-"`
+	G Element
+	H Element
+	Gg []Element
+	Hh []Element
+	Zkip bip
+}
+
+/*
+Bulletproofs arithmetic circuit proof.
+*/
+type proofBPCircuit struct {
+	AI Element
+	AO Element
+	S Element
+	T1 Element
+	T3 Element
+	T4 Element
+	T5 Element
+	T6 Element
+	Taux *big.Int
+	Mu *big.Int
+	Tprime *big.Int
+	Proofip proofBip
+}
+
+/*
+variable is a variable of the constraint system: the constant 1, a committed
+value, or the left input, right input or output of a multiplication gate.
+*/
+type variable struct {
+	kind int
+	index int64
+}
+
+/*
+term is the product of a coefficient and a variable.
+*/
+type term struct {
+	v variable
+	c *big.Int
+}
+
+/*
+linearCombination is a sum of terms.
+*/
+type linearCombination []term
+
+/*
+constraintSystem keeps the multiplication gates and the linear constraints of
+the circuit. The values of the variables are only known by the prover.
+*/
+type constraintSystem struct {
+	zkrp *bpCircuit
+	prover bool
+	n int64
+	V []Element
+	v []*big.Int
+	gamma []*big.Int
+	aL []*big.Int
+	aR []*big.Int
+	aO []*big.Int
+	constraints []linearCombination
+	// First error found while building the constraint system
+	err error
+}
+
+/*
+Setup is responsible for computing the common parameters for circuits with at
+most n multiplication gates. The inner product argument requires the number of
+gates to be a power of 2, then n is rounded up to the next power of 2.
+*/
+func (zkrp *bpCircuit) Setup(n int64) (error) {
+	var (
+		err error
+	)
+	if n <= 0 {
+		return errors.New("n must be positive")
+	}
+	grp := zkrp.group()
+	zkrp.N = 1
+	for zkrp.N < n {
+		zkrp.N = 2 * zkrp.N
+	}
+	zkrp.Group = grp
+	zkrp.G = grp.Generator()
+	zkrp.H, _, err = groupHU(grp)
+	if err != nil {
+		return err
+	}
+	zkrp.Gg, zkrp.Hh, err = groupGenerators(grp, zkrp.N)
+	if err != nil {
+		return err
+	}
+
+	// Setup Inner Product
+	zkrp.Zkip.Group = grp
+	zkrp.Zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	return nil
+}
+
+/*
+group returns the group of the parameters, which defaults to secp256k1.
+*/
+func (zkrp *bpCircuit) group() (Group) {
+	if zkrp.Group == nil {
+		return SECP256K1
+	}
+	return zkrp.Group
+}
+
+/*
+commit computes the Pedersen commitment g^x.h^r in constant time.
+*/
+func (zkrp *bpCircuit) commit(x, r *big.Int) (Element, error) {
+	return zkrp.group().MultiExpCT([]Element{zkrp.G, zkrp.H}, []*big.Int{x, r})
+}
+
+/*
+Prover returns an empty constraint system to be used by the prover.
+*/
+func (zkrp *bpCircuit) Prover() (*constraintSystem) {
+	return &constraintSystem{zkrp: zkrp, prover: true}
+}
+
+/*
+Verifier returns a constraint system to be used by the verifier, together with
+the variables that correspond to the commitments V.
+*/
+func (zkrp *bpCircuit) Verifier(V []Element) (*constraintSystem, []variable) {
+	var (
+		j int
+	)
+	cs := &constraintSystem{zkrp: zkrp, prover: false}
+	vars := make([]variable, len(V))
+	for j=0; j<len(V); j++ {
+		cs.V = append(cs.V, V[j])
+		vars[j] = variable{kind: varCommitted, index: int64(j)}
+	}
+	return cs, vars
+}
+
+/*
+One returns the variable that is always equal to 1, which is used to add constants
+to linear combinations.
+*/
+func (cs *constraintSystem) One() (variable) {
+	return variable{kind: varOne}
+}
+
+/*
+LC returns the linear combination 1.v.
+*/
+func (cs *constraintSystem) LC(v variable) (linearCombination) {
+	return linearCombination{term{v: v, c: new(big.Int).SetInt64(1)}}
+}
+
+/*
+check returns an error if a term of lc refers to a variable which does not belong
+to the constraint system, namely an undeclared committed value or gate.
+*/
+func (cs *constraintSystem) check(lc linearCombination) (error) {
+	for _, t := range lc {
+		if t.c == nil {
+			return errors.New("Coefficient is missing.")
+		}
+		switch t.v.kind {
+		case varOne:
+		case varCommitted:
+			if t.v.index < 0 || t.v.index >= int64(len(cs.V)) {
+				return errors.New("Committed variable " + strconv.FormatInt(t.v.index, 10) + " is not declared.")
+			}
+		case varLeft, varRight, varOutput:
+			if t.v.index < 0 || t.v.index >= cs.n {
+				return errors.New("Gate " + strconv.FormatInt(t.v.index, 10) + " is not allocated.")
+			}
+		default:
+			return errors.New("Unknown kind of variable.")
+		}
+	}
+	return nil
+}
+
+/*
+fail records the first error found while building the constraint system, which
+is returned by Prove and Verify.
+*/
+func (cs *constraintSystem) fail(err error) (error) {
+	if cs.err == nil {
+		cs.err = err
+	}
+	return err
+}
+
+/*
+Add returns the linear combination lc + c.v.
+*/
+func (lc linearCombination) Add(c *big.Int, v variable) (linearCombination) {
+	result := make(linearCombination, len(lc), len(lc) + 1)
+	copy(result, lc)
+	return append(result, term{v: v, c: c})
+}
+
+/*
+AddLC returns the linear combination lc + c.other.
+*/
+func (lc linearCombination) AddLC(c *big.Int, other linearCombination) (linearCombination) {
+	result := make(linearCombination, len(lc), len(lc) + len(other))
+	copy(result, lc)
+	for _, t := range other {
+		result = append(result, term{v: t.v, c: Multiply(c, t.c)})
+	}
+	return result
+}
+
+/*
+Commit commits to the value v with a random blinding factor and returns the
+corresponding variable. Only the prover can commit to values.
+*/
+func (cs *constraintSystem) Commit(v *big.Int) (variable, Element, error) {
+	if !cs.prover {
+		return variable{}, nil, errors.New("Only the prover can commit to values.")
+	}
+	f := field(cs.zkrp.group())
+	gamma, err := f.Random()
+	if err != nil {
+		return variable{}, nil, err
+	}
+	V, err := cs.zkrp.commit(v, gamma)
+	if err != nil {
+		return variable{}, nil, err
+	}
+	cs.V = append(cs.V, V)
+	cs.v = append(cs.v, f.Reduce(v))
+	cs.gamma = append(cs.gamma, gamma)
+	return variable{kind: varCommitted, index: int64(len(cs.V) - 1)}, V, nil
+}
+
+/*
+Allocate adds a multiplication gate with inputs l and r and returns the variables
+of the inputs and the output. The verifier must call it with nil inputs.
+*/
+func (cs *constraintSystem) Allocate(l, r *big.Int) (variable, variable, variable) {
+	i := cs.n
+	cs.n = cs.n + 1
+	if cs.prover {
+		f := field(cs.zkrp.group())
+		cs.aL = append(cs.aL, f.Reduce(l))
+		cs.aR = append(cs.aR, f.Reduce(r))
+		cs.aO = append(cs.aO, f.Reduce(Multiply(l, r)))
+	}
+	return variable{kind: varLeft, index: i}, variable{kind: varRight, index: i},
+		variable{kind: varOutput, index: i}
+}
+
+/*
+Multiply adds a multiplication gate whose inputs are the linear combinations left
+and right, and returns the variables of the inputs and the output. If left or
+right refers to an undeclared variable, no gate is added and the error is
+returned by Prove and Verify.
+*/
+func (cs *constraintSystem) Multiply(left, right linearCombination) (variable, variable, variable) {
+	var (
+		l, r *big.Int
+	)
+	if err := cs.check(left); err != nil {
+		cs.fail(err)
+		return variable{}, variable{}, variable{}
+	}
+	if err := cs.check(right); err != nil {
+		cs.fail(err)
+		return variable{}, variable{}, variable{}
+	}
+	if cs.prover {
+		l = cs.Eval(left)
+		r = cs.Eval(right)
+	}
+	vl, vr, vo := cs.Allocate(l, r)
+	cs.Constrain(left.Add(new(big.Int).SetInt64(-1), vl))
+	cs.Constrain(right.Add(new(big.Int).SetInt64(-1), vr))
+	return vl, vr, vo
+}
+
+/*
+Constrain adds the linear constraint lc = 0. It returns an error if lc refers to
+an undeclared variable, which is also returned by Prove and Verify.
+*/
+func (cs *constraintSystem) Constrain(lc linearCombination) (error) {
+	if err := cs.check(lc); err != nil {
+		return cs.fail(err)
+	}
+	cs.constraints = append(cs.constraints, lc)
+	return nil
+}
+
+/*
+Eval returns the value of the linear combination lc. It is only available to
+the prover, and returns nil if lc refers to an undeclared variable.
+*/
+func (cs *constraintSystem) Eval(lc linearCombination) (*big.Int) {
+	if !cs.prover || cs.check(lc) != nil {
+		return nil
+	}
+	q := cs.zkrp.group().Scalar().Order()
+	result := new(big.Int).SetInt64(0)
+	for _, t := range lc {
+		var value *big.Int
+		switch t.v.kind {
+		case varOne:
+			value = new(big.Int).SetInt64(1)
+		case varCommitted:
+			value = cs.v[t.v.index]
+		case varLeft:
+			value = cs.aL[t.v.index]
+		case varRight:
+			value = cs.aR[t.v.index]
+		case varOutput:
+			value = cs.aO[t.v.index]
+		}
+		result = Mod(Add(result, Multiply(t.c, value)), q)
+	}
+	return result
+}
+
+/*
+size returns the number of gates used by the proof, which is the number of
+multiplication gates rounded up to the next power of 2.
+*/
+func (cs *constraintSystem) size() (int64) {
+	n := int64(1)
+	for n < cs.n {
+		n = 2 * n
+	}
+	return n
+}
+
+/*
+newTranscript returns the transcript of the circuit proof, which binds the
+generators, the commitments and the whole constraint system.
+*/
+func (cs *constraintSystem) newTranscript(n int64) (*Transcript) {
+	q := cs.zkrp.group().Scalar().Order()
+	t := NewTranscript("Bulletproofs arithmetic circuit")
+	t.AppendInt64("N", n)
+	t.AppendPoint("G", cs.zkrp.G)
+	t.AppendPoint("H", cs.zkrp.H)
+	t.AppendPoint("U", cs.zkrp.Zkip.Uu)
+	t.AppendElements("Gg", cs.zkrp.Gg[:n])
+	t.AppendElements("Hh", cs.zkrp.Hh[:n])
+	t.AppendInt64("m", int64(len(cs.V)))
+	t.AppendElements("V", cs.V)
+	t.AppendInt64("n", cs.n)
+	t.AppendInt64("Q", int64(len(cs.constraints)))
+	for _, lc := range cs.constraints {
+		var buf []byte
+		for _, tm := range lc {
+			buf = append(buf, byte(tm.v.kind))
+			buf = append(buf, []byte(strconv.FormatInt(tm.v.index, 10) + ":")...)
+			buf = append(buf, []byte(Mod(tm.c, q).String() + ";")...)
+		}
+		t.AppendMessage("constraint", buf)
+	}
+	return t
+}
+
+/*
+flatten computes the vectors wL, wR, wO and wV and the scalar wc such that the
+constraints weighted by the powers z^(q+1) are equal to
+< wL, aL > + < wR, aR > + < wO, aO > = < wV, v > + wc.
+*/
+func (cs *constraintSystem) flatten(z *big.Int, n int64) ([]*big.Int, []*big.Int, []*big.Int, []*big.Int, *big.Int) {
+	q := cs.zkrp.group().Scalar().Order()
+	wL, _ := VectorCopy(new(big.Int).SetInt64(0), n)
+	wR, _ := VectorCopy(new(big.Int).SetInt64(0), n)
+	wO, _ := VectorCopy(new(big.Int).SetInt64(0), n)
+	wV, _ := VectorCopy(new(big.Int).SetInt64(0), int64(len(cs.V)))
+	wc := new(big.Int).SetInt64(0)
+	zq := z
+	for _, lc := range cs.constraints {
+		for _, t := range lc {
+			c := Multiply(zq, t.c)
+			switch t.v.kind {
+			case varOne:
+				wc = Mod(Sub(wc, c), q)
+			case varCommitted:
+				wV[t.v.index] = Mod(Sub(wV[t.v.index], c), q)
+			case varLeft:
+				wL[t.v.index] = Mod(Add(wL[t.v.index], c), q)
+			case varRight:
+				wR[t.v.index] = Mod(Add(wR[t.v.index], c), q)
+			case varOutput:
+				wO[t.v.index] = Mod(Add(wO[t.v.index], c), q)
+			}
+		}
+		zq = Mod(Multiply(zq, z), q)
+	}
+	return wL, wR, wO, wV, wc
+}
+
+/*
+hprime computes the generators h'[i] = h[i]^(y^-i), for i = 0..n-1.
+*/
+func (cs *constraintSystem) hprime(y *big.Int, n int64) ([]Element) {
+	grp := cs.zkrp.group()
+	f := field(grp)
+	return mulElements(grp, cs.zkrp.Hh[:n], f.powerOf(f.Inverse(y), n))
+}
+
+/*
+Prove computes the ZK proof that the committed values satisfy the constraint system.
+*/
+func (cs *constraintSystem) Prove() (proofBPCircuit, error) {
+	var (
+		i int64
+		err error
+		proof proofBPCircuit
+	)
+	if !cs.prover {
+		return proof, errors.New("Only the prover can compute the proof.")
+	}
+	if cs.err != nil {
+		return proof, cs.err
+	}
+	n := cs.size()
+	if n > cs.zkrp.N {
+		return proof, errors.New("Number of multiplication gates exceeds the parameters.")
+	}
+	grp := cs.zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	// The padding gates are equal to 0.0 = 0
+	aL := make([]*big.Int, n)
+	aR := make([]*big.Int, n)
+	aO := make([]*big.Int, n)
+	sL := make([]*big.Int, n)
+	sR := make([]*big.Int, n)
+	zero, _ := VectorCopy(new(big.Int).SetInt64(0), n)
+	copy(aL, zero)
+	copy(aR, zero)
+	copy(aO, zero)
+	copy(aL, cs.aL)
+	copy(aR, cs.aR)
+	copy(aO, cs.aO)
+	g := cs.zkrp.Gg[:n]
+	h := cs.zkrp.Hh[:n]
+	t := cs.newTranscript(n)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// First phase
+	//////////////////////////////////////////////////////////////////////////////
+	alpha, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	beta, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	rho, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	i = 0
+	for i<n {
+		if sL[i], err = f.Random(); err != nil {
+			return proof, err
+		}
+		if sR[i], err = f.Random(); err != nil {
+			return proof, err
+		}
+		i = i + 1
+	}
+	AI, err := commitVector(grp, cs.zkrp.H, g, h, aL, aR, alpha)
+	if err != nil {
+		return proof, err
+	}
+	AO, err := commitVector(grp, cs.zkrp.H, g, h, aO, zero, beta)
+	if err != nil {
+		return proof, err
+	}
+	S, err := commitVector(grp, cs.zkrp.H, g, h, sL, sR, rho)
+	if err != nil {
+		return proof, err
+	}
+
+	// Fiat-Shamir heuristic to compute challenges y, z
+	t.AppendPoint("AI", AI)
+	t.AppendPoint("AO", AO)
+	t.AppendPoint("S", S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Second phase
+	//////////////////////////////////////////////////////////////////////////////
+	wL, wR, wO, wV, _ := cs.flatten(z, n)
+	vy := f.powerOf(y, n)
+	vyinv := f.powerOf(f.Inverse(y), n)
+
+	// l(X) = l1.X + l2.X^2 + l3.X^3
+	// l1 = aL + y^-n . wR, l2 = aO, l3 = sL
+	yinvwR, _ := f.vectorMul(vyinv, wR)
+	l1, _ := f.vectorAdd(aL, yinvwR)
+	l2 := aO
+	l3 := sL
+
+	// r(X) = r0 + r1.X + r3.X^3
+	// r0 = wO - y^n, r1 = y^n . aR + wL, r3 = y^n . sR
+	r0, _ := f.vectorSub(wO, vy)
+	r1, _ := f.vectorMul(vy, aR)
+	r1, _ = f.vectorAdd(r1, wL)
+	r3, _ := f.vectorMul(vy, sR)
+
+	// t(X) = < l(X), r(X) > = sum_k t[k].X^k, where t[2] is not committed
+	sp := func(a, b []*big.Int) *big.Int {
+		res, _ := f.scalarProduct(a, b)
+		return res
+	}
+	tk := make([]*big.Int, 7)
+	tk[1] = sp(l1, r0)
+	tk[3] = Mod(Add(sp(l2, r1), sp(l3, r0)), q)
+	tk[4] = Mod(Add(sp(l1, r3), sp(l3, r1)), q)
+	tk[5] = sp(l2, r3)
+	tk[6] = sp(l3, r3)
+	tau := make([]*big.Int, 7)
+	T := make([]Element, 7)
+	for _, k := range []int{1, 3, 4, 5, 6} {
+		if tau[k], err = f.Random(); err != nil {
+			return proof, err
+		}
+		if T[k], err = cs.zkrp.commit(tk[k], tau[k]); err != nil {
+			return proof, err
+		}
+	}
+
+	// Fiat-Shamir heuristic to compute 'random' challenge x
+	t.AppendPoint("T1", T[1])
+	t.AppendPoint("T3", T[3])
+	t.AppendPoint("T4", T[4])
+	t.AppendPoint("T5", T[5])
+	t.AppendPoint("T6", T[6])
+	x := t.ChallengeScalar("x", q)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Third phase                                                              //
+	//////////////////////////////////////////////////////////////////////////////
+	vx := f.powerOf(x, 7)
+
+	// taux = sum_k tau[k].x^k + x^2 . < wV, gamma >
+	wVgamma, _ := f.scalarProduct(wV, cs.gamma)
+	taux := Multiply(vx[2], wVgamma)
+	for _, k := range []int{1, 3, 4, 5, 6} {
+		taux = Add(taux, Multiply(tau[k], vx[k]))
+	}
+	taux = Mod(taux, q)
+
+	// mu = alpha.x + beta.x^2 + rho.x^3
+	mu := Add(Multiply(alpha, vx[1]), Multiply(beta, vx[2]))
+	mu = Mod(Add(mu, Multiply(rho, vx[3])), q)
+
+	// bl = l(x), br = r(x) and tprime = < bl, br >
+	bl := f.vectorScalarMul(l1, vx[1])
+	bl, _ = f.vectorAdd(bl, f.vectorScalarMul(l2, vx[2]))
+	bl, _ = f.vectorAdd(bl, f.vectorScalarMul(l3, vx[3]))
+	br := f.vectorScalarMul(r1, vx[1])
+	br, _ = f.vectorAdd(br, r0)
+	br, _ = f.vectorAdd(br, f.vectorScalarMul(r3, vx[3]))
+	tprime, _ := f.scalarProduct(bl, br)
+
+	// Inner Product over (g, h', P.h^-mu, tprime)
+	zkip := cs.zkrp.Zkip
+	zkip.N = n
+	zkip.Gg = g
+	zkip.Hh = cs.hprime(y, n)
+
+	// The commitment P = g^bl.h'^br is determined by the transcript
+	t.AppendScalar("taux", taux)
+	t.AppendScalar("mu", mu)
+	t.AppendScalar("tprime", tprime)
+	proofip, err := zkip.prove(t, bl, br)
+	if err != nil {
+		return proof, err
+	}
+
+	proof.AI = AI
+	proof.AO = AO
+	proof.S = S
+	proof.T1 = T[1]
+	proof.T3 = T[3]
+	proof.T4 = T[4]
+	proof.T5 = T[5]
+	proof.T6 = T[6]
+	proof.Taux = taux
+	proof.Mu = mu
+	proof.Tprime = tprime
+	proof.Proofip = proofip
+
+	return proof, nil
+}
+
+/*
+Verify returns true if and only if the proof is valid, i.e. if the committed values
+satisfy the constraint system.
+*/
+func (cs *constraintSystem) Verify(proof proofBPCircuit) (bool, error) {
+	var (
+		i int64
+		j int
+	)
+	if cs.err != nil {
+		return false, cs.err
+	}
+	n := cs.size()
+	if n > cs.zkrp.N {
+		return false, errors.New("Number of multiplication gates exceeds the parameters.")
+	}
+	grp := cs.zkrp.group()
+	if err := cs.zkrp.Validate(); err != nil {
+		return false, err
+	}
+	if err := checkElements(grp, cs.V, int64(len(cs.V)), "V"); err != nil {
+		return false, err
+	}
+	if err := proof.validate(grp); err != nil {
+		return false, err
+	}
+	if err := checkRounds(&proof.Proofip, n); err != nil {
+		return false, err
+	}
+	f := field(grp)
+	q := f.Order()
+	g := cs.zkrp.Gg[:n]
+	t := cs.newTranscript(n)
+	t.AppendPoint("AI", proof.AI)
+	t.AppendPoint("AO", proof.AO)
+	t.AppendPoint("S", proof.S)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+	t.AppendPoint("T1", proof.T1)
+	t.AppendPoint("T3", proof.T3)
+	t.AppendPoint("T4", proof.T4)
+	t.AppendPoint("T5", proof.T5)
+	t.AppendPoint("T6", proof.T6)
+	x := t.ChallengeScalar("x", q)
+
+	wL, wR, wO, wV, wc := cs.flatten(z, n)
+	vy := f.powerOf(y, n)
+	vyinv := f.powerOf(f.Inverse(y), n)
+	vx := f.powerOf(x, 7)
+	yinvwR, _ := f.vectorMul(vyinv, wR)
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Check that g^tprime.h^taux = g^(x^2.(delta + wc)).V^(x^2.wV).prod T[k]^(x^k)
+	//////////////////////////////////////////////////////////////////////////////
+
+	// delta(y,z) = < y^-n . wR, wL >
+	delta, _ := f.scalarProduct(yinvwR, wL)
+	x2 := vx[2]
+	points := []Element{cs.zkrp.G, cs.zkrp.H, proof.T1, proof.T3, proof.T4, proof.T5, proof.T6}
+	scalars := []*big.Int{
+		f.Reduce(Sub(proof.Tprime, Multiply(x2, Add(delta, wc)))),
+		proof.Taux,
+		Sub(q, vx[1]),
+		Sub(q, vx[3]),
+		Sub(q, vx[4]),
+		Sub(q, vx[5]),
+		Sub(q, vx[6]),
+	}
+	for j=0; j<len(cs.V); j++ {
+		points = append(points, cs.V[j])
+		scalars = append(scalars, Sub(q, Mod(Multiply(x2, wV[j]), q)))
+	}
+	check, _ := grp.MultiExp(points, scalars)
+	ct := check.IsZero()
+
+	//////////////////////////////////////////////////////////////////////////////
+	// Compute P = AI^x.AO^(x^2).S^(x^3).g^(x.y^-n.wR).h'^(x.wL + wO - y^n).h^-mu
+	//////////////////////////////////////////////////////////////////////////////
+	hprime := cs.hprime(y, n)
+	points = []Element{proof.AI, proof.AO, proof.S, cs.zkrp.H}
+	scalars = []*big.Int{vx[1], vx[2], vx[3], Sub(q, proof.Mu)}
+	i = 0
+	for i<n {
+		points = append(points, g[i], hprime[i])
+		scalars = append(scalars, f.Reduce(Multiply(vx[1], yinvwR[i])))
+		scalars = append(scalars, f.Reduce(Sub(Add(Multiply(vx[1], wL[i]), wO[i]), vy[i])))
+		i = i + 1
+	}
+	P, _ := grp.MultiExp(points, scalars)
+
+	// Verify Inner Product Proof ################################################
+	zkip := cs.zkrp.Zkip
+	zkip.N = n
+	zkip.Gg = g
+	zkip.Hh = hprime
+	t.AppendScalar("taux", proof.Taux)
+	t.AppendScalar("mu", proof.Mu)
+	t.AppendScalar("tprime", proof.Tprime)
+	ok, _ := zkip.verifyMultiExp(t, P, proof.Tprime, proof.Proofip)
+
+	return ct && ok, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+/*
+rangeGadget constrains lc to be in [0,2^bits), where value is the value of lc,
+which is nil for the verifier.
+*/
+func rangeGadget(cs *constraintSystem, lc linearCombination, value *big.Int, bits int) {
+	one := new(big.Int).SetInt64(1)
+	mone := new(big.Int).SetInt64(-1)
+	sum := linearCombination{}
+	exp := new(big.Int).SetInt64(1)
+	for i := 0; i < bits; i++ {
+		var l, r *big.Int
+		if value != nil {
+			// b.(b-1) = 0
+			l = new(big.Int).SetUint64(uint64(value.Bit(i)))
+			r = Sub(l, one)
+		}
+		vl, vr, vo := cs.Allocate(l, r)
+		cs.Constrain(cs.LC(vo))
+		cs.Constrain(cs.LC(vl).Add(mone, vr).Add(mone, cs.One()))
+		sum = sum.Add(exp, vl)
+		exp = Multiply(exp, new(big.Int).SetInt64(2))
+	}
+	cs.Constrain(lc.AddLC(mone, sum))
+}
+
+/*
+setGadget constrains v to be one of the elements of set, by checking that
+prod (v - set[i]) = 0.
+*/
+func setGadget(cs *constraintSystem, v variable, set []int64) {
+	product := cs.LC(v).Add(new(big.Int).SetInt64(-set[0]), cs.One())
+	for i := 1; i < len(set); i++ {
+		_, _, o := cs.Multiply(product, cs.LC(v).Add(new(big.Int).SetInt64(-set[i]), cs.One()))
+		product = cs.LC(o)
+	}
+	cs.Constrain(product)
+}
+
+/*
+policy builds the circuit "age >= 18 AND country in countries", where age is
+smaller than 2^8.
+*/
+func policy(cs *constraintSystem, age, country variable, ageValue *big.Int) {
+	var diff *big.Int
+	if ageValue != nil {
+		diff = Sub(ageValue, new(big.Int).SetInt64(18))
+	}
+	rangeGadget(cs, cs.LC(age).Add(new(big.Int).SetInt64(-18), cs.One()), diff, 8)
+	setGadget(cs, country, []int64{31, 32, 33, 49})
+}
+
+/*
+provePolicy commits to age and country and proves the policy.
+*/
+func provePolicy(zkrp *bpCircuit, age, country int64) ([]Element, proofBPCircuit) {
+	cs := zkrp.Prover()
+	vage, Vage, _ := cs.Commit(new(big.Int).SetInt64(age))
+	vcountry, Vcountry, _ := cs.Commit(new(big.Int).SetInt64(country))
+	policy(cs, vage, vcountry, new(big.Int).SetInt64(age))
+	proof, _ := cs.Prove()
+	return []Element{Vage, Vcountry}, proof
+}
+
+/*
+verifyPolicy verifies the proof of the policy for the commitments V.
+*/
+func verifyPolicy(zkrp *bpCircuit, V []Element, proof proofBPCircuit) bool {
+	cs, vars := zkrp.Verifier(V)
+	policy(cs, vars[0], vars[1], nil)
+	ok, _ := cs.Verify(proof)
+	return ok
+}
+
+/*
+Test a single multiplication a.b = c between committed values.
+*/
+func TestCircuitMultiplication(t *testing.T) {
+	var (
+		zkrp bpCircuit
+	)
+	zkrp.Setup(1)
+	mone := new(big.Int).SetInt64(-1)
+	values := [][]int64{{3, 5, 15}, {3, 5, 16}}
+	expected := []bool{true, false}
+	for k := range values {
+		cs := zkrp.Prover()
+		a, Va, _ := cs.Commit(new(big.Int).SetInt64(values[k][0]))
+		b, Vb, _ := cs.Commit(new(big.Int).SetInt64(values[k][1]))
+		c, Vc, _ := cs.Commit(new(big.Int).SetInt64(values[k][2]))
+		_, _, o := cs.Multiply(cs.LC(a), cs.LC(b))
+		cs.Constrain(cs.LC(o).Add(mone, c))
+		proof, _ := cs.Prove()
+
+		vcs, vars := zkrp.Verifier([]Element{Va, Vb, Vc})
+		_, _, vo := vcs.Multiply(vcs.LC(vars[0]), vcs.LC(vars[1]))
+		vcs.Constrain(vcs.LC(vo).Add(mone, vars[2]))
+		ok, _ := vcs.Verify(proof)
+		if ok != expected[k] {
+			t.Errorf("Assert failure: expected %t, actual: %t", expected[k], ok)
+		}
+	}
+}
+
+/*
+Test the policy "age >= 18 AND country in list".
+*/
+func TestCircuitPolicy(t *testing.T) {
+	var (
+		zkrp bpCircuit
+	)
+	zkrp.Setup(16)
+	ages := []int64{18, 42, 17, 42, 3}
+	countries := []int64{31, 49, 31, 34, 34}
+	expected := []bool{true, true, false, false, false}
+	for k := range ages {
+		V, proof := provePolicy(&zkrp, ages[k], countries[k])
+		ok := verifyPolicy(&zkrp, V, proof)
+		if ok != expected[k] {
+			t.Errorf("Assert failure: age %d, country %d, expected %t, actual: %t", ages[k], countries[k], expected[k], ok)
+		}
+	}
+}
+
+/*
+Test that the proof is bound to the commitments and to the constraint system.
+*/
+func TestCircuitBinding(t *testing.T) {
+	var (
+		zkrp bpCircuit
+	)
+	zkrp.Setup(16)
+	V, proof := provePolicy(&zkrp, 42, 31)
+	Vother, _ := provePolicy(&zkrp, 42, 31)
+	ok := verifyPolicy(&zkrp, []Element{Vother[0], V[1]}, proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+	// Another circuit with the same gates, but another set of countries
+	cs, vars := zkrp.Verifier(V)
+	rangeGadget(cs, cs.LC(vars[0]).Add(new(big.Int).SetInt64(-18), cs.One()), nil, 8)
+	setGadget(cs, vars[1], []int64{30, 32, 33, 49})
+	ok, _ = cs.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
+
+/*
+Test that constraints referring to undeclared variables are rejected, instead of
+making the prover or the verifier panic.
+*/
+func TestCircuitUndeclared(t *testing.T) {
+	var (
+		zkrp bpCircuit
+	)
+	zkrp.Setup(4)
+	cs := zkrp.Prover()
+	a, _, _ := cs.Commit(new(big.Int).SetInt64(3))
+	undeclared := variable{kind: varCommitted, index: 1}
+	if err := cs.Constrain(cs.LC(a).Add(new(big.Int).SetInt64(-1), undeclared)); err == nil {
+		t.Errorf("Assert failure: expected error for an undeclared committed variable")
+	}
+	if _, err := cs.Prove(); err == nil {
+		t.Errorf("Assert failure: expected error from Prove")
+	}
+	V := []Element{new(p256).ScalarBaseMult(new(big.Int).SetInt64(3))}
+	vcs, vars := zkrp.Verifier(V)
+	vcs.Multiply(vcs.LC(vars[0]), vcs.LC(variable{kind: varOutput, index: 3}))
+	if ok, err := vcs.Verify(proofBPCircuit{}); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if vcs.Constrain(vcs.LC(variable{kind: 7})) == nil {
+		t.Errorf("Assert failure: expected error for an unknown kind of variable")
+	}
+}
+
+func TestCircuitSetupInput(t *testing.T) {
+	var (
+		zkrp bpCircuit
+	)
+	if zkrp.Setup(0) == nil {
+		t.Errorf("Assert failure: expected error for n = 0")
+	}
+	zkrp.Setup(4)
+	V, proof := provePolicy(&zkrp, 42, 31)
+	if proof.AI != nil || verifyPolicy(&zkrp, V, proof) {
+		t.Errorf("Assert failure: expected failure when the circuit exceeds the parameters")
+	}
+}
+
+func BenchmarkCircuitPolicy(b *testing.B) {
+	var (
+		zkrp bpCircuit
+	)
+	zkrp.Setup(16)
+	for i := 0; i < b.N; i++ {
+		V, proof := provePolicy(&zkrp, 42, 31)
+		if !verifyPolicy(&zkrp, V, proof) {
+			b.Errorf("Assert failure: expected true")
+		}
+	}
+}
@@ -0,0 +1,526 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the implementation of the Bulletproofs+ range proof, as
+described in the paper by Chung, Han, Ju, Kim and Seo:
+
+	Bulletproofs+: Shorter Proofs for Privacy-Enhanced Distributed Ledger
+	https://eprint.iacr.org/2020/735
+
+Instead of the inner product argument, Bulletproofs+ uses a weighted inner product
+argument (WIP), where the weighted inner product of a and b is
+a (.)y b = sum_i a[i].b[i].y^(i+1). The proof that a committed value belongs to
+[0,2^N) only has 2log(N)+3 points and 3 scalars, against 2log(N)+4 points and 5
+scalars of Bulletproofs. As in bulletproofs.go, the proof for [a,b) is composed
+by two proofs that x-b+2^N and x-a belong to [0,2^N), and the same generators
+are used: G, H = MapToGroup(SEEDH), Gg and Hh.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+)
+
+/*
+Bulletproofs+ parameters. As with bp, they are not modified after Setup.
+*/
+type bpp struct {
+	N int64
+	A int64
+	B int64
+	// Group over which the proofs are computed, secp256k1 if nil
+	Group Group
+	G Element
+	H Element
+	Gg []Element
+	Hh []Element
+}
+
+/*
+Bulletproofs+ proof that V commits to a secret x in the interval [a,b).
+*/
+type proofBPP struct {
+	V Element
+	P1 proofBPPUL
+	P2 proofBPPUL
+}
+
+/*
+Bulletproofs+ proof that a committed value belongs to the interval [0,2^N).
+*/
+type proofBPPUL struct {
+	A Element
+	Proofwip proofWIP
+}
+
+/*
+Weighted inner product proof. Rprime, Sprime and Dprime are r', s' and delta' of
+the paper.
+*/
+type proofWIP struct {
+	Ls []Element
+	Rs []Element
+	A Element
+	B Element
+	Rprime *big.Int
+	Sprime *big.Int
+	Dprime *big.Int
+}
+
+/*
+group returns the group of the parameters, which is secp256k1 unless another
+group was chosen before calling Setup.
+*/
+func (zkrp *bpp) group() (Group) {
+	if zkrp.Group == nil {
+		return SECP256K1
+	}
+	return zkrp.Group
+}
+
+/*
+weightedInnerProduct computes a (.)y b = sum_i a[i].b[i].y^(i+1).
+*/
+func (f *scalarField) weightedInnerProduct(a, b []*big.Int, y *big.Int) (*big.Int, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := new(big.Int).SetInt64(0)
+	yi := new(big.Int).SetInt64(1)
+	for i=0; i<len(a); i++ {
+		yi = Mod(Multiply(yi, y), f.q)
+		result = Add(result, Multiply(Multiply(a[i], b[i]), yi))
+	}
+	return Mod(result, f.q), nil
+}
+
+/*
+Setup is responsible for computing the common parameters. As in bp, the range
+proof is done over the interval [a,b), where N is the smallest power of 2 such
+that b-a <= 2^N.
+*/
+func (zkrp *bpp) Setup(a,b int64) (error) {
+	var (
+		err error
+	)
+	if a >= b {
+		return errors.New("a must be less than b")
+	}
+	grp := zkrp.group()
+	zkrp.A = a
+	zkrp.B = b
+	zkrp.N = rangeSize(a, b)
+	zkrp.Group = grp
+	zkrp.G = grp.Generator()
+	zkrp.H, _, err = groupHU(grp)
+	if err != nil {
+		return err
+	}
+	zkrp.Gg, zkrp.Hh, err = groupGenerators(grp, zkrp.N)
+	return err
+}
+
+/*
+newTranscript returns the transcript of the range proof, which binds the interval
+[a,b), N and the generators.
+*/
+func (zkrp *bpp) newTranscript() (*Transcript) {
+	t := NewTranscript("Bulletproofs+ range proof")
+	t.AppendInt64("a", zkrp.A)
+	t.AppendInt64("b", zkrp.B)
+	t.AppendInt64("N", zkrp.N)
+	t.AppendPoint("G", zkrp.G)
+	t.AppendPoint("H", zkrp.H)
+	t.AppendElements("Gg", zkrp.Gg)
+	t.AppendElements("Hh", zkrp.Hh)
+	return t
+}
+
+/*
+commit computes the Pedersen commitment g^x.h^r in constant time.
+*/
+func (zkrp *bpp) commit(x, r *big.Int) (Element, error) {
+	return zkrp.group().MultiExpCT([]Element{zkrp.G, zkrp.H}, []*big.Int{x, r})
+}
+
+/*
+Commit computes the commitment V = g^secret.h^gamma with a random gamma, and returns
+both V and gamma.
+*/
+func (zkrp *bpp) Commit(secret *big.Int) (Element, *big.Int, error) {
+	gamma, err := zkrp.group().Scalar().Random()
+	if err != nil {
+		return nil, nil, err
+	}
+	V, err := zkrp.commit(secret, gamma)
+	if err != nil {
+		return nil, nil, err
+	}
+	return V, gamma, nil
+}
+
+/*
+ShiftCommitment computes the commitments to x-b+2^N and x-a, given the commitment
+V to x.
+*/
+func (zkrp *bpp) ShiftCommitment(V Element) (Element, Element) {
+	grp := zkrp.group()
+	ul := new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N))
+	ulb := Sub(ul, new(big.Int).SetInt64(zkrp.B))
+	Vb := grp.Add(V, grp.ScalarMult(zkrp.G, ulb))
+	ma := Sub(new(big.Int).SetInt64(0), new(big.Int).SetInt64(zkrp.A))
+	Va := grp.Add(V, grp.ScalarMult(zkrp.G, ma))
+	return Vb, Va
+}
+
+/*
+Prove computes the ZK proof that secret belongs to the interval [a,b).
+*/
+func (zkrp *bpp) Prove(secret *big.Int) (proofBPP, error) {
+	var (
+		proof proofBPP
+	)
+	V, gamma, err := zkrp.Commit(secret)
+	if err != nil {
+		return proof, err
+	}
+	return zkrp.ProveCommitment(V, secret, gamma)
+}
+
+/*
+ProveCommitment computes the ZK proof that the existing commitment V commits to an
+element of the interval [a,b), given its opening (secret, gamma).
+*/
+func (zkrp *bpp) ProveCommitment(V Element, secret, gamma *big.Int) (proofBPP, error) {
+	var (
+		proof proofBPP
+		err error
+	)
+	C, _ := zkrp.commit(secret, gamma)
+	if V == nil || !C.Equals(V) {
+		return proof, errors.New("Commitment does not match the opening.")
+	}
+	t := zkrp.newTranscript()
+	Vb, Va := zkrp.ShiftCommitment(V)
+
+	// x - b + 2^N
+	ul := new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N))
+	xb := Add(Sub(secret, new(big.Int).SetInt64(zkrp.B)), ul)
+	proof.P1, err = zkrp.proveUL(t, xb, gamma, Vb)
+	if err != nil {
+		return proof, err
+	}
+
+	// x - a
+	xa := Sub(secret, new(big.Int).SetInt64(zkrp.A))
+	proof.P2, err = zkrp.proveUL(t, xa, gamma, Va)
+	if err != nil {
+		return proof, err
+	}
+	proof.V = V
+	return proof, nil
+}
+
+/*
+Verify returns true if and only if the proof is valid, i.e. if V commits to
+an element of the interval [a,b).
+*/
+func (zkrp *bpp) Verify(proof proofBPP) (bool, error) {
+	return zkrp.VerifyCommitment(proof.V, proof)
+}
+
+/*
+VerifyCommitment returns true if and only if the proof shows that the commitment V,
+which is given by the verifier, commits to an element of the interval [a,b).
+*/
+func (zkrp *bpp) VerifyCommitment(V Element, proof proofBPP) (bool, error) {
+	if err := zkrp.validateVerify(V, &proof.P1, &proof.P2); err != nil {
+		return false, err
+	}
+	t := zkrp.newTranscript()
+	Vb, Va := zkrp.ShiftCommitment(V)
+	first, err := zkrp.verifyUL(t, Vb, proof.P1)
+	if err != nil {
+		return false, err
+	}
+	second, err := zkrp.verifyUL(t, Va, proof.P2)
+	if err != nil {
+		return false, err
+	}
+	return first && second, nil
+}
+
+/*
+ulPowers returns the vector of the first N+2 powers of y and the vector d of
+exponents 2^i.y^(N-i) of Hh used by both the prover and the verifier.
+*/
+func (zkrp *bpp) ulPowers(f *scalarField, y *big.Int) ([]*big.Int, []*big.Int) {
+	var (
+		i int64
+	)
+	vy := f.powerOf(y, zkrp.N + 2)
+	d := make([]*big.Int, zkrp.N)
+	p2 := new(big.Int).SetInt64(1)
+	for i=0; i<zkrp.N; i++ {
+		d[i] = Mod(Multiply(p2, vy[zkrp.N - i]), f.q)
+		p2 = Add(p2, p2)
+	}
+	return vy, d
+}
+
+/*
+proveUL computes the proof that secret belongs to [0,2^N), where V is the
+commitment to secret with randomness gamma. The range proof is reduced to the
+weighted inner product argument for the commitment
+Ahat = A.g^(-z.1^n).h^(d + z.1^n).V^(y^(N+1)).G^zeta, with witness
+aL - z.1^n, aR + d + z.1^n and alpha + gamma.y^(N+1).
+*/
+func (zkrp *bpp) proveUL(t *Transcript, secret, gamma *big.Int, V Element) (proofBPPUL, error) {
+	var (
+		i int64
+		proof proofBPPUL
+	)
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	aL, _ := Decompose(secret, 2, zkrp.N)
+	aR, _ := ComputeAR(aL)
+	naL, _ := VectorConvertToBig(aL, zkrp.N)
+	naR, _ := VectorConvertToBig(aR, zkrp.N)
+	alpha, err := f.Random()
+	if err != nil {
+		return proof, err
+	}
+	A, err := commitVector(grp, zkrp.H, zkrp.Gg, zkrp.Hh, naL, naR, alpha)
+	if err != nil {
+		return proof, err
+	}
+
+	t.AppendPoint("V", V)
+	t.AppendPoint("A", A)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+
+	vy, d := zkrp.ulPowers(f, y)
+	aHatL := make([]*big.Int, zkrp.N)
+	aHatR := make([]*big.Int, zkrp.N)
+	for i=0; i<zkrp.N; i++ {
+		aHatL[i] = Mod(Sub(naL[i], z), q)
+		aHatR[i] = Mod(Add(Add(naR[i], d[i]), z), q)
+	}
+	alphaHat := Mod(Add(alpha, Multiply(gamma, vy[zkrp.N + 1])), q)
+
+	proof.A = A
+	proof.Proofwip, err = zkrp.proveWIP(t, y, aHatL, aHatR, alphaHat)
+	return proof, err
+}
+
+/*
+roundExp computes g^a.h^b.G^c.H^d in constant time, which gives L and R in each
+round of the weighted inner product argument.
+*/
+func (zkrp *bpp) roundExp(g,h []Element, a,b []*big.Int, c,d *big.Int) (Element, error) {
+	points := append(append(append([]Element{}, g...), h...), zkrp.G, zkrp.H)
+	scalars := append(append(append([]*big.Int{}, a...), b...), c, d)
+	return zkrp.group().MultiExpCT(points, scalars)
+}
+
+/*
+proveWIP computes the weighted inner product argument of the paper for the
+generators Gg and Hh, where G is the generator of the weighted inner product
+and H is the generator of the randomness. The rounds reduce the size of the
+vectors by half, and the last one is the zero-knowledge argument for vectors
+of size 1. Every exponentiation with secret exponents runs in constant time.
+*/
+func (zkrp *bpp) proveWIP(t *Transcript, y *big.Int, a, b []*big.Int, alpha *big.Int) (proofWIP, error) {
+	var (
+		proof proofWIP
+		err error
+		L, R Element
+	)
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	g := zkrp.Gg
+	h := zkrp.Hh
+	n := int64(len(a))
+	for n > 1 {
+		n2 := n / 2
+		yn2 := new(big.Int).Exp(y, new(big.Int).SetInt64(n2), q)
+		yinvn2 := f.Inverse(yn2)
+
+		// cL = a1 (.)y b2 and cR = (y^n'.a2) (.)y b1
+		cL, _ := f.weightedInnerProduct(a[:n2], b[n2:], y)
+		a2y := f.vectorScalarMul(a[n2:], yn2)
+		cR, _ := f.weightedInnerProduct(a2y, b[:n2], y)
+		dL, _ := f.Random()
+		dR, _ := f.Random()
+
+		// L = g2^(y^-n'.a1).h1^b2.G^cL.H^dL
+		L, err = zkrp.roundExp(g[n2:], h[:n2], f.vectorScalarMul(a[:n2], yinvn2), b[n2:], cL, dL)
+		if err != nil {
+			return proof, err
+		}
+		// R = g1^(y^n'.a2).h2^b1.G^cR.H^dR
+		R, err = zkrp.roundExp(g[:n2], h[n2:], a2y, b[:n2], cR, dR)
+		if err != nil {
+			return proof, err
+		}
+
+		t.AppendPoint("L", L)
+		t.AppendPoint("R", R)
+		e := t.ChallengeScalar("e", q)
+		einv := f.Inverse(e)
+		e2 := Mod(Multiply(e, e), q)
+		e2inv := Mod(Multiply(einv, einv), q)
+
+		// g' = g1^(e^-1).g2^(e.y^-n'), h' = h1^e.h2^(e^-1)
+		g = foldElements(grp, g[:n2], g[n2:], einv, Mod(Multiply(e, yinvn2), q))
+		h = foldElements(grp, h[:n2], h[n2:], e, einv)
+		// a' = a1.e + a2.y^n'.e^-1, b' = b1.e^-1 + b2.e
+		a, _ = f.vectorAdd(f.vectorScalarMul(a[:n2], e), f.vectorScalarMul(a2y, einv))
+		b, _ = f.vectorAdd(f.vectorScalarMul(b[:n2], einv), f.vectorScalarMul(b[n2:], e))
+		// alpha' = dL.e^2 + alpha + dR.e^-2
+		alpha = Mod(Add(alpha, Add(Multiply(dL, e2), Multiply(dR, e2inv))), q)
+
+		proof.Ls = append(proof.Ls, L)
+		proof.Rs = append(proof.Rs, R)
+		n = n2
+	}
+
+	// Zero-knowledge argument for vectors of size 1
+	r, _ := f.Random()
+	s, _ := f.Random()
+	delta, _ := f.Random()
+	eta, _ := f.Random()
+	// A = g^r.h^s.G^(y.(r.b + s.a)).H^delta
+	cA := Mod(Multiply(y, Add(Multiply(r, b[0]), Multiply(s, a[0]))), q)
+	proof.A, err = grp.MultiExpCT([]Element{g[0], h[0], zkrp.G, zkrp.H}, []*big.Int{r, s, cA, delta})
+	if err != nil {
+		return proof, err
+	}
+	// B = G^(y.r.s).H^eta
+	cB := Mod(Multiply(y, Multiply(r, s)), q)
+	proof.B, err = grp.MultiExpCT([]Element{zkrp.G, zkrp.H}, []*big.Int{cB, eta})
+	if err != nil {
+		return proof, err
+	}
+	t.AppendPoint("A", proof.A)
+	t.AppendPoint("B", proof.B)
+	e := t.ChallengeScalar("e", q)
+	e2 := Mod(Multiply(e, e), q)
+	proof.Rprime = Mod(Add(r, Multiply(a[0], e)), q)
+	proof.Sprime = Mod(Add(s, Multiply(b[0], e)), q)
+	proof.Dprime = Mod(Add(Add(eta, Multiply(delta, e)), Multiply(alpha, e2)), q)
+	return proof, nil
+}
+
+/*
+verifyUL returns true if and only if the proof shows that V commits to an element
+of [0,2^N). Instead of folding the generators, the verification equation of the
+weighted inner product argument, P^(e^2).A^e.B == g^(r'.e.s).h^(s'.e.s^-1).G^(r'.s'.y).H^delta',
+is checked with a single multi-exponentiation of size 2N+2log(N)+6, where s are
+the exponents of the folded generators given by ipScalars.
+*/
+func (zkrp *bpp) verifyUL(t *Transcript, V Element, proof proofBPPUL) (bool, error) {
+	var (
+		i int64
+		j int
+		points []Element
+		scalars []*big.Int
+	)
+	grp := zkrp.group()
+	f := field(grp)
+	q := f.Order()
+	w := proof.Proofwip
+	logn := len(w.Ls)
+
+	t.AppendPoint("V", V)
+	t.AppendPoint("A", proof.A)
+	y := t.ChallengeScalar("y", q)
+	z := t.ChallengeScalar("z", q)
+	x := make([]*big.Int, logn)
+	xinv := make([]*big.Int, logn)
+	for j=0; j<logn; j++ {
+		t.AppendPoint("L", w.Ls[j])
+		t.AppendPoint("R", w.Rs[j])
+		x[j] = t.ChallengeScalar("e", q)
+		xinv[j] = f.Inverse(x[j])
+	}
+	t.AppendPoint("A", w.A)
+	t.AppendPoint("B", w.B)
+	e := t.ChallengeScalar("e", q)
+	e2 := Mod(Multiply(e, e), q)
+
+	s, err := f.ipScalars(x, zkrp.N)
+	if err != nil {
+		return false, err
+	}
+	sinv, _ := f.ipScalars(xinv, zkrp.N)
+	vy, d := zkrp.ulPowers(f, y)
+	vyinv := f.powerOf(f.Inverse(y), zkrp.N)
+
+	// zeta = (z - z^2).sum(y^i) - z.y^(N+1).(2^N - 1)
+	sy := new(big.Int).SetInt64(0)
+	for i=1; i<=zkrp.N; i++ {
+		sy = Add(sy, vy[i])
+	}
+	z2 := Mod(Multiply(z, z), q)
+	ul := Sub(new(big.Int).Lsh(new(big.Int).SetInt64(1), uint(zkrp.N)), new(big.Int).SetInt64(1))
+	zeta := Sub(Multiply(Sub(z, z2), sy), Multiply(Multiply(z, vy[zkrp.N + 1]), ul))
+
+	re := Mod(Multiply(w.Rprime, e), q)
+	se := Mod(Multiply(w.Sprime, e), q)
+	mz := Mod(Multiply(e2, Sub(q, z)), q)
+	points = make([]Element, 0, 2*zkrp.N + 2*int64(logn) + 6)
+	scalars = make([]*big.Int, 0, 2*zkrp.N + 2*int64(logn) + 6)
+	for i=0; i<zkrp.N; i++ {
+		// g[i]^(-e^2.z - r'.e.s[i].y^-i)
+		points = append(points, zkrp.Gg[i])
+		scalars = append(scalars, Sub(mz, Multiply(re, Mod(Multiply(s[i], vyinv[i]), q))))
+		// h[i]^(e^2.(d[i] + z) - s'.e.s[i]^-1)
+		points = append(points, zkrp.Hh[i])
+		scalars = append(scalars, Sub(Multiply(e2, Add(d[i], z)), Multiply(se, sinv[i])))
+	}
+	for j=0; j<logn; j++ {
+		// L[j]^(e^2.x[j]^2) and R[j]^(e^2.x[j]^-2)
+		points = append(points, w.Ls[j], w.Rs[j])
+		scalars = append(scalars, Multiply(e2, Multiply(x[j], x[j])))
+		scalars = append(scalars, Multiply(e2, Multiply(xinv[j], xinv[j])))
+	}
+	points = append(points, proof.A, V, zkrp.G, zkrp.H, w.A, w.B)
+	scalars = append(scalars, e2)
+	scalars = append(scalars, Multiply(e2, vy[zkrp.N + 1]))
+	scalars = append(scalars, Sub(Multiply(e2, zeta), Multiply(Multiply(w.Rprime, w.Sprime), y)))
+	scalars = append(scalars, Sub(q, w.Dprime))
+	scalars = append(scalars, e)
+	scalars = append(scalars, new(big.Int).SetInt64(1))
+	for i := range scalars {
+		scalars[i] = Mod(scalars[i], q)
+	}
+
+	result, err := grp.MultiExp(points, scalars)
+	if err != nil {
+		return false, err
+	}
+	return result.IsZero(), nil
+}
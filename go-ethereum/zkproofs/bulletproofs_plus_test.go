@@ -0,0 +1,203 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+/*
+Test the weighted inner product a (.)y b = sum_i a[i].b[i].y^(i+1).
+*/
+func TestWeightedInnerProduct(t *testing.T) {
+	a := []*big.Int{big.NewInt(2), big.NewInt(3)}
+	b := []*big.Int{big.NewInt(5), big.NewInt(7)}
+	// 2.5.10 + 3.7.100
+	c, _ := secpField.weightedInnerProduct(a, b, big.NewInt(10))
+	if c.Cmp(big.NewInt(2200)) != 0 {
+		t.Errorf("Assert failure: expected 2200, actual: %s", c)
+	}
+}
+
+/*
+Test Bulletproofs+ for values inside and outside of the interval, including both ends.
+*/
+func TestBulletproofsPlus(t *testing.T) {
+	var (
+		zkrp bpp
+	)
+	zkrp.Setup(18, 200)
+	values := []int64{18, 40, 199, 17, 200, 250, -1}
+	for _, v := range values {
+		proof, _ := zkrp.Prove(new(big.Int).SetInt64(v))
+		ok, err := zkrp.Verify(proof)
+		expected := v >= 18 && v < 200
+		if ok != expected || err != nil {
+			t.Errorf("Assert failure for %d: expected %t, actual: %t, %v", v, expected, ok, err)
+		}
+	}
+}
+
+/*
+Test Bulletproofs+ over every group.
+*/
+func TestBulletproofsPlusGroups(t *testing.T) {
+	for _, g := range groups {
+		zkrp := bpp{Group: g}
+		zkrp.Setup(0, 1000)
+		proof, _ := zkrp.Prove(new(big.Int).SetInt64(999))
+		ok, err := zkrp.Verify(proof)
+		if ok != true || err != nil {
+			t.Errorf("%s: expected true, actual: %t, %v", g.Name(), ok, err)
+		}
+	}
+}
+
+/*
+Test that tampered proofs and commitments are rejected.
+*/
+func TestTamperedBulletproofsPlus(t *testing.T) {
+	var (
+		zkrp bpp
+	)
+	zkrp.Setup(18, 200)
+	x := new(big.Int).SetInt64(42)
+	V, gamma, _ := zkrp.Commit(x)
+	valid, _ := zkrp.ProveCommitment(V, x, gamma)
+	W, _, _ := zkrp.Commit(x)
+	if ok, _ := zkrp.VerifyCommitment(W, valid); ok != false {
+		t.Errorf("Assert failure: expected false for another commitment")
+	}
+	tamper := []func(p *proofBPP){
+		func(p *proofBPP) { p.P1.Proofwip.Rprime = Mod(Add(p.P1.Proofwip.Rprime, big.NewInt(1)), ORDER) },
+		func(p *proofBPP) { p.P2.Proofwip.Dprime = Mod(Add(p.P2.Proofwip.Dprime, big.NewInt(1)), ORDER) },
+		func(p *proofBPP) { p.P1.A = p.P2.A },
+		func(p *proofBPP) { p.P2.Proofwip.B = p.P2.Proofwip.A },
+		func(p *proofBPP) { p.P1.Proofwip.Ls = append([]Element{}, p.P1.Proofwip.Ls...); p.P1.Proofwip.Ls[0] = p.P1.Proofwip.Rs[0] },
+		func(p *proofBPP) { p.P1, p.P2 = p.P2, p.P1 },
+	}
+	for i, f := range tamper {
+		proof := valid
+		f(&proof)
+		if ok, _ := zkrp.Verify(proof); ok != false {
+			t.Errorf("Assert failure for case %d: expected false, actual: %t", i, ok)
+		}
+	}
+	if ok, _ := zkrp.Verify(valid); ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	if _, err := zkrp.ProveCommitment(V, new(big.Int).SetInt64(43), gamma); err == nil {
+		t.Errorf("Assert failure: expected error for a wrong opening")
+	}
+}
+
+/*
+Test the binary encoding of Bulletproofs+ proofs and compare their size with
+the size of Bulletproofs proofs for the same interval.
+*/
+func TestBulletproofsPlusEncoding(t *testing.T) {
+	var (
+		zkrp bpp
+		params bp
+		decoded proofBPP
+	)
+	zkrp.Setup(0, 4294967296)
+	params.Setup(0, 4294967296)
+	x := new(big.Int).SetInt64(65535)
+	proof, _ := zkrp.Prove(x)
+	data, _ := proof.MarshalBinary()
+	// V and, for each proof, 2log(N)+3 points, 3 scalars and the number of rounds
+	size := POINTSIZE + 2 * ((2*5+3) * POINTSIZE + 3 * SCALARSIZE + 1)
+	if len(data) != size {
+		t.Errorf("Assert failure: expected %d bytes, actual: %d", size, len(data))
+	}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	ok, _ := zkrp.Verify(decoded)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("Assert failure: expected error for a short encoding")
+	}
+	bproof, _ := params.Prove(x)
+	bdata, _ := bproof.MarshalBinary()
+	if len(data) >= len(bdata) {
+		t.Errorf("Assert failure: Bulletproofs+ proof has %d bytes, Bulletproofs proof %d", len(data), len(bdata))
+	}
+	t.Logf("Proof size for [0,2^32): Bulletproofs+ %d bytes, Bulletproofs %d bytes", len(data), len(bdata))
+}
+
+func BenchmarkBulletproofsPlusProve(b *testing.B) {
+	var (
+		zkrp bpp
+	)
+	zkrp.Setup(0, 4294967296)
+	x := new(big.Int).SetInt64(4294967295)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zkrp.Prove(x)
+	}
+}
+
+func BenchmarkBulletproofsPlusVerify(b *testing.B) {
+	var (
+		zkrp bpp
+	)
+	zkrp.Setup(0, 4294967296)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(4294967295))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ok, _ := zkrp.Verify(proof)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
+
+/*
+The benchmarks below measure Bulletproofs with the same interval, to compare with
+Bulletproofs+.
+*/
+func BenchmarkBulletproofsProve(b *testing.B) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(0, 4294967296)
+	x := new(big.Int).SetInt64(4294967295)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zkrp.Prove(x)
+	}
+}
+
+func BenchmarkBulletproofsVerify(b *testing.B) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(0, 4294967296)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(4294967295))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ok, _ := zkrp.Verify(proof)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
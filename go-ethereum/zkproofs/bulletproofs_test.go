@@ -22,6 +22,8 @@ import (
 	"crypto/rand"
 	"fmt"
 	"time" 
+	"strconv"
+	"sync"
 	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
 )
 
@@ -197,14 +199,73 @@ func TestInnerProduct(t *testing.T) {
 	b[3] = new(big.Int).SetInt64(7)
 	c := new(big.Int).SetInt64(142)
 	commit, _ := CommitInnerProduct(zkrp.Gg, zkrp.Hh, a, b)
-	zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh, c)
-	proof, _ := zkip.Prove(a, b, commit)	
-	ok, _ := zkip.Verify(proof)
+	zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	proof, _ := zkip.Prove(a, b, commit, c)	
+	ok, _ := zkip.Verify(commit, c, proof)
 	if ok != true {
 		t.Errorf("Assert failure: expected true, actual: %t", ok)
 	}
 }
 
+/*
+Test method IPScalars, which must return the exponents of the folded generators.
+*/
+func TestIPScalars(t *testing.T) {
+	x := []*big.Int{big.NewInt(3), big.NewInt(5)}
+	s, _ := IPScalars(x, 4)
+	inv3 := ModInverse(big.NewInt(3), ORDER)
+	inv5 := ModInverse(big.NewInt(5), ORDER)
+	ok := (s[0].Cmp(Mod(Multiply(inv3, inv5), ORDER)) == 0)
+	ok = ok && (s[1].Cmp(Mod(Multiply(inv3, big.NewInt(5)), ORDER)) == 0)
+	ok = ok && (s[2].Cmp(Mod(Multiply(big.NewInt(3), inv5), ORDER)) == 0)
+	ok = ok && (s[3].Cmp(big.NewInt(15)) == 0)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+}
+
+/*
+Test that Verify and VerifyMultiExp agree on valid and modified inner product proofs.
+*/
+func TestInnerProductMultiExp(t *testing.T) {
+	var (
+		zkrp bp
+		zkip bip
+		i int64
+	)
+	zkrp.Setup(0, 256)
+	a := make([]*big.Int, zkrp.N)
+	b := make([]*big.Int, zkrp.N)
+	i = 0
+	for i<zkrp.N {
+		a[i], _ = rand.Int(rand.Reader, ORDER)
+		b[i], _ = rand.Int(rand.Reader, ORDER)
+		i = i + 1
+	}
+	c, _ := ScalarProduct(a, b)
+	commit, _ := CommitInnerProduct(zkrp.Gg, zkrp.Hh, a, b)
+	zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	proof, _ := zkip.Prove(a, b, commit, c)
+	ok1, _ := zkip.Verify(commit, c, proof)
+	ok2, _ := zkip.VerifyMultiExp(commit, c, proof)
+	if ok1 != true || ok2 != true {
+		t.Errorf("Assert failure: expected true, actual: %t, %t", ok1, ok2)
+	}
+	proof.A = Mod(Add(proof.A, new(big.Int).SetInt64(1)), ORDER)
+	ok1, _ = zkip.Verify(commit, c, proof)
+	ok2, _ = zkip.VerifyMultiExp(commit, c, proof)
+	if ok1 != false || ok2 != false {
+		t.Errorf("Assert failure: expected false, actual: %t, %t", ok1, ok2)
+	}
+	proof, _ = zkip.Prove(a, b, commit, c)
+	proof.Ls[1] = proof.Rs[1]
+	ok1, _ = zkip.Verify(commit, c, proof)
+	ok2, _ = zkip.VerifyMultiExp(commit, c, proof)
+	if ok1 != false || ok2 != false {
+		t.Errorf("Assert failure: expected false, actual: %t, %t", ok1, ok2)
+	}
+}
+
 /*
 Test the FALSE case of ZK Range Proof scheme using Bulletproofs. 
 */
@@ -267,6 +328,203 @@ func TestTrueBulletproofsZKRP(t *testing.T) {
 	}
 }
 
+/*
+Test the ZK Range Proof scheme using Bulletproofs on the edges of the interval [a,b).
+The interval is half-open: the ages from 18 to 65 are proven with [18,66), then 65
+is accepted while 66 is rejected.
+*/
+func TestIntervalBulletproofsZKRP(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 66)
+	values := []int64{17, 66, 274, 18, 65, 42}
+	expected := []bool{false, false, false, true, true, true}
+	for i := range values {
+		proof, _ := zkrp.Prove(new(big.Int).SetInt64(values[i]))
+		ok, _ := zkrp.Verify(proof)
+		if ok != expected[i] {
+			t.Errorf("Assert failure: expected %t for %d, actual: %t", expected[i], values[i], ok)
+		}
+	}
+}
+
+/*
+Test that the verifier rejects a proof when the values used to compute the 
+inner product commitment P are modified.
+*/
+func TestTamperedBulletproofsZKRP(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 66)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(42))
+	proof.P2.Mu = Mod(Add(proof.P2.Mu, new(big.Int).SetInt64(1)), ORDER)
+	ok, _ := zkrp.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+	proof, _ = zkrp.Prove(new(big.Int).SetInt64(42))
+	proof.P1.S = proof.P1.A
+	ok, _ = zkrp.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
+
+/*
+Tests if the Setup algorithm is rejecting wrong input as expected. 
+*/
+func TestBulletproofsSetupInput(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	e := zkrp.Setup(66, 18)
+	result := e == nil || e.Error() != "a must be less than b"
+	if result {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+}
+
+/*
+Test the flow where the issuer commits to the secret and hands the opening to the
+prover, and the verifier receives the commitment separately from the proof.
+*/
+func TestBulletproofsCommitment(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 200)
+	x := new(big.Int).SetInt64(42)
+	V, gamma, _ := zkrp.Commit(x)
+	proof, err := zkrp.ProveCommitment(V, x, gamma)
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	ok, _ := zkrp.VerifyCommitment(V, proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	// The commitment in the proof is not trusted by VerifyCommitment
+	W, _, _ := zkrp.Commit(x)
+	proof.V = W
+	ok, _ = zkrp.VerifyCommitment(V, proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	ok, _ = zkrp.VerifyCommitment(W, proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+	// Wrong opening
+	_, err = zkrp.ProveCommitment(V, new(big.Int).SetInt64(43), gamma)
+	if err == nil {
+		t.Errorf("Assert failure: expected error for a wrong opening")
+	}
+	// The errors of the subproofs are returned
+	broken := zkrp
+	broken.Gg = zkrp.Gg[:2]
+	if _, err = broken.ProveCommitment(V, x, gamma); err == nil {
+		t.Errorf("Assert failure: expected error for missing generators")
+	}
+	// Prove with (x, gamma) chosen by the caller
+	proof, _ = zkrp.ProveOpening(x, gamma)
+	ok, _ = zkrp.VerifyCommitment(V, proof)
+	if ok != true || !proof.V.Equals(V) {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+}
+
+/*
+Test that Prove and Verify do not modify the parameters, so that a proof verifies
+after other proofs were computed with the same parameters.
+*/
+func TestBulletproofsParamsUnchanged(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 200)
+	hh := append([]Element{}, zkrp.Zkip.Hh...)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+	zkrp.Prove(new(big.Int).SetInt64(17))
+	zkrp.Verify(proof)
+	if !equalElements(zkrp.Zkip.Hh, hh) || zkrp.CheckGenerators() != nil {
+		t.Errorf("Assert failure: parameters modified by Prove or Verify")
+	}
+	ok, _ := zkrp.Verify(proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+}
+
+/*
+Test Prove and Verify from many goroutines sharing the same parameters. It is
+meant to be run with go test -race.
+*/
+func TestConcurrentBulletproofs(t *testing.T) {
+	var (
+		zkrp bp
+		wg sync.WaitGroup
+	)
+	zkrp.Setup(18, 200)
+	values := []int64{17, 18, 40, 199, 200, 250}
+	proofs := make([]proofBP, len(values))
+	for i := range values {
+		proofs[i], _ = zkrp.Prove(new(big.Int).SetInt64(values[i]))
+	}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := range values {
+				k := (i + g) % len(values)
+				expected := values[k] >= 18 && values[k] < 200
+				proof, _ := zkrp.Prove(new(big.Int).SetInt64(values[k]))
+				ok, _ := zkrp.Verify(proof)
+				ok2, _ := zkrp.Verify(proofs[k])
+				if ok != expected || ok2 != expected {
+					t.Errorf("Assert failure for %d: expected %t, actual: %t, %t", values[k], expected, ok, ok2)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+/*
+Test the inner product argument from many goroutines sharing the same parameters.
+*/
+func TestConcurrentInnerProduct(t *testing.T) {
+	var (
+		zkip bip
+		wg sync.WaitGroup
+	)
+	gg, hh, _ := groupGenerators(SECP256K1, 8)
+	H, _, _ := groupHU(SECP256K1)
+	zkip.Setup(H, gg, hh)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			a := make([]*big.Int, 8)
+			b := make([]*big.Int, 8)
+			for i := range a {
+				a[i] = new(big.Int).SetInt64(int64(g + i))
+				b[i] = new(big.Int).SetInt64(int64(g * i))
+			}
+			// Every goroutine shares the parameters, c is passed as an argument
+			c, _ := ScalarProduct(a, b)
+			P, _ := zkip.Commit(a, b)
+			proof, _ := zkip.Prove(a, b, P, c)
+			ok, _ := zkip.Verify(P, c, proof)
+			ok2, _ := zkip.VerifyMultiExp(P, c, proof)
+			if ok != true || ok2 != true {
+				t.Errorf("Assert failure: expected true, actual: %t, %t", ok, ok2)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
 
 func BenchmarkBulletproofs(b *testing.B) {
 	var (
@@ -286,6 +544,109 @@ func BenchmarkBulletproofs(b *testing.B) {
 	}
 }
 
+func BenchmarkVectorExp(b *testing.B) {
+	for _, n := range []int{64, 256, 1024} {
+		points, scalars := randomMultiExpInput(n)
+		b.Run("Naive/" + strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				naiveMultiExp(points, scalars)
+			}
+		})
+		b.Run("Straus/" + strconv.Itoa(n), func(b *testing.B) {
+			var (
+				aff []affine
+				sc []scalar
+			)
+			aff = make([]affine, n)
+			sc = make([]scalar, n)
+			for k := range points {
+				aff[k].setP256(points[k])
+				sc[k].setBig(scalars[k])
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				straus(aff, sc)
+			}
+		})
+		b.Run("Pippenger/" + strconv.Itoa(n), func(b *testing.B) {
+			var (
+				aff []affine
+				sc []scalar
+			)
+			aff = make([]affine, n)
+			sc = make([]scalar, n)
+			for k := range points {
+				aff[k].setP256(points[k])
+				sc[k].setBig(scalars[k])
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				pippenger(aff, sc)
+			}
+		})
+		b.Run("VectorExp/" + strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				VectorExp(points, scalars)
+			}
+		})
+	}
+}
+
+func BenchmarkInnerProductProve(b *testing.B) {
+	var (
+		zkrp bp
+		zkip bip
+	)
+	zkrp.Setup(0, 4294967296)
+	a, _ := VectorCopy(new(big.Int).SetInt64(3), zkrp.N)
+	c, _ := ScalarProduct(a, a)
+	commit, _ := CommitInnerProduct(zkrp.Gg, zkrp.Hh, a, a)
+	zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		zkip.Prove(a, a, commit, c)
+	}
+}
+
+func BenchmarkVectorScalarExp(b *testing.B) {
+	points, scalars := randomMultiExpInput(64)
+	b.Run("Naive", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for k := range points {
+				new(p256).ScalarMult(points[k], scalars[0])
+			}
+		}
+	})
+	b.Run("VectorScalarExp", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			VectorScalarExp(points, scalars[0])
+		}
+	})
+}
+
+func BenchmarkInnerProductVerify(b *testing.B) {
+	var (
+		zkrp bp
+		zkip bip
+	)
+	zkrp.Setup(0, 4294967296)
+	a, _ := VectorCopy(new(big.Int).SetInt64(3), zkrp.N)
+	c, _ := ScalarProduct(a, a)
+	commit, _ := CommitInnerProduct(zkrp.Gg, zkrp.Hh, a, a)
+	zkip.Setup(zkrp.H, zkrp.Gg, zkrp.Hh)
+	proof, _ := zkip.Prove(a, a, commit, c)
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			zkip.Verify(commit, c, proof)
+		}
+	})
+	b.Run("VerifyMultiExp", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			zkip.VerifyMultiExp(commit, c, proof)
+		}
+	})
+}
+
 func BenchmarkScalarMult(b *testing.B) {
 	var (
 		a *big.Int
@@ -300,39 +661,6 @@ func BenchmarkScalarMult(b *testing.B) {
 	fmt.Println(A)
 }
 
-func TestHashBP(t *testing.T) {
-	agx, _ := new(big.Int).SetString("110720467414728166769654679803728202169916280248550137472490865118702779748947", 10)
-	agy, _ := new(big.Int).SetString("103949684536896233354287911519259186718323435572971865592336813380571928560949", 10)
-	sgx, _ := new(big.Int).SetString("78662919066140655151560869958157053125629409725243565127658074141532489435921", 10)
-	sgy, _ := new(big.Int).SetString("114946280626097680211499478702679495377587739951564115086530426937068100343655", 10)
-	pointa := &p256{X:agx, Y:agy}
-	points := &p256{X:sgx, Y:sgy}
-	result1, result2, _ := HashBP(pointa, points)
-	res1, _ := new(big.Int).SetString("103823382860325249552741530200099120077084118788867728791742258217664299339569", 10)
-	res2, _ := new(big.Int).SetString("8192372577089859289404358830067912230280991346287696886048261417244724213964", 10)
-	ok1 := (result1.Cmp(res1) != 0)
-	ok2 := (result2.Cmp(res2) != 0)
-	ok := ok1 && ok2
-	if ok {
-		t.Errorf("Assert failure: expected true, actual: %t", ok)
-	}
-}
-
-func TestHashBPGx(t *testing.T) {
-	gx, _ := new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16);
-        gy, _ := new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16);
-	point := &p256{X:gx, Y:gy}
-	result1, result2, _ := HashBP(point, point)
-	res1, _ := new(big.Int).SetString("11897424191990306464486192136408618361228444529783223689021929580052970909263", 10)
-	res2, _ := new(big.Int).SetString("22166487799255634251145870394406518059682307840904574298117500050508046799269", 10)
-	ok1 := (result1.Cmp(res1) != 0)
-	ok2 := (result2.Cmp(res2) != 0)
-	ok := ok1 && ok2
-	if ok {
-		t.Errorf("Assert failure: expected true, actual: %t", ok)
-	}
-}
-
 func TestInv(t *testing.T) {
 	y, _ := new(big.Int).SetString("103823382860325249552741530200099120077084118788867728791742258217664299339569", 10)
 	yinv := ModInverse(y, ORDER)
@@ -343,11 +671,21 @@ func TestInv(t *testing.T) {
 	}
 }
 
+/*
+Test that the parameters and the proof saved in setup.dat and proof.dat are valid.
+*/
 func TestHPrime(t *testing.T) {
 	var zkrp *bp
 	var proof *proofBP
-	zkrp, _ = LoadParamFromDisk("setup.dat")
-	proof, _ = LoadProofFromDisk("proof.dat")
+	store := NewDirStore(".")
+	zkrp, err := store.LoadParams("setup.dat")
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	proof, err = store.LoadProof("proof.dat")
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
 	ok, _ := zkrp.Verify(*proof)
 	if !ok {
 		t.Errorf("Assert failure: expected true, actual: %t", ok)
@@ -28,43 +28,54 @@ import (
 	"strconv"
 	"bytes"
 	"math/big"
-	"math"
 	"crypto/rand"
 	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
 )
 
 /*
-paramsSet contains elements generated by the verifier, which are necessary for the prover.
-This must be computed in a trusted setup.
+IssuerKey contains the key pair of the issuer, who signs the elements of the set
+or the digits in [0,u). The private key must be kept by the issuer: it is never
+included in the parameters given to the prover and the verifier, since with it
+anyone could forge signatures and prove false statements.
+*/
+type IssuerKey struct {
+	kp keypair
+	H *bn256.G2
+}
+
+/*
+paramsSet contains elements generated by the issuer, which are necessary for the prover.
 */
 type paramsSet struct {
 	signatures map[int64]*bn256.G2
 	H *bn256.G2
-	// TODO:must protect the private key
-	kp keypair
-	// u determines the amount of signatures we need in the public params. 
-	// Each signature can be compressed to just 1 field element of 256 bits.
-	// Then the parameters have minimum size equal to 256*u bits. 
-	// l determines how many pairings we need to compute, then in order to improve
-	// verifier`s performance we want to minize it.
-	// Namely, we have 2*l pairings for the prover and 3*l for the verifier.  
+	pubk *bn256.G1
 }
 
 /*
-paramsUL contains elements generated by the verifier, which are necessary for the prover.
-This must be computed in a trusted setup.
+ProverParams contains elements generated by the issuer, which are necessary for
+the prover of [0,u^l).
 */
-type paramsUL struct {
+type ProverParams struct {
 	signatures map[string]*bn256.G2
 	H *bn256.G2
-	// TODO:must protect the private key
-	kp keypair
+	pubk *bn256.G1
 	// u determines the amount of signatures we need in the public params. 
 	// Each signature can be compressed to just 1 field element of 256 bits.
 	// Then the parameters have minimum size equal to 256*u bits. 
 	// l determines how many pairings we need to compute, then in order to improve
 	// verifier`s performance we want to minize it.
-	// Namely, we have 2*l pairings for the prover and 3*l for the verifier.  
+	// The costs of each choice are predicted by EstimateUL.
+	u,l int64
+}
+
+/*
+VerifierParams contains the public elements necessary for the verifier. Set
+membership proofs only use pubk and H, in which case u and l are zero.
+*/
+type VerifierParams struct {
+	H *bn256.G2
+	pubk *bn256.G1
 	u,l int64
 }
 
@@ -91,51 +102,133 @@ type proofUL struct {
 }
 
 /*
-SetupSet generates the signature for the elements in the set.
+NewIssuerKey generates the key pair of the issuer.
 */
-func SetupSet(s []int64) (paramsSet, error) {
+func NewIssuerKey() (*IssuerKey, error) {
+	var (
+		err error
+		k IssuerKey
+	)
+	k.kp, err = keygen()
+	if err != nil {
+		return nil, err
+	}
+	//TODO: protect the 'master' key
+	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
+	k.H = new(bn256.G2).ScalarBaseMult(h)
+	return &k, nil
+}
+
+/*
+SetupSet generates the signature for the elements in the set, using the key of the issuer.
+*/
+func (k *IssuerKey) SetupSet(s []int64) (paramsSet, error) {
 	var (
 		i int
 		p paramsSet
 	)
-	p.kp, _ = keygen()
-
 	p.signatures = make(map[int64]*bn256.G2)
 	for i=0; i < len(s); i++ {
-		sig_i, _ := sign(new(big.Int).SetInt64(int64(s[i])), p.kp.privk)
+		sig_i, err := sign(new(big.Int).SetInt64(int64(s[i])), k.kp.privk)
+		if err != nil {
+			return p, err
+		}
 		p.signatures[s[i]] = sig_i 
 	}
-	//TODO: protect the 'master' key
-	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	p.H = k.H
+	p.pubk = k.kp.pubk
 	return p, nil
 }
 
 /*
-SetupUL generates the signature for the interval [0,u^l).
-The value of u should be roughly b/log(b), but we can choose smaller values in
-order to get smaller parameters, at the cost of having worse performance.
+SetupUL generates the signature for the interval [0,u^l), using the key of the issuer.
 */
-func SetupUL(u, l int64) (paramsUL, error) {
+func (k *IssuerKey) SetupUL(u, l int64) (ProverParams, error) {
 	var (
 		i int64
-		p paramsUL
+		p ProverParams
 	)
-	p.kp, _ = keygen()
-
 	p.signatures = make(map[string]*bn256.G2)
 	for i=0; i < u; i++ {
-		sig_i, _ := sign(new(big.Int).SetInt64(i), p.kp.privk)
+		sig_i, err := sign(new(big.Int).SetInt64(i), k.kp.privk)
+		if err != nil {
+			return p, err
+		}
 		p.signatures[strconv.FormatInt(i, 10)] = sig_i 
 	}
-	//TODO: protect the 'master' key
-	h := GetBigInt("18560948149108576432482904553159745978835170526553990798435819795989606410925")
-	p.H = new(bn256.G2).ScalarBaseMult(h)
+	p.H = k.H
+	p.pubk = k.kp.pubk
 	p.u = u
 	p.l = l
 	return p, nil
 }
 
+/*
+SetupSet generates the signature for the elements in the set. The key of the
+issuer is discarded, then no more signatures can be generated.
+*/
+func SetupSet(s []int64) (paramsSet, error) {
+	k, err := NewIssuerKey()
+	if err != nil {
+		return paramsSet{}, err
+	}
+	return k.SetupSet(s)
+}
+
+/*
+SetupUL generates the signature for the interval [0,u^l).
+The value of u should be roughly b/log(b), but we can choose smaller values in
+order to get smaller parameters, at the cost of having worse performance.
+The key of the issuer is discarded, then no more signatures can be generated.
+*/
+func SetupUL(u, l int64) (ProverParams, error) {
+	k, err := NewIssuerKey()
+	if err != nil {
+		return ProverParams{}, err
+	}
+	return k.SetupUL(u, l)
+}
+
+/*
+VerifierParams returns the public parameters necessary to verify set membership proofs.
+*/
+func (p *paramsSet) VerifierParams() (VerifierParams) {
+	return VerifierParams{H: p.H, pubk: p.pubk}
+}
+
+/*
+VerifierParams returns the public parameters necessary to verify proofs for [0,u^l).
+*/
+func (p *ProverParams) VerifierParams() (VerifierParams) {
+	return VerifierParams{H: p.H, pubk: p.pubk, u: p.u, l: p.l}
+}
+
+/*
+transcriptSet returns the transcript of the ZK Set Membership proof, which binds
+the public parameters and the commitment C.
+*/
+func transcriptSet(p *VerifierParams, C *bn256.G2) (*Transcript) {
+	t := NewTranscript("CCS08 set membership")
+	t.AppendG1("y", p.pubk)
+	t.AppendG2("H", p.H)
+	t.AppendG2("C", C)
+	return t
+}
+
+/*
+transcriptUL returns the transcript of the ZKRP proof, which binds the public
+parameters and the commitment C.
+*/
+func transcriptUL(p *VerifierParams, C *bn256.G2) (*Transcript) {
+	t := NewTranscript("CCS08 range proof")
+	t.AppendInt64("u", p.u)
+	t.AppendInt64("l", p.l)
+	t.AppendG1("y", p.pubk)
+	t.AppendG2("H", p.H)
+	t.AppendG2("C", C)
+	return t
+}
+
 /*
 ProveSet method is used to produce the ZK Set Membership proof.
 */
@@ -175,8 +268,12 @@ func ProveSet(x int64, r *big.Int, p paramsSet) (proofSet, error) {
 	// so that it is possible to delegate the commitment computation to an external party.
 	proof_out.C, _ = Commit(new(big.Int).SetInt64(x), r, p.H)
 	// Fiat-Shamir heuristic
-	proof_out.c, _ = HashSet(proof_out.a, proof_out.D)
-	proof_out.c = Mod(proof_out.c, bn256.Order)
+	vp := p.VerifierParams()
+	t := transcriptSet(&vp, proof_out.C)
+	t.AppendG2("V", proof_out.V)
+	t.AppendG2("D", proof_out.D)
+	t.AppendGT("a", proof_out.a)
+	proof_out.c = t.ChallengeScalar("c", bn256.Order)
 
 	proof_out.zr = Sub(proof_out.m, Multiply(r, proof_out.c))
 	proof_out.zr = Mod(proof_out.zr, bn256.Order)
@@ -190,7 +287,18 @@ func ProveSet(x int64, r *big.Int, p paramsSet) (proofSet, error) {
 /*
 ProveUL method is used to produce the ZKRP proof that secret x belongs to the interval [0,U^L].
 */
-func ProveUL(x,r *big.Int, p paramsUL) (proofUL, error) {
+func ProveUL(x,r *big.Int, p ProverParams) (proofUL, error) {
+	C, _ := Commit(x, r, p.H)
+	proof_out, err := proveUL(x, r, C, p)
+	proof_out.C = C
+	return proof_out, err
+}
+
+/*
+proveUL computes the proof of ProveUL for the commitment C = g^x.h^r, which is
+bound to the transcript but not included in the proof.
+*/
+func proveUL(x,r *big.Int, C *bn256.G2, p ProverParams) (proofUL, error) {
 	var (
 		i int64
 		v []*big.Int
@@ -235,12 +343,15 @@ func ProveUL(x,r *big.Int, p paramsUL) (proofUL, error) {
 	}	
 	proof_out.D.Add(proof_out.D, D)
 	
-	// Consider passing C as input, 
-	// so that it is possible to delegate the commitment computation to an external party.
-	proof_out.C, _ = Commit(x, r, p.H)
 	// Fiat-Shamir heuristic
-	proof_out.c, _ = Hash(proof_out.a, proof_out.D)
-	proof_out.c = Mod(proof_out.c, bn256.Order)
+	vp := p.VerifierParams()
+	t := transcriptUL(&vp, C)
+	for i = 0; i< p.l; i++ {
+		t.AppendG2("V", proof_out.V[i])
+		t.AppendGT("a", proof_out.a[i])
+	}
+	t.AppendG2("D", proof_out.D)
+	proof_out.c = t.ChallengeScalar("c", bn256.Order)
 
 	proof_out.zr = Sub(proof_out.m, Multiply(r, proof_out.c))
 	proof_out.zr = Mod(proof_out.zr, bn256.Order)
@@ -256,159 +367,187 @@ func ProveUL(x,r *big.Int, p paramsUL) (proofUL, error) {
 /*
 VerifySet is used to validate the ZK Set Membership proof. It returns true iff the proof is valid.
 */
-func VerifySet(proof_out *proofSet, p *paramsSet) (bool, error) {
-	var (
-		D *bn256.G2
-		r1, r2 bool
-		p1,p2 *bn256.GT
-	)
-	// D == C^c.h^ zr.g^zsig ?
-	D = new(bn256.G2).ScalarMult(proof_out.C, proof_out.c)
-	D.Add(D, new(bn256.G2).ScalarMult(p.H, proof_out.zr)) 	
-	aux := new(bn256.G2).ScalarBaseMult(proof_out.zsig)
-	D.Add(D, aux) 	
-
-	DBytes := D.Marshal()
-	pDBytes := proof_out.D.Marshal()
-	r1 = bytes.Equal(DBytes, pDBytes)
-
-	r2 = true
-	// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
-	p1 = bn256.Pair(p.kp.pubk, proof_out.V)
-	p1.ScalarMult(p1, proof_out.c)
-	p2 = bn256.Pair(G1, proof_out.V)
-	p2.ScalarMult(p2, proof_out.zsig)
-	p2.Invert(p2)
-	p1.Add(p1, p2)
-	p1.Add(p1, new(bn256.GT).ScalarMult(E, proof_out.zv))
-
-	pBytes := p1.Marshal()
-	aBytes := proof_out.a.Marshal()
-	r2 = r2 && bytes.Equal(pBytes, aBytes) 
-	return r1 && r2, nil
+func VerifySet(proof_out *proofSet, p *VerifierParams) (bool, error) {
+	if err := p.validateSet(); err != nil {
+		return false, err
+	}
+	return verifySetBatch([]proofSet{*proof_out}, p)
 }
 
 /*
 VerifyUL is used to validate the ZKRP proof. It returns true iff the proof is valid.
 */
-func VerifyUL(proof_out *proofUL, p *paramsUL) (bool, error) {
-	var (
-		i int64
-		D *bn256.G2
-		r1, r2 bool
-		p1,p2 *bn256.GT
-	)
-	// D == C^c.h^ zr.g^zsig ?
-	D = new(bn256.G2).ScalarMult(proof_out.C, proof_out.c)
-	D.Add(D, new(bn256.G2).ScalarMult(p.H, proof_out.zr)) 	
-	for i = 0; i< p.l; i++ {
-		ui := new(big.Int).Exp(new(big.Int).SetInt64(p.u), new(big.Int).SetInt64(i), nil)
-		muizsigi := new(big.Int).Mul(proof_out.zsig[i], ui)
-		muizsigi = Mod(muizsigi, bn256.Order)
-		aux := new(bn256.G2).ScalarBaseMult(muizsigi)
-		D.Add(D, aux) 	
+func VerifyUL(proof_out *proofUL, p *VerifierParams) (bool, error) {
+	if err := checkG2(proof_out.C, "C"); err != nil {
+		return false, err
 	}
+	return verifyUL(proof_out, proof_out.C, p)
+}
 
-	DBytes := D.Marshal()
-	pDBytes := proof_out.D.Marshal()
-	r1 = bytes.Equal(DBytes, pDBytes)
-
-	r2 = true
-	for i = 0; i < p.l; i++ {
-		// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
-		p1 = bn256.Pair(p.kp.pubk, proof_out.V[i])
-		p1.ScalarMult(p1, proof_out.c)
-		p2 = bn256.Pair(G1, proof_out.V[i])
-		p2.ScalarMult(p2, proof_out.zsig[i])
-		p2.Invert(p2)
-		p1.Add(p1, p2)
-		p1.Add(p1, new(bn256.GT).ScalarMult(E, proof_out.zv[i]))
-	
-		pBytes := p1.Marshal()
-		aBytes := proof_out.a[i].Marshal()
-		r2 = r2 && bytes.Equal(pBytes, aBytes) 
+/*
+verifyUL verifies the proof of VerifyUL against the commitment C, ignoring the
+commitment contained in the proof.
+*/
+func verifyUL(proof_out *proofUL, C *bn256.G2, p *VerifierParams) (bool, error) {
+	if err := p.Validate(); err != nil {
+		return false, err
+	}
+	batch := newBatchCCS08(p.H)
+	ok, err := batch.addUL(proof_out, C, p)
+	if !ok || err != nil {
+		return false, err
 	}
-	return r1 && r2, nil
+	return batch.check(), nil
 }
 
 /*
-proof contains the necessary elements for the ZK proof.
+proof contains the necessary elements for the ZK proof. The proofs p1 and p2 do
+not carry their commitments, which are derived from the commitment C held by the
+verifier.
 */
 type proof struct {
 	p1,p2 proofUL
 }
 
 /*
-params contains elements generated by the verifier, which are necessary for the prover.
-This must be computed in a trusted setup.
+params contains the parameters for [a,b). The prover needs p, while the verifier
+only needs the public parameters v.
 */
 type params struct {
-	p *paramsUL 
+	p *ProverParams
+	v *VerifierParams
 	a,b int64
 }
 
+/*
+ccs08 contains the parameters, the commitment C = g^x.h^r and the proof. The
+prover also holds the opening x, r of C, while the verifier only needs C.
+*/
 type ccs08 struct {
 	p *params
 	x, r *big.Int
+	C *bn256.G2
 	proof_out proof
 	pubk *bn256.G1
 }
 
 /*
-Setup receives integers a and b, and configures the parameters for the rangeproof scheme.
+Setup receives integers a and b, and configures the parameters for the rangeproof
+scheme, choosing u and l which minimize the size of the parameters plus the size
+of the proof.
 */
 func (zkrp *ccs08) Setup(a,b int64) (error) {
-	// Compute optimal values for u and l
+	_, err := zkrp.SetupOptimal(a, b, TotalSize)
+	return err
+}
+
+/*
+SetupOptimal configures the parameters for the interval [a,b), choosing u and l
+by OptimalUL for the given objective. It returns the predicted costs.
+*/
+func (zkrp *ccs08) SetupOptimal(a,b int64, objective int) (CostsUL, error) {
 	var (
-		u,l int64
-		logb float64
+		size int64
 		p *params
 	)
+	zkrp.p = nil
 	if a > b {
-		zkrp.p = nil
-		return errors.New("a must be less than or equal to b")
+		return CostsUL{}, errors.New("a must be less than or equal to b")
 	}
+	if a == b {
+		return CostsUL{}, errors.New("Interval [a,b) is empty.")
+	}
+	size = b - a
+	if size < 0 {
+		return CostsUL{}, errors.New("Interval is too large.")
+	}
+	costs, err := OptimalUL(size, objective)
+	if err != nil {
+		return costs, err
+	}
+	params_out, err := SetupUL(costs.U, costs.L)
+	if err != nil {
+		return costs, err
+	}
+	verifier_out := params_out.VerifierParams()
 	p = new(params)
-	logb = math.Log(float64(b))
-	if logb != 0 {
-		// TODO: understand how to find optimal parameters
-		//u = b / int64(logb)
-		u = 57
-		if u != 0 {
-			l = 0
-			for i:=b; i>0; i=i/u {
-				l=l+1
-			}
-			params_out, e := SetupUL(u, l)
-			p.p = &params_out
-			p.a = a
-			p.b = b
-			zkrp.p = p
-			return e
-		} else {
-			zkrp.p = nil
-			return errors.New("u is zero") 
-		}
-	} else {
-		zkrp.p = nil
-		return errors.New("log(b) is zero") 
+	p.p = &params_out
+	p.v = &verifier_out
+	p.a = a
+	p.b = b
+	zkrp.p = p
+	return costs, nil
+}
+
+/*
+Commit computes the commitment C = g^x.h^r to the secret x, using the randomness r.
+*/
+func (zkrp *ccs08) Commit() (*bn256.G2, error) {
+	if err := zkrp.Validate(); err != nil {
+		return nil, err
 	}
+	if zkrp.x == nil || zkrp.r == nil {
+		return nil, errors.New("Secret x and randomness r are missing.")
+	}
+	C, err := Commit(zkrp.x, zkrp.r, zkrp.p.v.H)
+	zkrp.C = C
+	return C, err
+}
+
+/*
+shift returns the commitments C.g^(u^l-b) and C.g^(-a), which commit to x-b+u^l
+and x-a with the same randomness as C.
+*/
+func (zkrp *ccs08) shift(C *bn256.G2) (*bn256.G2, *bn256.G2) {
+	ul := new(big.Int).Exp(new(big.Int).SetInt64(zkrp.p.v.u), new(big.Int).SetInt64(zkrp.p.v.l), nil)
+	ub := Mod(Sub(ul, new(big.Int).SetInt64(zkrp.p.b)), bn256.Order)
+	ma := Mod(new(big.Int).SetInt64(-zkrp.p.a), bn256.Order)
+	C1 := new(bn256.G2).Add(C, new(bn256.G2).ScalarBaseMult(ub))
+	C2 := new(bn256.G2).Add(C, new(bn256.G2).ScalarBaseMult(ma))
+	return C1, C2
 }
 
 /*
-Prove method is responsible for generating the zero knowledge proof.
+Prove method is responsible for generating the zero knowledge proof that the
+secret x committed in C belongs to [a,b). If C is not set, it is computed by Commit.
 */
 func (zkrp *ccs08) Prove() (error) {
+	if zkrp.C == nil {
+		if _, err := zkrp.Commit(); err != nil {
+			return err
+		}
+	} else {
+		if err := zkrp.Validate(); err != nil {
+			return err
+		}
+		if zkrp.x == nil || zkrp.r == nil {
+			return errors.New("Secret x and randomness r are missing.")
+		}
+		C, _ := Commit(zkrp.x, zkrp.r, zkrp.p.v.H)
+		if !bytes.Equal(C.Marshal(), zkrp.C.Marshal()) {
+			return errors.New("Commitment C does not open to x and r.")
+		}
+	}
+	if zkrp.p.p == nil {
+		return errors.New("Prover parameters are missing.")
+	}
 	ul := new(big.Int).Exp(new(big.Int).SetInt64(zkrp.p.p.u), new(big.Int).SetInt64(zkrp.p.p.l), nil)
-	
+	C1, C2 := zkrp.shift(zkrp.C)
+
 	// x - b + ul
 	xb := new(big.Int).Sub(zkrp.x, new(big.Int).SetInt64(zkrp.p.b))
 	xb.Add(xb, ul)
-	first, _ := ProveUL(xb, zkrp.r, *zkrp.p.p)
+	first, err := proveUL(xb, zkrp.r, C1, *zkrp.p.p)
+	if err != nil {
+		return err
+	}
 
 	// x - a
 	xa := new(big.Int).Sub(zkrp.x, new(big.Int).SetInt64(zkrp.p.a))
-	second, _ := ProveUL(xa, zkrp.r, *zkrp.p.p)
+	second, err := proveUL(xa, zkrp.r, C2, *zkrp.p.p)
+	if err != nil {
+		return err
+	}
 
 	zkrp.proof_out.p1 = first
 	zkrp.proof_out.p2 = second
@@ -416,11 +555,30 @@ func (zkrp *ccs08) Prove() (error) {
 }
 
 /*
-Verify is responsible for validating the proof.
+Verify is responsible for validating the proof. Both proofs are verified against
+the commitments derived from C, then the proof holds for the value committed in C.
+The equations of both proofs are checked as a batch, with a single pairing product.
 */
 func (zkrp *ccs08) Verify() (bool, error) {
-	first, _ := VerifyUL(&zkrp.proof_out.p1, zkrp.p.p)
-	second, _ := VerifyUL(&zkrp.proof_out.p2, zkrp.p.p)
-	return first && second, nil
+	if err := zkrp.Validate(); err != nil {
+		return false, err
+	}
+	if err := checkG2(zkrp.C, "C"); err != nil {
+		return false, err
+	}
+	if err := zkrp.proof_out.Validate(); err != nil {
+		return false, err
+	}
+	C1, C2 := zkrp.shift(zkrp.C)
+	// Both proofs are verified as a batch
+	batch := newBatchCCS08(zkrp.p.v.H)
+	first, err := batch.addUL(&zkrp.proof_out.p1, C1, zkrp.p.v)
+	if !first || err != nil {
+		return false, err
+	}
+	second, err := batch.addUL(&zkrp.proof_out.p2, C2, zkrp.p.v)
+	if !second || err != nil {
+		return false, err
+	}
+	return batch.check(), nil
 }
-
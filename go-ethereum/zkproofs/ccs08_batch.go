@@ -0,0 +1,282 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the batch verification of CCS08 proofs. Each proof must satisfy
+D == C^c.h^zr.g^zsig in G2, which is written as C^c.h^zr.g^zsig.D^-1 == 1, and
+the pairing equations of its digits in GT. The equations in G2 of all the proofs
+are combined using random weights, such that the exponents of h and g are added
+and each proof only needs C^(rho.c).D^-rho. The pairing equations are combined
+by pairingProduct, such that all the proofs share a single final exponentiation.
+The first weight is 1, which is enough to verify a single proof.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+	"crypto/rand"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+batchCCS08 accumulates the equations in G2 and the pairing equations of the proofs.
+*/
+type batchCCS08 struct {
+	pp *pairingProduct
+	D *bn256.G2
+	H *bn256.G2
+	h *big.Int
+	g *big.Int
+	first bool
+}
+
+/*
+newBatchCCS08 returns an empty batch for the parameters with generator H.
+*/
+func newBatchCCS08(H *bn256.G2) (*batchCCS08) {
+	return &batchCCS08{
+		pp: newPairingProduct(),
+		D: new(bn256.G2).ScalarBaseMult(big.NewInt(0)),
+		H: H,
+		h: new(big.Int),
+		g: new(big.Int),
+		first: true,
+	}
+}
+
+/*
+weight returns 1 for the first equation and a random weight of 128 bits afterwards.
+*/
+func (batch *batchCCS08) weight() (*big.Int, error) {
+	if batch.first {
+		batch.first = false
+		return big.NewInt(1), nil
+	}
+	return rand.Int(rand.Reader, WEIGHT)
+}
+
+/*
+addD adds the equation D == C^c.h^zr.g^zsig with a random weight.
+*/
+func (batch *batchCCS08) addD(C, D *bn256.G2, c, zr, zsig *big.Int) (error) {
+	rho, err := batch.weight()
+	if err != nil {
+		return err
+	}
+	batch.D.Add(batch.D, new(bn256.G2).ScalarMult(C, Mod(Multiply(rho, c), bn256.Order)))
+	batch.D.Add(batch.D, new(bn256.G2).Neg(new(bn256.G2).ScalarMult(D, rho)))
+	batch.h = Mod(Add(batch.h, Multiply(rho, zr)), bn256.Order)
+	batch.g = Mod(Add(batch.g, Multiply(rho, zsig)), bn256.Order)
+	return nil
+}
+
+/*
+addPairing adds the equation a == e(y^c.g^-zsig, V).e(g^zv, g) with a random weight.
+*/
+func (batch *batchCCS08) addPairing(pubk *bn256.G1, V *bn256.G2, a *bn256.GT, c, zsig, zv *big.Int) (error) {
+	rho, err := batch.weight()
+	if err != nil {
+		return err
+	}
+	batch.pp.add(rho, pubk, V, a, c, zsig, zv)
+	return nil
+}
+
+/*
+addSet checks the challenge of the set membership proof and adds its equations
+to the batch. It returns false if the challenge is wrong.
+*/
+func (batch *batchCCS08) addSet(proof_out *proofSet, p *VerifierParams) (bool, error) {
+	if err := p.validateSet(); err != nil {
+		return false, err
+	}
+	if err := proof_out.Validate(); err != nil {
+		return false, err
+	}
+	// The challenge must be derived from the transcript
+	t := transcriptSet(p, proof_out.C)
+	t.AppendG2("V", proof_out.V)
+	t.AppendG2("D", proof_out.D)
+	t.AppendGT("a", proof_out.a)
+	if t.ChallengeScalar("c", bn256.Order).Cmp(proof_out.c) != 0 {
+		return false, nil
+	}
+	// D == C^c.h^ zr.g^zsig ?
+	if err := batch.addD(proof_out.C, proof_out.D, proof_out.c, proof_out.zr, proof_out.zsig); err != nil {
+		return false, err
+	}
+	// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
+	return true, batch.addPairing(p.pubk, proof_out.V, proof_out.a, proof_out.c, proof_out.zsig, proof_out.zv)
+}
+
+/*
+addUL checks the challenge of the proof for [0,u^l) against the commitment C and
+adds its equations to the batch. It returns false if the challenge is wrong.
+*/
+func (batch *batchCCS08) addUL(proof_out *proofUL, C *bn256.G2, p *VerifierParams) (bool, error) {
+	var (
+		i int64
+	)
+	if err := p.Validate(); err != nil {
+		return false, err
+	}
+	if err := proof_out.Validate(); err != nil {
+		return false, err
+	}
+	if int64(len(proof_out.V)) != p.l {
+		return false, errors.New("Proof does not match the parameters.")
+	}
+	// The challenge must be derived from the transcript
+	t := transcriptUL(p, C)
+	for i = 0; i< p.l; i++ {
+		t.AppendG2("V", proof_out.V[i])
+		t.AppendGT("a", proof_out.a[i])
+	}
+	t.AppendG2("D", proof_out.D)
+	if t.ChallengeScalar("c", bn256.Order).Cmp(proof_out.c) != 0 {
+		return false, nil
+	}
+	// D == C^c.h^ zr.g^(sum u^i.zsig[i]) ?
+	zsig := new(big.Int)
+	ui := big.NewInt(1)
+	for i = 0; i< p.l; i++ {
+		zsig = Mod(Add(zsig, Multiply(proof_out.zsig[i], ui)), bn256.Order)
+		ui = Multiply(ui, new(big.Int).SetInt64(p.u))
+	}
+	if err := batch.addD(C, proof_out.D, proof_out.c, proof_out.zr, zsig); err != nil {
+		return false, err
+	}
+	for i = 0; i < p.l; i++ {
+		// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
+		err := batch.addPairing(p.pubk, proof_out.V[i], proof_out.a[i], proof_out.c, proof_out.zsig[i], proof_out.zv[i])
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+/*
+check returns true if and only if the combinations of the equations in G2 and of
+the pairing equations hold.
+*/
+func (batch *batchCCS08) check() (bool) {
+	D := new(bn256.G2).Add(batch.D, new(bn256.G2).ScalarMult(batch.H, batch.h))
+	D.Add(D, new(bn256.G2).ScalarBaseMult(batch.g))
+	return D.IsZero() && batch.pp.check()
+}
+
+/*
+verifyULBatch returns true if and only if the combination of the equations of all
+the proofs holds.
+*/
+func verifyULBatch(proofs []proofUL, p *VerifierParams) (bool, error) {
+	batch := newBatchCCS08(p.H)
+	for k := range proofs {
+		if err := checkG2(proofs[k].C, "C"); err != nil {
+			return false, err
+		}
+		ok, err := batch.addUL(&proofs[k], proofs[k].C, p)
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+	return batch.check(), nil
+}
+
+/*
+verifySetBatch returns true if and only if the combination of the equations of all
+the proofs holds.
+*/
+func verifySetBatch(proofs []proofSet, p *VerifierParams) (bool, error) {
+	batch := newBatchCCS08(p.H)
+	for k := range proofs {
+		ok, err := batch.addSet(&proofs[k], p)
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+	return batch.check(), nil
+}
+
+/*
+VerifyULBatch verifies several proofs for [0,u^l) with the same parameters, using
+a single pairing product. It returns true if and only if every proof is valid.
+Otherwise, it also returns the indexes of the invalid proofs, which are found by
+recursively splitting the batch. The malformed proofs, which are rejected by
+Validate, are reported as invalid.
+*/
+func VerifyULBatch(proofs []proofUL, p *VerifierParams) (bool, []int, error) {
+	var (
+		invalid []int
+	)
+	if len(proofs) == 0 {
+		return true, nil, nil
+	}
+	if err := p.Validate(); err != nil {
+		return false, nil, err
+	}
+	ok, _ := verifyULBatch(proofs, p)
+	if ok {
+		return true, nil, nil
+	}
+	if len(proofs) == 1 {
+		return false, []int{0}, nil
+	}
+	half := len(proofs) / 2
+	_, left, _ := VerifyULBatch(proofs[:half], p)
+	_, right, _ := VerifyULBatch(proofs[half:], p)
+	invalid = append(invalid, left...)
+	for _, k := range right {
+		invalid = append(invalid, k + half)
+	}
+	return false, invalid, nil
+}
+
+/*
+VerifySetBatch verifies several set membership proofs with the same parameters,
+using a single pairing product. It returns true if and only if every proof is
+valid. Otherwise, it also returns the indexes of the invalid proofs, which are
+found by recursively splitting the batch.
+*/
+func VerifySetBatch(proofs []proofSet, p *VerifierParams) (bool, []int, error) {
+	var (
+		invalid []int
+	)
+	if len(proofs) == 0 {
+		return true, nil, nil
+	}
+	if err := p.validateSet(); err != nil {
+		return false, nil, err
+	}
+	ok, _ := verifySetBatch(proofs, p)
+	if ok {
+		return true, nil, nil
+	}
+	if len(proofs) == 1 {
+		return false, []int{0}, nil
+	}
+	half := len(proofs) / 2
+	_, left, _ := VerifySetBatch(proofs[:half], p)
+	_, right, _ := VerifySetBatch(proofs[half:], p)
+	invalid = append(invalid, left...)
+	for _, k := range right {
+		invalid = append(invalid, k + half)
+	}
+	return false, invalid, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+	"crypto/rand"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+proveULBatch returns n valid proofs for [0,u^l) with the parameters p.
+*/
+func proveULBatch(n int, p ProverParams) ([]proofUL) {
+	var (
+		i int
+	)
+	proofs := make([]proofUL, n)
+	for i=0; i<n; i++ {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		proofs[i], _ = ProveUL(new(big.Int).SetInt64(int64(1000 + i)), r, p)
+	}
+	return proofs
+}
+
+func equalIndexes(a, b []int) (bool) {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Test the batch verification of valid proofs for [0,u^l).
+*/
+func TestVerifyULBatch(t *testing.T) {
+	p, _ := SetupUL(10, 4)
+	vp := p.VerifierParams()
+	proofs := proveULBatch(5, p)
+	ok, invalid, err := VerifyULBatch(proofs, &vp)
+	if ok != true || invalid != nil || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v, %v", ok, invalid, err)
+	}
+	ok, invalid, err = VerifyULBatch(nil, &vp)
+	if ok != true || invalid != nil || err != nil {
+		t.Errorf("Assert failure: expected true for an empty batch, actual: %t, %v, %v", ok, invalid, err)
+	}
+	if _, _, err := VerifyULBatch(proofs, &VerifierParams{}); err == nil {
+		t.Errorf("Assert failure: expected error for invalid parameters")
+	}
+}
+
+/*
+Test that the invalid proofs of a batch are found, whichever equation fails.
+*/
+func TestVerifyULBatchInvalid(t *testing.T) {
+	p, _ := SetupUL(10, 4)
+	vp := p.VerifierParams()
+	proofs := proveULBatch(7, p)
+	// Pairing equation
+	proofs[1].zv = append([]*big.Int{}, proofs[1].zv...)
+	proofs[1].zv[2] = Mod(Add(proofs[1].zv[2], big.NewInt(1)), bn256.Order)
+	// Equation in G2
+	proofs[4].zr = Mod(Add(proofs[4].zr, big.NewInt(1)), bn256.Order)
+	// Challenge
+	proofs[5].c = Mod(Add(proofs[5].c, big.NewInt(1)), bn256.Order)
+	// Malformed proof
+	proofs[6].D = nil
+	ok, invalid, err := VerifyULBatch(proofs, &vp)
+	if ok != false || err != nil || !equalIndexes(invalid, []int{1, 4, 5, 6}) {
+		t.Errorf("Assert failure: expected false and [1 4 5 6], actual: %t, %v, %v", ok, invalid, err)
+	}
+	// Two wrong equations in G2 which compensate each other without weights
+	proofs = proveULBatch(2, p)
+	proofs[0].zr = Mod(Add(proofs[0].zr, big.NewInt(1)), bn256.Order)
+	proofs[1].zr = Mod(Sub(proofs[1].zr, big.NewInt(1)), bn256.Order)
+	ok, invalid, _ = VerifyULBatch(proofs, &vp)
+	if ok != false || !equalIndexes(invalid, []int{0, 1}) {
+		t.Errorf("Assert failure: expected false and [0 1], actual: %t, %v", ok, invalid)
+	}
+}
+
+/*
+Test the batch verification of set membership proofs.
+*/
+func TestVerifySetBatch(t *testing.T) {
+	var (
+		i int
+	)
+	s, _ := SetupSet([]int64{12, 42, 61, 71})
+	vp := s.VerifierParams()
+	proofs := make([]proofSet, 4)
+	for i=0; i<4; i++ {
+		r, _ := rand.Int(rand.Reader, bn256.Order)
+		proofs[i], _ = ProveSet([]int64{12, 42, 61, 71}[i], r, s)
+	}
+	ok, invalid, err := VerifySetBatch(proofs, &vp)
+	if ok != true || invalid != nil || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v, %v", ok, invalid, err)
+	}
+	proofs[2].zsig = Mod(Add(proofs[2].zsig, big.NewInt(1)), bn256.Order)
+	ok, invalid, err = VerifySetBatch(proofs, &vp)
+	if ok != false || err != nil || !equalIndexes(invalid, []int{2}) {
+		t.Errorf("Assert failure: expected false and [2], actual: %t, %v, %v", ok, invalid, err)
+	}
+}
+
+func BenchmarkVerifyULBatch(b *testing.B) {
+	costs, _ := OptimalUL(4294967296, TotalSize)
+	p, _ := SetupUL(costs.U, costs.L)
+	vp := p.VerifierParams()
+	proofs := proveULBatch(16, p)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ok, _, _ := VerifyULBatch(proofs, &vp)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
+
+/*
+BenchmarkVerifyULEach verifies the proofs of BenchmarkVerifyULBatch one by one.
+*/
+func BenchmarkVerifyULEach(b *testing.B) {
+	costs, _ := OptimalUL(4294967296, TotalSize)
+	p, _ := SetupUL(costs.U, costs.L)
+	vp := p.VerifierParams()
+	proofs := proveULBatch(16, p)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k := range proofs {
+			ok, _ := VerifyUL(&proofs[k], &vp)
+			if ok != true {
+				b.Errorf("Assert failure: expected true, actual: %t", ok)
+			}
+		}
+	}
+}
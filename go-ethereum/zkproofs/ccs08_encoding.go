@@ -0,0 +1,1017 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the binary and JSON encodings of the CCS08 parameters and
+proofs. Elements of G1, G2 and GT are encoded with bn256 Marshal in 64, 128 and
+384 bytes, and decoded with bn256 Unmarshal. Decoding is strict: the encoding must
+be the one returned by Marshal and the element must belong to the group of order
+bn256.Order. Scalars are encoded in 32 bytes in big-endian order and must be
+smaller than bn256.Order. Integers are encoded in 8 bytes in big-endian order.
+
+The random values s, t and m of the prover are never encoded, since they reveal
+the secret, and neither is the private key of the issuer.
+
+The proof for [0,u^l) is encoded as
+C || l || V[0] || a[0] || ... || V[l-1] || a[l-1] || D || c || zr || zsig[0] || zv[0] || ... || zsig[l-1] || zv[l-1]
+where l is encoded in one byte. The set membership proof is encoded as
+C || V || D || a || c || zr || zsig || zv.
+The verifier parameters are encoded as u || l || pubk || H, and the prover
+parameters as u || l || pubk || H || sig[0] || ... || sig[u-1]. The parameters of
+the set membership proof are encoded as
+pubk || H || n || s[0] || sig[0] || ... || s[n-1] || sig[n-1]
+where the n elements of the set are in increasing order. Finally, the proof for
+[a,b) is encoded as a || b || u || l || pubk || H || C || P1 || P2, where the
+proofs P1 and P2 are encoded without C, since their commitments are derived from C.
+
+The JSON encoding contains the same values, where the elements are encoded in
+hexadecimal and the scalars in base 10.
+*/
+
+package zkproofs
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strconv"
+	"math/big"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/binary"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+var (
+	G1SIZE = 64
+	G2SIZE = 128
+	GTSIZE = 384
+	// Maximum number of elements in the set
+	MAXSET = 1 << 20
+)
+
+/*
+appendInt64 appends the encoding of n in 8 bytes to buf.
+*/
+func appendInt64(buf []byte, n int64) ([]byte) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(n))
+	return append(buf, b...)
+}
+
+/*
+appendBN appends the encoding of every bn256 element, which may belong to G1, G2 or GT.
+*/
+func appendBN(buf []byte, elements ...interface{ Marshal() []byte }) ([]byte) {
+	for _, e := range elements {
+		buf = append(buf, e.Marshal()...)
+	}
+	return buf
+}
+
+/*
+appendBNScalars appends the encoding of every scalar modulo bn256.Order.
+*/
+func appendBNScalars(buf []byte, scalars ...*big.Int) ([]byte) {
+	for _, s := range scalars {
+		b := Mod(s, bn256.Order).Bytes()
+		buf = append(buf, make([]byte, SCALARSIZE-len(b))...)
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+/*
+integer reads an integer in 8 bytes.
+*/
+func (d *decoder) integer() (int64) {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+/*
+g1 reads an element of G1, which must not be the point at infinity.
+*/
+func (d *decoder) g1() (*bn256.G1) {
+	b := d.next(G1SIZE)
+	if b == nil {
+		return nil
+	}
+	p, ok := new(bn256.G1).Unmarshal(b)
+	if !ok || !bytes.Equal(p.Marshal(), b) {
+		d.err = errors.New("Invalid encoding of an element of G1.")
+		return nil
+	}
+	return p
+}
+
+/*
+g2 reads an element of G2. Unmarshal only checks the curve equation, then the
+order of the element is checked as well.
+*/
+func (d *decoder) g2() (*bn256.G2) {
+	b := d.next(G2SIZE)
+	if b == nil {
+		return nil
+	}
+	p, ok := new(bn256.G2).Unmarshal(b)
+	if !ok || !bytes.Equal(p.Marshal(), b) {
+		d.err = errors.New("Invalid encoding of an element of G2.")
+		return nil
+	}
+	if !new(bn256.G2).ScalarMult(p, bn256.Order).IsZero() {
+		d.err = errors.New("Element does not belong to G2.")
+		return nil
+	}
+	return p
+}
+
+/*
+gt reads an element of GT. Unmarshal does not check anything, then the order of
+the element is checked.
+*/
+func (d *decoder) gt() (*bn256.GT) {
+	b := d.next(GTSIZE)
+	if b == nil {
+		return nil
+	}
+	a, ok := new(bn256.GT).Unmarshal(b)
+	if !ok || !bytes.Equal(a.Marshal(), b) {
+		d.err = errors.New("Invalid encoding of an element of GT.")
+		return nil
+	}
+	if !inGT(a) {
+		d.err = errors.New("Element does not belong to GT.")
+		return nil
+	}
+	return a
+}
+
+/*
+newBNDecoder returns a decoder for the CCS08 encodings, whose scalars must be
+smaller than bn256.Order.
+*/
+func newBNDecoder(data []byte) (*decoder) {
+	return &decoder{data: data, grp: BN256G1}
+}
+
+/*
+encode appends the binary encoding of the proof to buf, without the commitment C.
+*/
+func (p *proofUL) encode(buf []byte) ([]byte, error) {
+	var (
+		i int
+	)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	l := len(p.V)
+	if l > 255 {
+		return nil, errors.New("Proof must have at most 255 digits.")
+	}
+	buf = append(buf, byte(l))
+	for i=0; i<l; i++ {
+		buf = appendBN(buf, p.V[i], p.a[i])
+	}
+	buf = appendBN(buf, p.D)
+	buf = appendBNScalars(buf, p.c, p.zr)
+	for i=0; i<l; i++ {
+		buf = appendBNScalars(buf, p.zsig[i], p.zv[i])
+	}
+	return buf, nil
+}
+
+/*
+decode reads the proof, without the commitment C.
+*/
+func (p *proofUL) decode(d *decoder) {
+	var (
+		i int
+	)
+	b := d.next(1)
+	if b == nil {
+		return
+	}
+	l := int(b[0])
+	if l == 0 {
+		d.err = errors.New("Proof must have at least one digit.")
+		return
+	}
+	p.V = make([]*bn256.G2, l)
+	p.a = make([]*bn256.GT, l)
+	p.zsig = make([]*big.Int, l)
+	p.zv = make([]*big.Int, l)
+	for i=0; i<l; i++ {
+		p.V[i] = d.g2()
+		p.a[i] = d.gt()
+	}
+	p.D = d.g2()
+	p.c = d.scalar()
+	p.zr = d.scalar()
+	for i=0; i<l; i++ {
+		p.zsig[i] = d.scalar()
+		p.zv[i] = d.scalar()
+	}
+}
+
+/*
+MarshalBinary returns the binary encoding of the proof for [0,u^l).
+*/
+func (p *proofUL) MarshalBinary() ([]byte, error) {
+	if err := checkG2(p.C, "C"); err != nil {
+		return nil, err
+	}
+	return p.encode(appendBN(nil, p.C))
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the proof for [0,u^l).
+*/
+func (p *proofUL) UnmarshalBinary(data []byte) error {
+	var (
+		proof proofUL
+	)
+	d := newBNDecoder(data)
+	proof.C = d.g2()
+	proof.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	*p = proof
+	return nil
+}
+
+/*
+MarshalBinary returns the binary encoding of the set membership proof.
+*/
+func (p *proofSet) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	buf := appendBN(nil, p.C, p.V, p.D, p.a)
+	return appendBNScalars(buf, p.c, p.zr, p.zsig, p.zv), nil
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the set membership proof.
+*/
+func (p *proofSet) UnmarshalBinary(data []byte) error {
+	var (
+		proof proofSet
+	)
+	d := newBNDecoder(data)
+	proof.C = d.g2()
+	proof.V = d.g2()
+	proof.D = d.g2()
+	proof.a = d.gt()
+	proof.c = d.scalar()
+	proof.zr = d.scalar()
+	proof.zsig = d.scalar()
+	proof.zv = d.scalar()
+	if err := d.finish(); err != nil {
+		return err
+	}
+	*p = proof
+	return nil
+}
+
+/*
+encode appends the binary encoding of the verifier parameters to buf.
+*/
+func (p *VerifierParams) encode(buf []byte) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	buf = appendInt64(buf, p.u)
+	buf = appendInt64(buf, p.l)
+	return appendBN(buf, p.pubk, p.H), nil
+}
+
+/*
+decode reads the verifier parameters.
+*/
+func (p *VerifierParams) decode(d *decoder) {
+	p.u = d.integer()
+	p.l = d.integer()
+	p.pubk = d.g1()
+	p.H = d.g2()
+}
+
+/*
+MarshalBinary returns the binary encoding of the verifier parameters.
+*/
+func (p *VerifierParams) MarshalBinary() ([]byte, error) {
+	return p.encode(nil)
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the verifier parameters.
+*/
+func (p *VerifierParams) UnmarshalBinary(data []byte) error {
+	var (
+		params VerifierParams
+	)
+	d := newBNDecoder(data)
+	params.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+/*
+MarshalBinary returns the binary encoding of the prover parameters, which include
+the signatures of the digits in [0,u).
+*/
+func (p *ProverParams) MarshalBinary() ([]byte, error) {
+	var (
+		i int64
+	)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	vp := p.VerifierParams()
+	buf, _ := vp.encode(nil)
+	for i=0; i<p.u; i++ {
+		buf = appendBN(buf, p.signatures[strconv.FormatInt(i, 10)])
+	}
+	return buf, nil
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the prover parameters.
+*/
+func (p *ProverParams) UnmarshalBinary(data []byte) error {
+	var (
+		i int64
+		vp VerifierParams
+	)
+	d := newBNDecoder(data)
+	vp.decode(d)
+	if d.err == nil && (vp.u < 2 || len(d.data) % G2SIZE != 0 || int64(len(d.data) / G2SIZE) != vp.u) {
+		return errors.New("Parameters must have u signatures.")
+	}
+	signatures := make(map[string]*bn256.G2)
+	for i=0; i<vp.u && d.err == nil; i++ {
+		signatures[strconv.FormatInt(i, 10)] = d.g2()
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	params := ProverParams{signatures: signatures, H: vp.H, pubk: vp.pubk, u: vp.u, l: vp.l}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+/*
+elements returns the elements of the set in increasing order.
+*/
+func (p *paramsSet) elements() ([]int64) {
+	s := make([]int64, 0, len(p.signatures))
+	for k := range p.signatures {
+		s = append(s, k)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+/*
+MarshalBinary returns the binary encoding of the parameters of the set membership
+proof, which include the signatures of the elements of the set.
+*/
+func (p *paramsSet) MarshalBinary() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if len(p.signatures) > MAXSET {
+		return nil, errors.New("Set has too many elements.")
+	}
+	buf := appendBN(nil, p.pubk, p.H)
+	buf = appendInt64(buf, int64(len(p.signatures)))
+	for _, k := range p.elements() {
+		buf = appendInt64(buf, k)
+		buf = appendBN(buf, p.signatures[k])
+	}
+	return buf, nil
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the parameters of the set membership
+proof. The elements must be in increasing order.
+*/
+func (p *paramsSet) UnmarshalBinary(data []byte) error {
+	var (
+		i, n int64
+		params paramsSet
+	)
+	d := newBNDecoder(data)
+	params.pubk = d.g1()
+	params.H = d.g2()
+	n = d.integer()
+	if d.err == nil && (n < 1 || n > int64(MAXSET) || int64(len(d.data)) != n * int64(8 + G2SIZE)) {
+		return errors.New("Invalid number of elements in the set.")
+	}
+	params.signatures = make(map[int64]*bn256.G2)
+	prev := int64(0)
+	for i=0; i<n && d.err == nil; i++ {
+		k := d.integer()
+		if i > 0 && k <= prev {
+			return errors.New("Elements of the set must be in increasing order.")
+		}
+		params.signatures[k] = d.g2()
+		prev = k
+	}
+	if err := d.finish(); err != nil {
+		return err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+/*
+MarshalBinary returns the binary encoding of the proof for [a,b), together with
+the public parameters and the commitment C, which is all that the verifier needs.
+The secret x, the randomness r and the prover parameters are not encoded.
+*/
+func (zkrp *ccs08) MarshalBinary() ([]byte, error) {
+	if err := zkrp.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkG2(zkrp.C, "C"); err != nil {
+		return nil, err
+	}
+	buf := appendInt64(nil, zkrp.p.a)
+	buf = appendInt64(buf, zkrp.p.b)
+	buf, err := zkrp.p.v.encode(buf)
+	if err != nil {
+		return nil, err
+	}
+	buf = appendBN(buf, zkrp.C)
+	buf, err = zkrp.proof_out.p1.encode(buf)
+	if err != nil {
+		return nil, err
+	}
+	return zkrp.proof_out.p2.encode(buf)
+}
+
+/*
+UnmarshalBinary decodes the binary encoding of the proof for [a,b), after which
+Verify can be called. Both proofs must have l digits.
+*/
+func (zkrp *ccs08) UnmarshalBinary(data []byte) error {
+	var (
+		p params
+		vp VerifierParams
+		out ccs08
+	)
+	d := newBNDecoder(data)
+	p.a = d.integer()
+	p.b = d.integer()
+	vp.decode(d)
+	p.v = &vp
+	out.p = &p
+	out.C = d.g2()
+	out.proof_out.p1.decode(d)
+	out.proof_out.p2.decode(d)
+	if err := d.finish(); err != nil {
+		return err
+	}
+	if err := out.validate(); err != nil {
+		return err
+	}
+	*zkrp = out
+	return nil
+}
+
+/*
+validate checks the decoded proof for [a,b) and its parameters.
+*/
+func (zkrp *ccs08) validate() (error) {
+	if err := zkrp.Validate(); err != nil {
+		return err
+	}
+	if err := checkG2(zkrp.C, "C"); err != nil {
+		return err
+	}
+	if err := zkrp.proof_out.Validate(); err != nil {
+		return err
+	}
+	if int64(len(zkrp.proof_out.p1.V)) != zkrp.p.v.l || int64(len(zkrp.proof_out.p2.V)) != zkrp.p.v.l {
+		return errors.New("Proof does not match the parameters.")
+	}
+	return nil
+}
+
+/*
+bnHex returns the hexadecimal encoding of a bn256 element.
+*/
+func bnHex(e interface{ Marshal() []byte }) (string) {
+	return hex.EncodeToString(e.Marshal())
+}
+
+/*
+bnHexes returns the hexadecimal encoding of the bn256 elements.
+*/
+func bnHexes(es []*bn256.G2) ([]string) {
+	result := make([]string, len(es))
+	for i, e := range es {
+		result[i] = bnHex(e)
+	}
+	return result
+}
+
+/*
+bnStrings returns the scalars in base 10.
+*/
+func bnStrings(s []*big.Int) ([]string) {
+	result := make([]string, len(s))
+	for i, e := range s {
+		result[i] = e.String()
+	}
+	return result
+}
+
+/*
+jsonDecoder decodes the values of the JSON encodings, keeping the first error.
+*/
+type jsonDecoder struct {
+	err error
+}
+
+/*
+decoder returns a decoder for the hexadecimal encoding s.
+*/
+func (j *jsonDecoder) decoder(s string) (*decoder) {
+	b, err := hex.DecodeString(s)
+	if err != nil && j.err == nil {
+		j.err = errors.New("Invalid hexadecimal encoding.")
+	}
+	return newBNDecoder(b)
+}
+
+/*
+finish keeps the error of the decoder d.
+*/
+func (j *jsonDecoder) finish(d *decoder) {
+	if err := d.finish(); err != nil && j.err == nil {
+		j.err = err
+	}
+}
+
+/*
+g1 decodes an element of G1 in hexadecimal.
+*/
+func (j *jsonDecoder) g1(s string) (*bn256.G1) {
+	d := j.decoder(s)
+	p := d.g1()
+	j.finish(d)
+	return p
+}
+
+/*
+g2 decodes an element of G2 in hexadecimal.
+*/
+func (j *jsonDecoder) g2(s string) (*bn256.G2) {
+	d := j.decoder(s)
+	p := d.g2()
+	j.finish(d)
+	return p
+}
+
+/*
+g2s decodes elements of G2 in hexadecimal.
+*/
+func (j *jsonDecoder) g2s(s []string) ([]*bn256.G2) {
+	result := make([]*bn256.G2, len(s))
+	for i, e := range s {
+		result[i] = j.g2(e)
+	}
+	return result
+}
+
+/*
+gt decodes an element of GT in hexadecimal.
+*/
+func (j *jsonDecoder) gt(s string) (*bn256.GT) {
+	d := j.decoder(s)
+	a := d.gt()
+	j.finish(d)
+	return a
+}
+
+/*
+scalar decodes a scalar in base 10, which must be smaller than bn256.Order.
+*/
+func (j *jsonDecoder) scalar(s string) (*big.Int) {
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 || v.Cmp(bn256.Order) >= 0 {
+		if j.err == nil {
+			j.err = errors.New("Invalid scalar.")
+		}
+		return nil
+	}
+	return v
+}
+
+/*
+scalars decodes scalars in base 10.
+*/
+func (j *jsonDecoder) scalars(s []string) ([]*big.Int) {
+	result := make([]*big.Int, len(s))
+	for i, e := range s {
+		result[i] = j.scalar(e)
+	}
+	return result
+}
+
+type (
+	proofULJSON struct {
+		C string `json:"C,omitempty"`
+		V []string `json:"V"`
+		D string `json:"D"`
+		A []string `json:"a"`
+		Challenge string `json:"c"`
+		Zr string `json:"zr"`
+		Zsig []string `json:"zsig"`
+		Zv []string `json:"zv"`
+	}
+	proofSetJSON struct {
+		C string `json:"C"`
+		V string `json:"V"`
+		D string `json:"D"`
+		A string `json:"a"`
+		Challenge string `json:"c"`
+		Zr string `json:"zr"`
+		Zsig string `json:"zsig"`
+		Zv string `json:"zv"`
+	}
+	verifierParamsJSON struct {
+		U int64 `json:"u"`
+		L int64 `json:"l"`
+		Pubk string `json:"pubk"`
+		H string `json:"H"`
+	}
+	proverParamsJSON struct {
+		verifierParamsJSON
+		Signatures []string `json:"signatures"`
+	}
+	paramsSetJSON struct {
+		Pubk string `json:"pubk"`
+		H string `json:"H"`
+		Signatures map[int64]string `json:"signatures"`
+	}
+	ccs08JSON struct {
+		A int64 `json:"a"`
+		B int64 `json:"b"`
+		Params verifierParamsJSON `json:"params"`
+		C string `json:"C"`
+		P1 proofULJSON `json:"P1"`
+		P2 proofULJSON `json:"P2"`
+	}
+)
+
+/*
+toJSON returns the JSON values of the proof. The commitment C is omitted if it is missing.
+*/
+func (p *proofUL) toJSON() (proofULJSON, error) {
+	var (
+		i int
+		C string
+	)
+	if err := p.Validate(); err != nil {
+		return proofULJSON{}, err
+	}
+	if p.C != nil {
+		C = bnHex(p.C)
+	}
+	a := make([]string, len(p.a))
+	for i=0; i<len(p.a); i++ {
+		a[i] = bnHex(p.a[i])
+	}
+	return proofULJSON{
+		C: C,
+		V: bnHexes(p.V),
+		D: bnHex(p.D),
+		A: a,
+		Challenge: p.c.String(),
+		Zr: p.zr.String(),
+		Zsig: bnStrings(p.zsig),
+		Zv: bnStrings(p.zv),
+	}, nil
+}
+
+/*
+fromJSON decodes the JSON values of the proof.
+*/
+func (p *proofUL) fromJSON(aux *proofULJSON, j *jsonDecoder) {
+	var (
+		i int
+	)
+	if aux.C != "" {
+		p.C = j.g2(aux.C)
+	}
+	p.V = j.g2s(aux.V)
+	p.D = j.g2(aux.D)
+	p.a = make([]*bn256.GT, len(aux.A))
+	for i=0; i<len(aux.A); i++ {
+		p.a[i] = j.gt(aux.A[i])
+	}
+	p.c = j.scalar(aux.Challenge)
+	p.zr = j.scalar(aux.Zr)
+	p.zsig = j.scalars(aux.Zsig)
+	p.zv = j.scalars(aux.Zv)
+}
+
+func (p *proofUL) MarshalJSON() ([]byte, error) {
+	if err := checkG2(p.C, "C"); err != nil {
+		return nil, err
+	}
+	aux, err := p.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&aux)
+}
+
+func (p *proofUL) UnmarshalJSON(data []byte) error {
+	var (
+		aux proofULJSON
+		proof proofUL
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	proof.fromJSON(&aux, &j)
+	if j.err != nil {
+		return j.err
+	}
+	if err := checkG2(proof.C, "C"); err != nil {
+		return err
+	}
+	if err := proof.Validate(); err != nil {
+		return err
+	}
+	*p = proof
+	return nil
+}
+
+func (p *proofSet) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(&proofSetJSON{
+		C: bnHex(p.C),
+		V: bnHex(p.V),
+		D: bnHex(p.D),
+		A: bnHex(p.a),
+		Challenge: p.c.String(),
+		Zr: p.zr.String(),
+		Zsig: p.zsig.String(),
+		Zv: p.zv.String(),
+	})
+}
+
+func (p *proofSet) UnmarshalJSON(data []byte) error {
+	var (
+		aux proofSetJSON
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	proof := proofSet{
+		C: j.g2(aux.C),
+		V: j.g2(aux.V),
+		D: j.g2(aux.D),
+		a: j.gt(aux.A),
+		c: j.scalar(aux.Challenge),
+		zr: j.scalar(aux.Zr),
+		zsig: j.scalar(aux.Zsig),
+		zv: j.scalar(aux.Zv),
+	}
+	if j.err != nil {
+		return j.err
+	}
+	*p = proof
+	return nil
+}
+
+/*
+toJSON returns the JSON values of the verifier parameters.
+*/
+func (p *VerifierParams) toJSON() (verifierParamsJSON, error) {
+	if err := p.Validate(); err != nil {
+		return verifierParamsJSON{}, err
+	}
+	return verifierParamsJSON{U: p.u, L: p.l, Pubk: bnHex(p.pubk), H: bnHex(p.H)}, nil
+}
+
+/*
+fromJSON decodes the JSON values of the verifier parameters.
+*/
+func (p *VerifierParams) fromJSON(aux *verifierParamsJSON, j *jsonDecoder) {
+	p.u = aux.U
+	p.l = aux.L
+	p.pubk = j.g1(aux.Pubk)
+	p.H = j.g2(aux.H)
+}
+
+func (p *VerifierParams) MarshalJSON() ([]byte, error) {
+	aux, err := p.toJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&aux)
+}
+
+func (p *VerifierParams) UnmarshalJSON(data []byte) error {
+	var (
+		aux verifierParamsJSON
+		params VerifierParams
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	params.fromJSON(&aux, &j)
+	if j.err != nil {
+		return j.err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+/*
+MarshalJSON returns the JSON encoding of the prover parameters, where the
+signature of the digit i is at index i.
+*/
+func (p *ProverParams) MarshalJSON() ([]byte, error) {
+	var (
+		i int64
+	)
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	vp := p.VerifierParams()
+	aux := proverParamsJSON{Signatures: make([]string, p.u)}
+	aux.verifierParamsJSON, _ = vp.toJSON()
+	for i=0; i<p.u; i++ {
+		aux.Signatures[i] = bnHex(p.signatures[strconv.FormatInt(i, 10)])
+	}
+	return json.Marshal(&aux)
+}
+
+func (p *ProverParams) UnmarshalJSON(data []byte) error {
+	var (
+		aux proverParamsJSON
+		vp VerifierParams
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	vp.fromJSON(&aux.verifierParamsJSON, &j)
+	if int64(len(aux.Signatures)) != vp.u {
+		return errors.New("Parameters must have u signatures.")
+	}
+	params := ProverParams{signatures: make(map[string]*bn256.G2), H: vp.H, pubk: vp.pubk, u: vp.u, l: vp.l}
+	for i, s := range aux.Signatures {
+		params.signatures[strconv.Itoa(i)] = j.g2(s)
+	}
+	if j.err != nil {
+		return j.err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+func (p *paramsSet) MarshalJSON() ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	aux := paramsSetJSON{Pubk: bnHex(p.pubk), H: bnHex(p.H), Signatures: make(map[int64]string)}
+	for k, sig := range p.signatures {
+		aux.Signatures[k] = bnHex(sig)
+	}
+	return json.Marshal(&aux)
+}
+
+func (p *paramsSet) UnmarshalJSON(data []byte) error {
+	var (
+		aux paramsSetJSON
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if len(aux.Signatures) > MAXSET {
+		return errors.New("Set has too many elements.")
+	}
+	params := paramsSet{pubk: j.g1(aux.Pubk), H: j.g2(aux.H), signatures: make(map[int64]*bn256.G2)}
+	for k, s := range aux.Signatures {
+		params.signatures[k] = j.g2(s)
+	}
+	if j.err != nil {
+		return j.err
+	}
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	*p = params
+	return nil
+}
+
+/*
+MarshalJSON returns the JSON encoding of the proof for [a,b), with the same values
+as MarshalBinary.
+*/
+func (zkrp *ccs08) MarshalJSON() ([]byte, error) {
+	var (
+		err error
+		aux ccs08JSON
+	)
+	if err = zkrp.Validate(); err != nil {
+		return nil, err
+	}
+	if err = checkG2(zkrp.C, "C"); err != nil {
+		return nil, err
+	}
+	aux.A = zkrp.p.a
+	aux.B = zkrp.p.b
+	aux.C = bnHex(zkrp.C)
+	aux.Params, err = zkrp.p.v.toJSON()
+	if err == nil {
+		aux.P1, err = zkrp.proof_out.p1.toJSON()
+	}
+	if err == nil {
+		aux.P2, err = zkrp.proof_out.p2.toJSON()
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The commitments of P1 and P2 are derived from C
+	aux.P1.C = ""
+	aux.P2.C = ""
+	return json.Marshal(&aux)
+}
+
+func (zkrp *ccs08) UnmarshalJSON(data []byte) error {
+	var (
+		aux ccs08JSON
+		vp VerifierParams
+		out ccs08
+		j jsonDecoder
+	)
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.P1.C != "" || aux.P2.C != "" {
+		return errors.New("Proofs for [0,u^l) must not contain a commitment.")
+	}
+	vp.fromJSON(&aux.Params, &j)
+	out.p = &params{v: &vp, a: aux.A, b: aux.B}
+	out.C = j.g2(aux.C)
+	out.proof_out.p1.fromJSON(&aux.P1, &j)
+	out.proof_out.p2.fromJSON(&aux.P2, &j)
+	if j.err != nil {
+		return j.err
+	}
+	if err := out.validate(); err != nil {
+		return err
+	}
+	*zkrp = out
+	return nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"bytes"
+	"testing"
+	"math/big"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+Test that the proof for [a,b) can be verified after the binary and JSON encodings,
+which contain everything the verifier needs.
+*/
+func TestCCS08Encoding(t *testing.T) {
+	var (
+		zkrp ccs08
+		decoded ccs08
+		fromJSON ccs08
+	)
+	zkrp.Setup(18, 200)
+	zkrp.x = new(big.Int).SetInt64(42)
+	zkrp.r, _ = rand.Int(rand.Reader, bn256.Order)
+	zkrp.Prove()
+	data, err := zkrp.MarshalBinary()
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	// a, b, the verifier parameters, C and two proofs with l digits
+	l := int(zkrp.p.v.l)
+	size := 16 + (16 + G1SIZE + G2SIZE) + G2SIZE + 2 * (1 + l * (G2SIZE + GTSIZE) + G2SIZE + 2 * SCALARSIZE + 2 * l * SCALARSIZE)
+	if len(data) != size {
+		t.Errorf("Assert failure: expected %d bytes, actual: %d", size, len(data))
+	}
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	if decoded.x != nil || decoded.r != nil || decoded.p.p != nil {
+		t.Errorf("Assert failure: the encoding must only contain public values")
+	}
+	if result, _ := decoded.Verify(); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	if err := decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("Assert failure: expected error for a short encoding")
+	}
+	if err := decoded.UnmarshalBinary(append(data, 0)); err == nil {
+		t.Errorf("Assert failure: expected error for a long encoding")
+	}
+	js, _ := json.Marshal(&zkrp)
+	if err := json.Unmarshal(js, &fromJSON); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	if result, _ := fromJSON.Verify(); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	again, _ := fromJSON.MarshalBinary()
+	if !bytes.Equal(again, data) {
+		t.Errorf("Assert failure: JSON and binary encodings do not match")
+	}
+}
+
+/*
+Test the encodings of the proof for [0,u^l) and that invalid elements are rejected.
+*/
+func TestProofULEncoding(t *testing.T) {
+	var (
+		decoded proofUL
+	)
+	p, _ := SetupUL(10, 3)
+	vp := p.VerifierParams()
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	proof, _ := ProveUL(new(big.Int).SetInt64(421), r, p)
+	data, _ := proof.MarshalBinary()
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	if decoded.s != nil || decoded.t != nil || decoded.m != nil {
+		t.Errorf("Assert failure: the random values of the prover must not be encoded")
+	}
+	if result, _ := VerifyUL(&decoded, &vp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	js, _ := json.Marshal(&proof)
+	decoded = proofUL{}
+	if err := json.Unmarshal(js, &decoded); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	if result, _ := VerifyUL(&decoded, &vp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	// Offsets of C, V[0], a[0] and zsig[0]
+	offsetA := G2SIZE + 1 + G2SIZE
+	offsetZ := G2SIZE + 1 + 3 * (G2SIZE + GTSIZE) + G2SIZE + 2 * SCALARSIZE
+	tamper := []func(b []byte){
+		func(b []byte) { b[5] ^= 1 },
+		func(b []byte) { b[G2SIZE] = 0 },
+		func(b []byte) { b[G2SIZE + 1 + 7] ^= 1 },
+		func(b []byte) { b[offsetA + 100] ^= 1 },
+		func(b []byte) { copy(b[offsetZ:], bn256.Order.Bytes()) },
+	}
+	for i, f := range tamper {
+		b := append([]byte{}, data...)
+		f(b)
+		if err := decoded.UnmarshalBinary(b); err == nil {
+			t.Errorf("Assert failure for case %d: expected error", i)
+		}
+	}
+}
+
+/*
+Test the encodings of the parameters, which must not contain the private key of
+the issuer.
+*/
+func TestParamsEncoding(t *testing.T) {
+	var (
+		prover ProverParams
+		verifier VerifierParams
+		set paramsSet
+		setProof proofSet
+	)
+	k, _ := NewIssuerKey()
+	p, _ := k.SetupUL(10, 3)
+	data, _ := p.MarshalBinary()
+	if len(data) != 16 + G1SIZE + G2SIZE + 10 * G2SIZE {
+		t.Errorf("Assert failure: unexpected size %d", len(data))
+	}
+	if bytes.Contains(data, k.kp.privk.Bytes()) {
+		t.Errorf("Assert failure: the private key must not be encoded")
+	}
+	if err := prover.UnmarshalBinary(data); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	vp := p.VerifierParams()
+	vdata, _ := vp.MarshalBinary()
+	if err := verifier.UnmarshalBinary(vdata); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	proof, _ := ProveUL(new(big.Int).SetInt64(421), r, prover)
+	if result, _ := VerifyUL(&proof, &verifier); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	js, _ := json.Marshal(&p)
+	prover = ProverParams{}
+	if err := json.Unmarshal(js, &prover); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	again, _ := prover.MarshalBinary()
+	if !bytes.Equal(again, data) {
+		t.Errorf("Assert failure: JSON and binary encodings do not match")
+	}
+	js, _ = json.Marshal(&vp)
+	verifier = VerifierParams{}
+	if err := json.Unmarshal(js, &verifier); err != nil || verifier.u != 10 || verifier.l != 3 {
+		t.Errorf("Assert failure: unexpected error: %v", err)
+	}
+	if err := prover.UnmarshalBinary(data[:len(data)-G2SIZE]); err == nil {
+		t.Errorf("Assert failure: expected error for a missing signature")
+	}
+
+	s, _ := k.SetupSet([]int64{42, 12, 61})
+	data, _ = s.MarshalBinary()
+	if err := set.UnmarshalBinary(data); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	sp, _ := ProveSet(61, r, set)
+	pdata, _ := sp.MarshalBinary()
+	if err := setProof.UnmarshalBinary(pdata); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	svp := s.VerifierParams()
+	if result, _ := VerifySet(&setProof, &svp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	js, _ = json.Marshal(&sp)
+	setProof = proofSet{}
+	if err := json.Unmarshal(js, &setProof); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	if result, _ := VerifySet(&setProof, &svp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	js, _ = json.Marshal(&s)
+	set = paramsSet{}
+	if err := json.Unmarshal(js, &set); err != nil || len(set.signatures) != 3 {
+		t.Errorf("Assert failure: unexpected error: %v", err)
+	}
+	// The elements 12 and 42 in decreasing order
+	b := append([]byte{}, data...)
+	first := G1SIZE + G2SIZE + 8
+	copy(b[first:], data[first + 8 + G2SIZE:first + 2 * (8 + G2SIZE)])
+	copy(b[first + 8 + G2SIZE:], data[first:first + 8 + G2SIZE])
+	if err := set.UnmarshalBinary(b); err == nil {
+		t.Errorf("Assert failure: expected error for elements out of order")
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the selection of the parameters u and l of the CCS08 range
+proof. The proof for [a,b) requires u^l >= b-a. For a given l, the smallest such
+u is the best choice, since the size of the parameters grows with u, while the
+size of the proof and the number of pairings only depend on l. Then the candidates
+are the smallest u for each l, which are compared according to a cost model.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// Objectives of the selection of u and l
+	ParamsSize = iota
+	ProofSize
+	ProverPairings
+	VerifierPairings
+	TotalSize
+)
+
+var (
+	// Maximum value of u, namely the maximum number of signatures in the parameters
+	MAXU = int64(1) << 16
+)
+
+/*
+CostsUL contains the predicted costs of the proof for [a,b) with parameters u and l.
+ParamsSize is the size in bytes of the binary encoding of the prover parameters,
+and ProofSize the size of the two proofs for [0,u^l) in the binary encoding of the
+proof for [a,b). ProverPairings is the number of pairings computed by Prove, and
+VerifierPairings the number of Miller loops of Verify, which share a single final
+exponentiation.
+*/
+type CostsUL struct {
+	U, L int64
+	ParamsSize int64
+	ProofSize int64
+	ProverPairings int64
+	VerifierPairings int64
+}
+
+/*
+EstimateUL returns the predicted costs of the proof for [a,b) with parameters u and l.
+*/
+func EstimateUL(u, l int64) (CostsUL) {
+	var (
+		c CostsUL
+	)
+	c.U = u
+	c.L = l
+	// u || l || pubk || H || sig[0] || ... || sig[u-1]
+	c.ParamsSize = 16 + int64(G1SIZE + G2SIZE) + u * int64(G2SIZE)
+	// l || V[i] || a[i] || D || c || zr || zsig[i] || zv[i], for each proof
+	c.ProofSize = 2 * (1 + l * int64(G2SIZE + GTSIZE) + int64(G2SIZE) + 2 * int64(SCALARSIZE) + 2 * l * int64(SCALARSIZE))
+	// e(g,V[i]) for each proof
+	c.ProverPairings = 2 * l
+	// e(y^c.g^-zsig[i],V[i]) for each proof and e(g^zv,g)
+	c.VerifierPairings = 2 * l + 1
+	return c
+}
+
+/*
+Total returns the size of the parameters plus the size of the proof.
+*/
+func (c CostsUL) Total() (int64) {
+	return c.ParamsSize + c.ProofSize
+}
+
+/*
+cost returns the cost of the objective.
+*/
+func (c CostsUL) cost(objective int) (int64) {
+	switch objective {
+	case ParamsSize:
+		return c.ParamsSize
+	case ProofSize:
+		return c.ProofSize
+	case ProverPairings:
+		return c.ProverPairings
+	case VerifierPairings:
+		return c.VerifierPairings
+	}
+	return c.Total()
+}
+
+/*
+powerAtLeast returns true if and only if u^l >= size, for u >= 2 and size >= 1.
+The product stops as soon as it reaches size, then it does not overflow.
+*/
+func powerAtLeast(u, l, size int64) (bool) {
+	var (
+		i int64
+	)
+	r := int64(1)
+	for i=0; i<l; i++ {
+		// r.u >= size if and only if r > (size-1)/u
+		if r > (size - 1) / u {
+			return true
+		}
+		r = r * u
+	}
+	return r >= size
+}
+
+/*
+minimalU returns the smallest u >= 2 such that u^l >= size. The floating point
+estimate of size^(1/l) is corrected with exact integer arithmetic.
+*/
+func minimalU(size, l int64) (int64) {
+	if size <= 2 {
+		return 2
+	}
+	if l == 1 {
+		return size
+	}
+	// For l >= 2, the estimate is at most 2^32 and fits in an int64
+	u := int64(math.Pow(float64(size), 1/float64(l)))
+	if u < 2 {
+		u = 2
+	}
+	for u > 2 && powerAtLeast(u - 1, l, size) {
+		u = u - 1
+	}
+	for !powerAtLeast(u, l, size) {
+		u = u + 1
+	}
+	return u
+}
+
+/*
+CandidatesUL returns the costs of the smallest u for each l, such that u^l >= size
+and u <= MAXU, in increasing order of l.
+*/
+func CandidatesUL(size int64) ([]CostsUL, error) {
+	var (
+		l int64
+		result []CostsUL
+	)
+	if size < 1 {
+		return nil, errors.New("Size of the interval must be positive.")
+	}
+	for l=1; ; l++ {
+		u := minimalU(size, l)
+		if u <= MAXU {
+			result = append(result, EstimateUL(u, l))
+		}
+		// Larger values of l cannot reduce u below 2
+		if u == 2 {
+			return result, nil
+		}
+	}
+}
+
+/*
+OptimalUL returns the parameters u and l for an interval with size elements, which
+minimize the cost of the objective, and their predicted costs. Ties are broken by
+the size of the parameters plus the size of the proof.
+*/
+func OptimalUL(size int64, objective int) (CostsUL, error) {
+	var (
+		i int
+	)
+	if objective < ParamsSize || objective > TotalSize {
+		return CostsUL{}, errors.New("Unknown objective.")
+	}
+	candidates, err := CandidatesUL(size)
+	if err != nil {
+		return CostsUL{}, err
+	}
+	best := candidates[0]
+	for i=1; i<len(candidates); i++ {
+		c := candidates[i]
+		if c.cost(objective) < best.cost(objective) ||
+			(c.cost(objective) == best.cost(objective) && c.Total() < best.Total()) {
+			best = c
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math"
+	"math/big"
+	"crypto/rand"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+Test the smallest u such that u^l >= size.
+*/
+func TestMinimalU(t *testing.T) {
+	cases := [][3]int64{{150, 2, 13}, {169, 2, 13}, {170, 2, 14}, {4294967296, 4, 256}, {4294967297, 4, 257}, {1, 1, 2}, {1000, 1, 1000},
+		{1 << 62, 1, 1 << 62}, {1 << 62, 2, 1 << 31}, {1 << 62 + 1, 2, 1 << 31 + 1}, {1 << 62, 62, 2}, {1 << 62 + 1, 62, 3},
+		{math.MaxInt64, 1, math.MaxInt64}, {math.MaxInt64, 2, 3037000500}, {math.MaxInt64, 3, 2097152}, {math.MaxInt64, 63, 2},
+		{1 << 63 - 1 - 1 << 32, 63, 2}, {3037000499 * 3037000499, 2, 3037000499}, {3037000499 * 3037000499 + 1, 2, 3037000500},
+		{1162261467, 19, 3}, {1162261468, 19, 4}, {1162261466, 19, 3}}
+	for _, c := range cases {
+		if u := minimalU(c[0], c[1]); u != c[2] {
+			t.Errorf("Assert failure for size %d and l %d: expected %d, actual: %d", c[0], c[1], c[2], u)
+		}
+	}
+}
+
+/*
+Test the selection of u and l for ages and amounts, for every objective.
+*/
+func TestOptimalUL(t *testing.T) {
+	var (
+		objective int
+	)
+	names := []string{"params size", "proof size", "prover pairings", "verifier pairings", "total size"}
+	expected := map[int64][][2]int64{
+		150: {{2, 8}, {150, 1}, {150, 1}, {150, 1}, {13, 2}},
+		4294967296: {{2, 32}, {65536, 2}, {65536, 2}, {65536, 2}, {24, 7}},
+	}
+	for size, results := range expected {
+		candidates, _ := CandidatesUL(size)
+		for objective=ParamsSize; objective<=TotalSize; objective++ {
+			c, err := OptimalUL(size, objective)
+			if err != nil {
+				t.Errorf("Assert failure: unexpected error: %s", err)
+			}
+			if c.U != results[objective][0] || c.L != results[objective][1] {
+				t.Errorf("Assert failure for size %d and %s: expected (%d, %d), actual: (%d, %d)",
+					size, names[objective], results[objective][0], results[objective][1], c.U, c.L)
+			}
+			for _, other := range candidates {
+				if other.cost(objective) < c.cost(objective) {
+					t.Errorf("Assert failure for size %d and %s: (%d, %d) is better", size, names[objective], other.U, other.L)
+				}
+			}
+			t.Logf("size %d, %s: u = %d, l = %d, params %d bytes, proof %d bytes, pairings %d/%d",
+				size, names[objective], c.U, c.L, c.ParamsSize, c.ProofSize, c.ProverPairings, c.VerifierPairings)
+		}
+	}
+	if _, err := OptimalUL(150, TotalSize + 1); err == nil {
+		t.Errorf("Assert failure: expected error for an unknown objective")
+	}
+	if _, err := OptimalUL(0, TotalSize); err == nil {
+		t.Errorf("Assert failure: expected error for an empty interval")
+	}
+}
+
+/*
+Test that the predicted sizes match the encodings of the parameters and the proof.
+*/
+func TestSetupOptimal(t *testing.T) {
+	var (
+		zkrp ccs08
+	)
+	costs, err := zkrp.SetupOptimal(18, 150, VerifierPairings)
+	if err != nil || zkrp.p.v.u != costs.U || zkrp.p.v.l != costs.L || costs.L != 1 {
+		t.Errorf("Assert failure: unexpected parameters (%d, %d), %v", costs.U, costs.L, err)
+	}
+	zkrp.x = new(big.Int).SetInt64(149)
+	zkrp.r, _ = rand.Int(rand.Reader, bn256.Order)
+	if e := zkrp.Prove(); e != nil {
+		t.Errorf("Assert failure: unexpected error: %s", e)
+	}
+	if result, _ := zkrp.Verify(); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	params, _ := zkrp.p.p.MarshalBinary()
+	if int64(len(params)) != costs.ParamsSize {
+		t.Errorf("Assert failure: expected %d bytes of parameters, actual: %d", costs.ParamsSize, len(params))
+	}
+	proof, _ := zkrp.MarshalBinary()
+	// a, b, the verifier parameters and C
+	header := 16 + (16 + G1SIZE + G2SIZE) + G2SIZE
+	if int64(len(proof) - header) != costs.ProofSize {
+		t.Errorf("Assert failure: expected %d bytes of proof, actual: %d", costs.ProofSize, len(proof) - header)
+	}
+	zkrp.x = new(big.Int).SetInt64(150)
+	zkrp.C = nil
+	zkrp.Prove()
+	if result, _ := zkrp.Verify(); result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+	if _, err := zkrp.SetupOptimal(200, 18, TotalSize); err == nil {
+		t.Errorf("Assert failure: expected error for a > b")
+	}
+	if _, err := zkrp.SetupOptimal(18, 18, TotalSize); err == nil || zkrp.p != nil {
+		t.Errorf("Assert failure: expected error for an empty interval")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the verification of the pairing equations of the CCS08 proofs.
+For each digit, the verifier checks that
+a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv] == e(y^c.g^-zsig, V).e(g^zv, g)
+and, instead of computing two pairings and three exponentiations in GT for each
+digit, all the equations are combined with random weights rho into
+prod a^rho == prod e(y^(rho.c).g^(-rho.zsig), V) . e(g^(sum rho.zv), g)
+which is computed by bn256.PairingProduct with one Miller loop for each digit, one
+Miller loop for the generator of G2 and a single final exponentiation. If any
+equation does not hold, the combination holds with probability at most 2^-128.
+The weights are drawn by batchCCS08, which also combines the other equations.
+This requires the elements a to belong to GT, which is the case for the proofs
+computed by ProveUL and ProveSet and is checked when decoding proofs.
+*/
+
+package zkproofs
+
+import (
+	"bytes"
+	"math/big"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+var (
+	// Random weights have 128 bits
+	WEIGHT = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+/*
+pairingProduct accumulates the pairing equations of the CCS08 proofs.
+*/
+type pairingProduct struct {
+	g1 []*bn256.G1
+	g2 []*bn256.G2
+	a *bn256.GT
+	zv *big.Int
+}
+
+/*
+newPairingProduct returns an empty product of pairing equations.
+*/
+func newPairingProduct() (*pairingProduct) {
+	return &pairingProduct{a: new(bn256.GT).ScalarMult(E, big.NewInt(0)), zv: new(big.Int)}
+}
+
+/*
+add adds the equation a == e(y^c.g^-zsig, V).e(g^zv, g) with the weight rho.
+*/
+func (pp *pairingProduct) add(rho *big.Int, pubk *bn256.G1, V *bn256.G2, a *bn256.GT, c, zsig, zv *big.Int) {
+	// y^(rho.c).g^(-rho.zsig)
+	P := new(bn256.G1).ScalarMult(pubk, Mod(Multiply(rho, c), bn256.Order))
+	P.Add(P, new(bn256.G1).ScalarBaseMult(Mod(Sub(new(big.Int), Multiply(rho, zsig)), bn256.Order)))
+	pp.g1 = append(pp.g1, P)
+	pp.g2 = append(pp.g2, V)
+	pp.a.Add(pp.a, new(bn256.GT).ScalarMult(a, rho))
+	pp.zv = Mod(Add(pp.zv, Multiply(rho, zv)), bn256.Order)
+}
+
+/*
+check returns true if and only if the product of the equations holds.
+*/
+func (pp *pairingProduct) check() (bool) {
+	g1 := append(pp.g1, new(bn256.G1).ScalarBaseMult(pp.zv))
+	g2 := append(pp.g2, G2)
+	p := bn256.PairingProduct(g1, g2)
+	return bytes.Equal(p.Marshal(), pp.a.Marshal())
+}
@@ -0,0 +1,161 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"bytes"
+	"testing"
+	"math/big"
+	"crypto/rand"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+verifyULPairings verifies the pairing equations of the proof for [0,u^l) with two
+pairings and three exponentiations in GT for each digit, as VerifyUL did before
+the pairing product. It is used as reference by the tests and the benchmarks.
+*/
+func verifyULPairings(proof_out *proofUL, p *VerifierParams) (bool) {
+	var (
+		i int64
+		p1,p2 *bn256.GT
+	)
+	r := true
+	for i = 0; i < p.l; i++ {
+		// a == [e(V,y)^c].[e(V,g)^-zsig].[e(g,g)^zv]
+		p1 = bn256.Pair(p.pubk, proof_out.V[i])
+		p1.ScalarMult(p1, proof_out.c)
+		p2 = bn256.Pair(G1, proof_out.V[i])
+		p2.ScalarMult(p2, proof_out.zsig[i])
+		p2.Invert(p2)
+		p1.Add(p1, p2)
+		p1.Add(p1, new(bn256.GT).ScalarMult(E, proof_out.zv[i]))
+		r = r && bytes.Equal(p1.Marshal(), proof_out.a[i].Marshal())
+	}
+	return r
+}
+
+/*
+Test that the pairing product accepts the same proofs as the reference verifier,
+and rejects proofs where a single pairing equation does not hold.
+*/
+func TestPairingProductUL(t *testing.T) {
+	p, _ := SetupUL(10, 4)
+	vp := p.VerifierParams()
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	valid, _ := ProveUL(new(big.Int).SetInt64(4217), r, p)
+	if ok, _ := VerifyUL(&valid, &vp); ok != true || verifyULPairings(&valid, &vp) != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	// zv is not bound by the challenge nor by D, then only the pairing equations fail
+	for i := 0; i < 4; i++ {
+		proof := valid
+		proof.zv = append([]*big.Int{}, valid.zv...)
+		proof.zv[i] = Mod(Add(proof.zv[i], big.NewInt(1)), bn256.Order)
+		ok, err := VerifyUL(&proof, &vp)
+		if ok != false || err != nil || verifyULPairings(&proof, &vp) != false {
+			t.Errorf("Assert failure for digit %d: expected false, actual: %t, %v", i, ok, err)
+		}
+	}
+	// Two wrong equations which compensate each other in the product without weights
+	proof := valid
+	proof.zv = append([]*big.Int{}, valid.zv...)
+	proof.zv[0] = Mod(Add(proof.zv[0], big.NewInt(1)), bn256.Order)
+	proof.zv[1] = Mod(Sub(proof.zv[1], big.NewInt(1)), bn256.Order)
+	if ok, _ := VerifyUL(&proof, &vp); ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
+
+/*
+Test the pairing product of the set membership proof and of both proofs of ccs08.
+*/
+func TestPairingProductCCS08(t *testing.T) {
+	var (
+		zkrp ccs08
+	)
+	s, _ := SetupSet([]int64{12, 42})
+	svp := s.VerifierParams()
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	set, _ := ProveSet(42, r, s)
+	set.zv = Mod(Add(set.zv, big.NewInt(1)), bn256.Order)
+	if ok, err := VerifySet(&set, &svp); ok != false || err != nil {
+		t.Errorf("Assert failure: expected false, actual: %t, %v", ok, err)
+	}
+	zkrp.Setup(18, 200)
+	zkrp.x = new(big.Int).SetInt64(42)
+	zkrp.r = r
+	zkrp.Prove()
+	zkrp.proof_out.p2.zv = append([]*big.Int{}, zkrp.proof_out.p2.zv...)
+	zkrp.proof_out.p2.zv[0] = Mod(Add(zkrp.proof_out.p2.zv[0], big.NewInt(1)), bn256.Order)
+	if ok, err := zkrp.Verify(); ok != false || err != nil {
+		t.Errorf("Assert failure: expected false, actual: %t, %v", ok, err)
+	}
+}
+
+/*
+benchmarkUL returns the parameters and a proof for [0,2^32), with u and l chosen
+for the size of the parameters plus the size of the proof.
+*/
+func benchmarkUL(b *testing.B) (VerifierParams, proofUL) {
+	costs, _ := OptimalUL(4294967296, TotalSize)
+	p, _ := SetupUL(costs.U, costs.L)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	proof, _ := ProveUL(new(big.Int).SetInt64(4294967295), r, p)
+	b.ResetTimer()
+	return p.VerifierParams(), proof
+}
+
+func BenchmarkVerifyUL(b *testing.B) {
+	vp, proof := benchmarkUL(b)
+	for i := 0; i < b.N; i++ {
+		ok, _ := VerifyUL(&proof, &vp)
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
+
+/*
+BenchmarkVerifyULPairings measures the pairing equations as VerifyUL computed them
+before the pairing product, to compare with BenchmarkVerifyUL.
+*/
+func BenchmarkVerifyULPairings(b *testing.B) {
+	vp, proof := benchmarkUL(b)
+	for i := 0; i < b.N; i++ {
+		if verifyULPairings(&proof, &vp) != true {
+			b.Errorf("Assert failure: expected true")
+		}
+	}
+}
+
+func BenchmarkVerifyCCS08(b *testing.B) {
+	var (
+		zkrp ccs08
+	)
+	zkrp.Setup(0, 150)
+	zkrp.x = new(big.Int).SetInt64(42)
+	zkrp.r, _ = rand.Int(rand.Reader, bn256.Order)
+	zkrp.Prove()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ok, _ := zkrp.Verify()
+		if ok != true {
+			b.Errorf("Assert failure: expected true, actual: %t", ok)
+		}
+	}
+}
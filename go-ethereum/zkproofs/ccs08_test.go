@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"crypto/rand"
 	"fmt"
+	"strconv"
 	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
 	"time"
 )
@@ -100,7 +101,8 @@ func TestZKRP_UL(t *testing.T) {
 	p, _ := SetupUL(10, 5)
 	r, _ = rand.Int(rand.Reader, bn256.Order)
 	proof_out, _ := ProveUL(new(big.Int).SetInt64(42176), r, p)
-	result, _ := VerifyUL(&proof_out, &p)
+	vp := p.VerifierParams()
+	result, _ := VerifyUL(&proof_out, &vp)
 	fmt.Println("ZKRP UL result: ")
 	fmt.Println(result)
 	if result != true {
@@ -122,6 +124,29 @@ func TestZKRPSetupInput(t *testing.T) {
 	}
 }
 
+/*
+Tests that the interval [a,b) is half-open: b-1 is accepted, while b is rejected.
+*/
+func TestZKRPUpperBound(t *testing.T) {
+	var (
+		zkrp ccs08
+	)
+	zkrp.Setup(18, 200)
+	zkrp.r, _ = rand.Int(rand.Reader, bn256.Order)
+	zkrp.x = new(big.Int).SetInt64(199)
+	zkrp.Prove()
+	if result, _ := zkrp.Verify(); result != true {
+		t.Errorf("Assert failure: expected true for b-1, actual: %t", result)
+	}
+	zkrp.x = new(big.Int).SetInt64(200)
+	zkrp.C = nil
+	if e := zkrp.Prove(); e == nil {
+		if result, _ := zkrp.Verify(); result != false {
+			t.Errorf("Assert failure: expected false for b, actual: %t", result)
+		}
+	}
+}
+
 /*
 Tests the ZK Set Membership (CCS08) protocol.
 */
@@ -145,7 +170,8 @@ func TestZKSet(t *testing.T) {
 	proofTime := time.Now()
 	fmt.Println("Proof time:")
 	fmt.Println(proofTime.Sub(setupTime))
-	result, _ := VerifySet(&proof_out, &p)
+	vp := p.VerifierParams()
+	result, _ := VerifySet(&proof_out, &vp)
 	verifyTime := time.Now()
 	fmt.Println("Verify time:")
 	fmt.Println(verifyTime.Sub(proofTime))
@@ -188,3 +214,90 @@ func TestZKRP(t *testing.T) {
 		t.Errorf("Assert failure: expected true, actual: %t", result)
 	}
 }
+
+/*
+Tests that the ZK Range Proof (CCS08) is bound to the commitment C held by the
+verifier.
+*/
+func TestZKRPCommitment(t *testing.T) {
+	var (
+		prover ccs08
+		other ccs08
+	)
+	prover.Setup(18, 200)
+	prover.x = new(big.Int).SetInt64(42)
+	prover.r, _ = rand.Int(rand.Reader, bn256.Order)
+	C, _ := prover.Commit()
+	if e := prover.Prove(); e != nil {
+		t.Errorf("Assert failure: unexpected error: %s", e)
+	}
+	// The verifier only has the public parameters, C and the proof
+	verifier := ccs08{p: &params{v: prover.p.v, a: 18, b: 200}, C: C, proof_out: prover.proof_out}
+	if result, _ := verifier.Verify(); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	// Proofs for another commitment to the same value
+	other = ccs08{p: prover.p, x: prover.x}
+	other.r, _ = rand.Int(rand.Reader, bn256.Order)
+	other.Prove()
+	verifier.proof_out.p2 = other.proof_out.p2
+	if result, _ := verifier.Verify(); result != false {
+		t.Errorf("Assert failure: expected false for mixed proofs, actual: %t", result)
+	}
+	verifier.C = other.C
+	verifier.proof_out = prover.proof_out
+	if result, _ := verifier.Verify(); result != false {
+		t.Errorf("Assert failure: expected false for another commitment, actual: %t", result)
+	}
+	verifier.C = nil
+	if result, err := verifier.Verify(); result != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", result, err)
+	}
+	// C must open to x and r
+	prover.x = new(big.Int).SetInt64(43)
+	if e := prover.Prove(); e == nil {
+		t.Errorf("Assert failure: expected error for a wrong opening")
+	}
+}
+
+/*
+Tests that the issuer key signs the parameters, while the prover and verifier
+parameters only contain the public key.
+*/
+func TestIssuerKey(t *testing.T) {
+	var (
+		i int64
+	)
+	k, err := NewIssuerKey()
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	p, _ := k.SetupUL(10, 3)
+	// verify does not support the message 0, whose point g^0 is the identity
+	for i=1; i<p.u; i++ {
+		if ok, _ := verify(p.signatures[strconv.FormatInt(i, 10)], new(big.Int).SetInt64(i), p.pubk); ok != true {
+			t.Errorf("Assert failure: invalid signature of %d", i)
+		}
+	}
+	vp := p.VerifierParams()
+	if vp.u != 10 || vp.l != 3 || vp.pubk != k.kp.pubk || vp.H != p.H {
+		t.Errorf("Assert failure: verifier parameters do not match the prover parameters")
+	}
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	proof_out, _ := ProveUL(new(big.Int).SetInt64(421), r, p)
+	if result, _ := VerifyUL(&proof_out, &vp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+	// Parameters signed by another issuer
+	other, _ := SetupUL(10, 3)
+	forged, _ := ProveUL(new(big.Int).SetInt64(421), r, other)
+	if result, _ := VerifyUL(&forged, &vp); result != false {
+		t.Errorf("Assert failure: expected false, actual: %t", result)
+	}
+	s, _ := k.SetupSet([]int64{12, 42})
+	set, _ := ProveSet(42, r, s)
+	svp := s.VerifierParams()
+	if result, _ := VerifySet(&set, &svp); result != true {
+		t.Errorf("Assert failure: expected true, actual: %t", result)
+	}
+}
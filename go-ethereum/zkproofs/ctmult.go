@@ -0,0 +1,307 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the constant-time scalar multiplication, which must be used
+whenever the scalar is secret, e.g. the witness or the blinding factors of the prover.
+Points are represented in projective coordinates, where (X, Y, Z) represents the
+affine point (X/Z, Y/Z), and are added with the complete formulas for a = 0 from:
+Complete addition formulas for prime order elliptic curves
+Joost Renes, Craig Costello and Lejla Batina
+Eurocrypt 2016
+These formulas have no exceptional cases, in particular they also work for doubling
+and for the point at infinity (0, 1, 0), therefore they do not branch.
+The scalar is processed in fixed windows of ctWindow bits, from the most significant
+one, and every table lookup reads the whole table. Then the sequence of operations
+and of memory accesses does not depend on the scalar.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+)
+
+const (
+	// Window size of the constant-time scalar multiplication
+	ctWindow = uint(4)
+)
+
+var (
+	// 3b, where b = 7 is the constant of the curve equation
+	feB3 = fe{21, 0, 0, 0}
+)
+
+/*
+projective is an elliptic curve point in projective coordinates.
+*/
+type projective struct {
+	x, y, z fe
+}
+
+/*
+setInfinity sets p to the point at infinity (0, 1, 0).
+*/
+func (p *projective) setInfinity() *projective {
+	p.x = fe{}
+	p.y = feOne
+	p.z = fe{}
+	return p
+}
+
+/*
+setP256 sets p to the p256 point a. The point a is public, then this method may branch.
+*/
+func (p *projective) setP256(a *p256) *projective {
+	if a.IsZero() {
+		return p.setInfinity()
+	}
+	feSetBig(&p.x, a.X)
+	feSetBig(&p.y, a.Y)
+	p.z = feOne
+	return p
+}
+
+/*
+setAffine sets p to the affine point a.
+*/
+func (p *projective) setAffine(a *affine) *projective {
+	if a.inf {
+		return p.setInfinity()
+	}
+	p.x = a.x
+	p.y = a.y
+	p.z = feOne
+	return p
+}
+
+/*
+toP256 converts p to affine coordinates. The result is public, then this method
+may branch on whether it is the point at infinity.
+*/
+func (p *projective) toP256() *p256 {
+	var (
+		zinv, x, y fe
+	)
+	if feIsZero(&p.z) {
+		return new(p256).SetInfinity()
+	}
+	feInv(&zinv, &p.z)
+	feMul(&x, &p.x, &zinv)
+	feMul(&y, &p.y, &zinv)
+	return &p256{X: feBig(&x), Y: feBig(&y)}
+}
+
+/*
+add sets r = p + q, using Algorithm 7 of Renes, Costello and Batina.
+*/
+func (r *projective) add(p, q *projective) *projective {
+	var (
+		t0, t1, t2, t3, t4, x3, y3, z3 fe
+	)
+	feMul(&t0, &p.x, &q.x)
+	feMul(&t1, &p.y, &q.y)
+	feMul(&t2, &p.z, &q.z)
+	feAdd(&t3, &p.x, &p.y)
+	feAdd(&t4, &q.x, &q.y)
+	feMul(&t3, &t3, &t4)
+	feAdd(&t4, &t0, &t1)
+	feSub(&t3, &t3, &t4)
+	feAdd(&t4, &p.y, &p.z)
+	feAdd(&x3, &q.y, &q.z)
+	feMul(&t4, &t4, &x3)
+	feAdd(&x3, &t1, &t2)
+	feSub(&t4, &t4, &x3)
+	feAdd(&x3, &p.x, &p.z)
+	feAdd(&y3, &q.x, &q.z)
+	feMul(&x3, &x3, &y3)
+	feAdd(&y3, &t0, &t2)
+	feSub(&y3, &x3, &y3)
+	feAdd(&x3, &t0, &t0)
+	feAdd(&t0, &x3, &t0)
+	feMul(&t2, &feB3, &t2)
+	feAdd(&z3, &t1, &t2)
+	feSub(&t1, &t1, &t2)
+	feMul(&y3, &feB3, &y3)
+	feMul(&x3, &t4, &y3)
+	feMul(&t2, &t3, &t1)
+	feSub(&x3, &t2, &x3)
+	feMul(&y3, &y3, &t0)
+	feMul(&t1, &t1, &z3)
+	feAdd(&y3, &t1, &y3)
+	feMul(&t0, &t0, &t3)
+	feMul(&z3, &z3, &t4)
+	feAdd(&z3, &z3, &t0)
+	r.x, r.y, r.z = x3, y3, z3
+	return r
+}
+
+/*
+double sets r = 2p, using Algorithm 9 of Renes, Costello and Batina.
+*/
+func (r *projective) double(p *projective) *projective {
+	var (
+		t0, t1, t2, x3, y3, z3 fe
+	)
+	feSqr(&t0, &p.y)
+	feAdd(&z3, &t0, &t0)
+	feAdd(&z3, &z3, &z3)
+	feAdd(&z3, &z3, &z3)
+	feMul(&t1, &p.y, &p.z)
+	feSqr(&t2, &p.z)
+	feMul(&t2, &feB3, &t2)
+	feMul(&x3, &t2, &z3)
+	feAdd(&y3, &t0, &t2)
+	feMul(&z3, &t1, &z3)
+	feAdd(&t1, &t2, &t2)
+	feAdd(&t2, &t1, &t2)
+	feSub(&t0, &t0, &t2)
+	feMul(&y3, &t0, &y3)
+	feAdd(&y3, &x3, &y3)
+	feMul(&t1, &p.x, &p.y)
+	feMul(&x3, &t0, &t1)
+	feAdd(&x3, &x3, &x3)
+	r.x, r.y, r.z = x3, y3, z3
+	return r
+}
+
+/*
+ctEqual returns all ones if a is equal to b and zero otherwise, without branching.
+*/
+func ctEqual(a, b uint64) uint64 {
+	x := a ^ b
+	return ((x | -x) >> 63) - 1
+}
+
+/*
+feMove sets r = a if mask is all ones, and leaves r unchanged if mask is zero.
+*/
+func feMove(r, a *fe, mask uint64) {
+	r[0] = (a[0] & mask) | (r[0] &^ mask)
+	r[1] = (a[1] & mask) | (r[1] &^ mask)
+	r[2] = (a[2] & mask) | (r[2] &^ mask)
+	r[3] = (a[3] & mask) | (r[3] &^ mask)
+}
+
+/*
+lookup sets p = table[d], reading every entry of the table.
+*/
+func (p *projective) lookup(table []projective, d uint) *projective {
+	var (
+		i int
+	)
+	p.setInfinity()
+	for i=0; i<len(table); i++ {
+		mask := ctEqual(uint64(i), uint64(d))
+		feMove(&p.x, &table[i].x, mask)
+		feMove(&p.y, &table[i].y, mask)
+		feMove(&p.z, &table[i].z, mask)
+	}
+	return p
+}
+
+/*
+ctTable returns the multiples table[k] = k.p, for 0 <= k < 2^ctWindow.
+*/
+func ctTable(p *projective) []projective {
+	var (
+		k int
+	)
+	table := make([]projective, 1 << ctWindow)
+	table[0].setInfinity()
+	for k=1; k<len(table); k++ {
+		table[k].add(&table[k-1], p)
+	}
+	return table
+}
+
+/*
+ctMultiExp computes prod_i points[i]^scalars[i] in constant time with respect to
+the scalars. All windows are processed, including the ones equal to zero.
+*/
+func ctMultiExp(points []projective, scalars []scalar) projective {
+	var (
+		i int
+		k uint
+		offset uint
+		acc, q projective
+	)
+	w := ctWindow
+	tables := make([][]projective, len(points))
+	for i=0; i<len(points); i++ {
+		tables[i] = ctTable(&points[i])
+	}
+	acc.setInfinity()
+	offset = (255 / w + 1) * w
+	for offset > 0 {
+		offset = offset - w
+		for k=0; k<w; k++ {
+			acc.double(&acc)
+		}
+		for i=0; i<len(points); i++ {
+			q.lookup(tables[i], scalars[i].window(offset, w))
+			acc.add(&acc, &q)
+		}
+	}
+	return acc
+}
+
+/*
+ScalarMultCT computes p = a^n in constant time with respect to n. The point a is
+assumed to be public.
+*/
+func (p *p256) ScalarMultCT(a *p256, n *big.Int) *p256 {
+	var (
+		q projective
+		s scalar
+	)
+	q.setP256(a)
+	s.setBig(n)
+	r := ctMultiExp([]projective{q}, []scalar{s})
+	*p = *r.toP256()
+	return p
+}
+
+/*
+ScalarBaseMultCT computes p = g^n in constant time with respect to n, where g is
+the base point of the curve.
+*/
+func (p *p256) ScalarBaseMultCT(n *big.Int) *p256 {
+	return p.ScalarMultCT(&p256{X: GX, Y: GY}, n)
+}
+
+/*
+MultiExpCT computes prod_i a[i]^b[i] in constant time with respect to the exponents.
+Contrary to MultiExp, zero exponents are not skipped. The points are assumed to be
+public.
+*/
+func MultiExpCT(a []*p256, b []*big.Int) (*p256, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	points := make([]projective, len(a))
+	scalars := make([]scalar, len(a))
+	for i=0; i<len(a); i++ {
+		points[i].setP256(a[i])
+		scalars[i].setBig(b[i])
+	}
+	r := ctMultiExp(points, scalars)
+	return r.toP256(), nil
+}
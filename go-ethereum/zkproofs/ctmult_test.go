@@ -0,0 +1,177 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"crypto/rand"
+	"math/big"
+)
+
+/*
+Test the complete addition formulas, including the exceptional cases of the
+affine formulas: doubling, adding the inverse and adding the point at infinity.
+*/
+func TestProjective(t *testing.T) {
+	var (
+		p, q, inf, r projective
+	)
+	a := new(p256).ScalarBaseMult(new(big.Int).SetInt64(3))
+	b := new(p256).ScalarBaseMult(new(big.Int).SetInt64(5))
+	p.setP256(a)
+	q.setP256(b)
+	inf.setInfinity()
+	sum := new(p256).ScalarBaseMult(new(big.Int).SetInt64(8))
+	if !r.add(&p, &q).toP256().Equals(sum) {
+		t.Errorf("Assert failure: wrong sum")
+	}
+	twice := new(p256).ScalarBaseMult(new(big.Int).SetInt64(6))
+	if !r.add(&p, &p).toP256().Equals(twice) || !r.double(&p).toP256().Equals(twice) {
+		t.Errorf("Assert failure: wrong double")
+	}
+	if !r.add(&p, &inf).toP256().Equals(a) || !r.add(&inf, &p).toP256().Equals(a) {
+		t.Errorf("Assert failure: wrong sum with infinity")
+	}
+	if !r.double(&inf).toP256().IsZero() {
+		t.Errorf("Assert failure: double of infinity is not infinity")
+	}
+	var neg projective
+	neg.setP256(new(p256).ScalarBaseMult(Sub(ORDER, new(big.Int).SetInt64(3))))
+	if !r.add(&p, &neg).toP256().IsZero() {
+		t.Errorf("Assert failure: p - p is not infinity")
+	}
+}
+
+/*
+Test the conversion of scalars to limbs without big.Int reduction, including the
+values close to ORDER and 2^256, and the values which are reduced by Mod.
+*/
+func TestScalarSetBig(t *testing.T) {
+	var (
+		s scalar
+		f fe
+	)
+	max := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	r, _ := rand.Int(rand.Reader, max)
+	cases := []*big.Int{big.NewInt(0), big.NewInt(1), r, Sub(ORDER, big.NewInt(1)), ORDER,
+		Add(ORDER, big.NewInt(1)), max, big.NewInt(-5), new(big.Int).Lsh(max, 3)}
+	for _, x := range cases {
+		s.setBig(x)
+		f = fe(s)
+		if feBig(&f).Cmp(Mod(x, ORDER)) != 0 {
+			t.Errorf("Assert failure: wrong scalar for %s", x)
+		}
+		feSetBig(&f, x)
+		if feBig(&f).Cmp(Mod(x, CURVE.P)) != 0 {
+			t.Errorf("Assert failure: wrong field element for %s", x)
+		}
+	}
+	f = feOrder
+	if feBig(&f).Cmp(ORDER) != 0 {
+		t.Errorf("Assert failure: wrong limbs of ORDER")
+	}
+}
+
+/*
+Test the constant-time scalar multiplication against ScalarMult.
+*/
+func TestScalarMultCT(t *testing.T) {
+	var (
+		i int
+	)
+	h, _ := MapToGroup(SEEDH)
+	scalars := []*big.Int{
+		new(big.Int).SetInt64(0),
+		new(big.Int).SetInt64(1),
+		new(big.Int).SetInt64(15),
+		new(big.Int).SetInt64(16),
+		Sub(ORDER, new(big.Int).SetInt64(1)),
+		ORDER,
+	}
+	for i=0; i<8; i++ {
+		r, _ := rand.Int(rand.Reader, ORDER)
+		scalars = append(scalars, r)
+	}
+	for _, k := range scalars {
+		expected := new(p256).ScalarMult(h, k)
+		actual := new(p256).ScalarMultCT(h, k)
+		if !actual.Equals(expected) {
+			t.Errorf("Assert failure: ScalarMultCT differs from ScalarMult for %s", k)
+		}
+		expected = new(p256).ScalarBaseMult(k)
+		actual = new(p256).ScalarBaseMultCT(k)
+		if !actual.Equals(expected) {
+			t.Errorf("Assert failure: ScalarBaseMultCT differs from ScalarBaseMult for %s", k)
+		}
+	}
+	inf := new(p256).SetInfinity()
+	if !new(p256).ScalarMultCT(inf, scalars[6]).IsZero() {
+		t.Errorf("Assert failure: expected the point at infinity")
+	}
+}
+
+/*
+Test the constant-time multi-exponentiation against MultiExp.
+*/
+func TestMultiExpCT(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 17} {
+		a, b := randomMultiExpInput(n)
+		if n > 1 {
+			b[0] = new(big.Int).SetInt64(0)
+			a[1] = new(p256).SetInfinity()
+		}
+		expected, _ := MultiExp(a, b)
+		actual, _ := MultiExpCT(a, b)
+		if !actual.Equals(expected) {
+			t.Errorf("Assert failure: MultiExpCT differs from MultiExp for %d points", n)
+		}
+	}
+	_, err := MultiExpCT(make([]*p256, 2), make([]*big.Int, 1))
+	if err == nil {
+		t.Errorf("Assert failure: expected error for different sizes")
+	}
+}
+
+/*
+Test the constant-time Pedersen commitment against g^x.h^r.
+*/
+func TestCommitG1CT(t *testing.T) {
+	h, _ := MapToGroup(SEEDH)
+	x := new(big.Int).SetInt64(42)
+	r, _ := rand.Int(rand.Reader, ORDER)
+	C, _ := CommitG1(x, r, h)
+	expected := new(p256).ScalarBaseMult(x)
+	expected.Multiply(expected, new(p256).ScalarMult(h, r))
+	if !C.Equals(expected) {
+		t.Errorf("Assert failure: wrong commitment")
+	}
+}
+
+func BenchmarkScalarMultCT(b *testing.B) {
+	h, _ := MapToGroup(SEEDH)
+	k, _ := rand.Int(rand.Reader, ORDER)
+	b.Run("Variable", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			new(p256).ScalarMult(h, k)
+		}
+	})
+	b.Run("Constant", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			new(p256).ScalarMultCT(h, k)
+		}
+	})
+}
@@ -0,0 +1,436 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the canonical binary encoding of Bulletproofs proofs. Points
+are encoded in 33 bytes using the compressed SEC format, namely 0x02 or 0x03,
+according to the parity of Y, followed by X in 32 bytes. The point at infinity
+is encoded as 33 zero bytes. Scalars are encoded in 32 bytes in big-endian order
+and must be smaller than ORDER. Decoding is strict: any other encoding is rejected.
+Proofs over other groups are encoded in the same way, where points take
+Group.ElementSize bytes and scalars must be smaller than the order of the group.
+
+The proof that V commits to an element of [a,b) is encoded as
+V || P1 || P2, where each proof for [0,2^N) is encoded as
+A || S || T1 || T2 || Taux || Mu || Tprime || k || L[0] || R[0] || ... || L[k-1] || R[k-1] || a || b
+and k = log(N) is the number of rounds of the inner product argument, in one byte.
+Bulletproofs+ proofs are encoded in the same way as V || P1 || P2, where each proof
+for [0,2^N) is encoded as
+A || k || L[0] || R[0] || ... || L[k-1] || R[k-1] || A' || B || r' || s' || delta'
+and A', B, r', s' and delta' belong to the weighted inner product proof.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+)
+
+var (
+	POINTSIZE = 33
+	SCALARSIZE = 32
+	// Maximum number of rounds of the inner product argument
+	MAXROUNDS = 32
+)
+
+/*
+MarshalBinary returns the compressed encoding of p in 33 bytes.
+*/
+func (p *p256) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, POINTSIZE)
+	if p.IsZero() {
+		return buf, nil
+	}
+	buf[0] = 0x02 + byte(p.Y.Bit(0))
+	x := p.X.Bytes()
+	copy(buf[POINTSIZE-len(x):], x)
+	return buf, nil
+}
+
+/*
+UnmarshalBinary decodes the compressed encoding of an elliptic curve point. It
+returns an error if the encoding is not canonical or if the point is not on the curve.
+*/
+func (p *p256) UnmarshalBinary(data []byte) error {
+	var (
+		i int
+	)
+	if len(data) != POINTSIZE {
+		return errors.New("Point encoding must have 33 bytes.")
+	}
+	if data[0] == 0 {
+		for i=1; i<POINTSIZE; i++ {
+			if data[i] != 0 {
+				return errors.New("Invalid encoding of the point at infinity.")
+			}
+		}
+		p.SetInfinity()
+		return nil
+	}
+	if data[0] != 0x02 && data[0] != 0x03 {
+		return errors.New("Invalid point prefix.")
+	}
+	x := new(big.Int).SetBytes(data[1:])
+	if x.Cmp(CURVE.P) >= 0 {
+		return errors.New("Point coordinate is not reduced.")
+	}
+	// y^2 = x^3 + 7
+	y2 := new(big.Int).Mul(x, x)
+	y2.Mul(y2, x)
+	y2.Add(y2, new(big.Int).SetInt64(7))
+	y2.Mod(y2, CURVE.P)
+	y := new(big.Int).ModSqrt(y2, CURVE.P)
+	if y == nil {
+		return errors.New("Point is not on the curve.")
+	}
+	if y.Bit(0) != uint(data[0] - 0x02) {
+		y.Sub(CURVE.P, y)
+	}
+	p.X = x
+	p.Y = y
+	return nil
+}
+
+/*
+scalarBytes returns the encoding of s modulo ORDER in 32 bytes.
+*/
+func scalarBytes(s *big.Int) ([]byte) {
+	buf := make([]byte, SCALARSIZE)
+	b := Mod(s, ORDER).Bytes()
+	copy(buf[SCALARSIZE-len(b):], b)
+	return buf
+}
+
+/*
+decoder reads points and scalars from a binary encoding.
+*/
+type decoder struct {
+	data []byte
+	err error
+	grp Group
+}
+
+/*
+next returns the next n bytes, or nil if the encoding is too short.
+*/
+func (d *decoder) next(n int) ([]byte) {
+	if d.err != nil {
+		return nil
+	}
+	if len(d.data) < n {
+		d.err = errors.New("Encoding is too short.")
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+/*
+point reads an element of the group of the decoder.
+*/
+func (d *decoder) point() (Element) {
+	b := d.next(d.grp.ElementSize())
+	if b == nil {
+		return d.grp.Identity()
+	}
+	p, err := d.grp.Unmarshal(b)
+	if err != nil {
+		d.err = err
+		return d.grp.Identity()
+	}
+	return p
+}
+
+/*
+scalar reads a scalar, which must be smaller than the order of the group.
+*/
+func (d *decoder) scalar() (*big.Int) {
+	s := new(big.Int)
+	b := d.next(SCALARSIZE)
+	if b == nil {
+		return s
+	}
+	s.SetBytes(b)
+	if s.Cmp(d.grp.Scalar().Order()) >= 0 && d.err == nil {
+		d.err = errors.New("Scalar is not reduced.")
+	}
+	return s
+}
+
+/*
+finish returns the first error found while decoding, or an error if there are
+bytes left.
+*/
+func (d *decoder) finish() (error) {
+	if d.err == nil && len(d.data) != 0 {
+		d.err = errors.New("Encoding is too long.")
+	}
+	return d.err
+}
+
+/*
+appendPoints appends the compressed encoding of every point to buf.
+*/
+func appendPoints(buf []byte, points ...Element) ([]byte) {
+	for _, p := range points {
+		b, _ := p.MarshalBinary()
+		buf = append(buf, b...)
+	}
+	return buf
+}
+
+/*
+appendScalars appends the encoding of every scalar to buf.
+*/
+func appendScalars(buf []byte, scalars ...*big.Int) ([]byte) {
+	for _, s := range scalars {
+		buf = append(buf, scalarBytes(s)...)
+	}
+	return buf
+}
+
+/*
+encode appends the binary encoding of the inner product proof to buf. The final
+generators are not encoded, since they are computed by the verifier.
+*/
+func (p *proofBip) encode(buf []byte) ([]byte, error) {
+	var (
+		i int
+	)
+	k := len(p.Ls)
+	if k > MAXROUNDS || len(p.Rs) != k {
+		return nil, errors.New("Invalid number of rounds of the inner product proof.")
+	}
+	buf = append(buf, byte(k))
+	for i=0; i<k; i++ {
+		buf = appendPoints(buf, p.Ls[i], p.Rs[i])
+	}
+	return appendScalars(buf, p.A, p.B), nil
+}
+
+/*
+decode reads the inner product proof.
+*/
+func (p *proofBip) decode(d *decoder) {
+	var (
+		i int
+	)
+	b := d.next(1)
+	if b == nil {
+		return
+	}
+	k := int(b[0])
+	if k > MAXROUNDS {
+		d.err = errors.New("Invalid number of rounds of the inner product proof.")
+		return
+	}
+	p.Ls = make([]Element, k)
+	p.Rs = make([]Element, k)
+	for i=0; i<k; i++ {
+		p.Ls[i] = d.point()
+		p.Rs[i] = d.point()
+	}
+	p.A = d.scalar()
+	p.B = d.scalar()
+	p.N = int64(1) << uint(k)
+}
+
+/*
+encode appends the binary encoding of the proof to buf.
+*/
+func (p *proofBPUL) encode(buf []byte) ([]byte, error) {
+	buf = appendPoints(buf, p.A, p.S, p.T1, p.T2)
+	buf = appendScalars(buf, p.Taux, p.Mu, p.Tprime)
+	return p.Proofip.encode(buf)
+}
+
+/*
+decode reads the proof.
+*/
+func (p *proofBPUL) decode(d *decoder) {
+	p.A = d.point()
+	p.S = d.point()
+	p.T1 = d.point()
+	p.T2 = d.point()
+	p.Taux = d.scalar()
+	p.Mu = d.scalar()
+	p.Tprime = d.scalar()
+	p.Proofip.decode(d)
+}
+
+/*
+MarshalBinary returns the canonical binary encoding of the proof.
+*/
+func (p *proofBPUL) MarshalBinary() ([]byte, error) {
+	return p.encode(nil)
+}
+
+/*
+UnmarshalBinary decodes the canonical binary encoding of a proof over secp256k1.
+*/
+func (p *proofBPUL) UnmarshalBinary(data []byte) error {
+	d := &decoder{data: data, grp: SECP256K1}
+	p.decode(d)
+	return d.finish()
+}
+
+/*
+MarshalBinary returns the canonical binary encoding of the proof.
+*/
+func (p *proofBP) MarshalBinary() ([]byte, error) {
+	buf := appendPoints(nil, p.V)
+	buf, err := p.P1.encode(buf)
+	if err != nil {
+		return nil, err
+	}
+	return p.P2.encode(buf)
+}
+
+/*
+UnmarshalBinary decodes the canonical binary encoding of a proof over secp256k1.
+Both proofs for [0,2^N) must have the same number of rounds.
+*/
+func (p *proofBP) UnmarshalBinary(data []byte) error {
+	return p.decode(&decoder{data: data, grp: SECP256K1})
+}
+
+/*
+UnmarshalProof decodes the canonical binary encoding of a proof over the group
+of the parameters.
+*/
+func (zkrp *bp) UnmarshalProof(data []byte) (proofBP, error) {
+	var (
+		proof proofBP
+	)
+	err := proof.decode(&decoder{data: data, grp: zkrp.group()})
+	return proof, err
+}
+
+/*
+decode reads the proof.
+*/
+func (p *proofBP) decode(d *decoder) error {
+	p.V = d.point()
+	p.P1.decode(d)
+	p.P2.decode(d)
+	if d.err == nil && len(p.P1.Proofip.Ls) != len(p.P2.Proofip.Ls) {
+		return errors.New("Both proofs must have the same number of rounds.")
+	}
+	return d.finish()
+}
+
+/*
+encode appends the binary encoding of the weighted inner product proof to buf.
+*/
+func (p *proofWIP) encode(buf []byte) ([]byte, error) {
+	var (
+		i int
+	)
+	k := len(p.Ls)
+	if k > MAXROUNDS || len(p.Rs) != k {
+		return nil, errors.New("Invalid number of rounds of the weighted inner product proof.")
+	}
+	buf = append(buf, byte(k))
+	for i=0; i<k; i++ {
+		buf = appendPoints(buf, p.Ls[i], p.Rs[i])
+	}
+	buf = appendPoints(buf, p.A, p.B)
+	return appendScalars(buf, p.Rprime, p.Sprime, p.Dprime), nil
+}
+
+/*
+decode reads the weighted inner product proof.
+*/
+func (p *proofWIP) decode(d *decoder) {
+	var (
+		i int
+	)
+	b := d.next(1)
+	if b == nil {
+		return
+	}
+	k := int(b[0])
+	if k > MAXROUNDS {
+		d.err = errors.New("Invalid number of rounds of the weighted inner product proof.")
+		return
+	}
+	p.Ls = make([]Element, k)
+	p.Rs = make([]Element, k)
+	for i=0; i<k; i++ {
+		p.Ls[i] = d.point()
+		p.Rs[i] = d.point()
+	}
+	p.A = d.point()
+	p.B = d.point()
+	p.Rprime = d.scalar()
+	p.Sprime = d.scalar()
+	p.Dprime = d.scalar()
+}
+
+/*
+MarshalBinary returns the canonical binary encoding of the Bulletproofs+ proof.
+*/
+func (p *proofBPP) MarshalBinary() ([]byte, error) {
+	var (
+		err error
+	)
+	buf := appendPoints(nil, p.V)
+	for _, ul := range []*proofBPPUL{&p.P1, &p.P2} {
+		buf = appendPoints(buf, ul.A)
+		buf, err = ul.Proofwip.encode(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+/*
+UnmarshalBinary decodes the canonical binary encoding of a Bulletproofs+ proof
+over secp256k1.
+*/
+func (p *proofBPP) UnmarshalBinary(data []byte) error {
+	return p.decode(&decoder{data: data, grp: SECP256K1})
+}
+
+/*
+UnmarshalProof decodes the canonical binary encoding of a Bulletproofs+ proof over
+the group of the parameters.
+*/
+func (zkrp *bpp) UnmarshalProof(data []byte) (proofBPP, error) {
+	var (
+		proof proofBPP
+	)
+	err := proof.decode(&decoder{data: data, grp: zkrp.group()})
+	return proof, err
+}
+
+/*
+decode reads the Bulletproofs+ proof.
+*/
+func (p *proofBPP) decode(d *decoder) error {
+	p.V = d.point()
+	p.P1.A = d.point()
+	p.P1.Proofwip.decode(d)
+	p.P2.A = d.point()
+	p.P2.Proofwip.decode(d)
+	if d.err == nil && len(p.P1.Proofwip.Ls) != len(p.P2.Proofwip.Ls) {
+		return errors.New("Both proofs must have the same number of rounds.")
+	}
+	return d.finish()
+}
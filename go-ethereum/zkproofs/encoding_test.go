@@ -0,0 +1,169 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+	"encoding/json"
+)
+
+/*
+Test the round trip of the compressed encoding of points, including the point at
+infinity and points with both parities of Y.
+*/
+func TestPointEncoding(t *testing.T) {
+	var (
+		i int64
+	)
+	for i=0; i<8; i++ {
+		p := new(p256).ScalarBaseMult(new(big.Int).SetInt64(i))
+		b, _ := p.MarshalBinary()
+		if len(b) != POINTSIZE {
+			t.Errorf("Assert failure: expected %d bytes, actual: %d", POINTSIZE, len(b))
+		}
+		q := new(p256)
+		err := q.UnmarshalBinary(b)
+		if err != nil || !q.Equals(p) {
+			t.Errorf("Assert failure: round trip failed for %d: %v", i, err)
+		}
+	}
+}
+
+/*
+Test that non-canonical and off-curve encodings of points are rejected.
+*/
+func TestPointEncodingReject(t *testing.T) {
+	var (
+		x int64
+	)
+	p := new(p256).ScalarBaseMult(new(big.Int).SetInt64(5))
+	b, _ := p.MarshalBinary()
+	q := new(p256)
+	if q.UnmarshalBinary(b[1:]) == nil {
+		t.Errorf("Assert failure: expected error for a short encoding")
+	}
+	b[0] = 0x04
+	if q.UnmarshalBinary(b) == nil {
+		t.Errorf("Assert failure: expected error for a wrong prefix")
+	}
+	// Infinity with a non-zero X
+	b[0] = 0x00
+	if q.UnmarshalBinary(b) == nil {
+		t.Errorf("Assert failure: expected error for a wrong encoding of infinity")
+	}
+	// X not reduced modulo P
+	b[0] = 0x02
+	copy(b[1:], CURVE.P.Bytes())
+	if q.UnmarshalBinary(b) == nil {
+		t.Errorf("Assert failure: expected error for a non-reduced coordinate")
+	}
+	// X such that x^3 + 7 is not a square modulo P
+	x = 1
+	for {
+		y2 := new(big.Int).Exp(new(big.Int).SetInt64(x), new(big.Int).SetInt64(3), CURVE.P)
+		y2.Add(y2, new(big.Int).SetInt64(7))
+		if new(big.Int).ModSqrt(y2, CURVE.P) == nil {
+			break
+		}
+		x++
+	}
+	b = scalarBytes(new(big.Int).SetInt64(x))
+	b = append([]byte{0x02}, b...)
+	if q.UnmarshalBinary(b) == nil {
+		t.Errorf("Assert failure: expected error for a point not on the curve")
+	}
+}
+
+/*
+Test the round trip of the binary encoding of a range proof and its size, which
+must be smaller than the size of the JSON encoding.
+*/
+func TestBulletproofsEncoding(t *testing.T) {
+	var (
+		zkrp bp
+		decoded proofBP
+	)
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(42))
+	b, err := proof.MarshalBinary()
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	// V || 2 x (4 points, 3 scalars, k, 2k points, 2 scalars)
+	k := len(proof.P1.Proofip.Ls)
+	size := POINTSIZE + 2 * (4 * POINTSIZE + 5 * SCALARSIZE + 1 + 2 * k * POINTSIZE)
+	if len(b) != size {
+		t.Errorf("Assert failure: expected %d bytes, actual: %d", size, len(b))
+	}
+	j, _ := json.Marshal(proof)
+	if len(b) >= len(j) {
+		t.Errorf("Assert failure: binary encoding (%d) is not smaller than JSON (%d)", len(b), len(j))
+	}
+	err = decoded.UnmarshalBinary(b)
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	ok, _ := zkrp.Verify(decoded)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	c, _ := decoded.MarshalBinary()
+	if string(c) != string(b) {
+		t.Errorf("Assert failure: encoding is not canonical")
+	}
+}
+
+/*
+Test that the decoding of a range proof rejects wrong lengths and non-canonical scalars.
+*/
+func TestBulletproofsEncodingReject(t *testing.T) {
+	var (
+		zkrp bp
+		decoded proofBP
+	)
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(42))
+	b, _ := proof.MarshalBinary()
+	if decoded.UnmarshalBinary(b[:len(b)-1]) == nil {
+		t.Errorf("Assert failure: expected error for a short encoding")
+	}
+	if decoded.UnmarshalBinary(append(b, 0)) == nil {
+		t.Errorf("Assert failure: expected error for a long encoding")
+	}
+	// Taux of the first proof replaced by ORDER
+	c := append([]byte{}, b...)
+	offset := POINTSIZE + 4 * POINTSIZE
+	copy(c[offset:offset+SCALARSIZE], ORDER.Bytes())
+	if decoded.UnmarshalBinary(c) == nil {
+		t.Errorf("Assert failure: expected error for a non-reduced scalar")
+	}
+	// Number of rounds of the first proof increased by one
+	c = append([]byte{}, b...)
+	offset = POINTSIZE + 4 * POINTSIZE + 3 * SCALARSIZE
+	c[offset]++
+	if decoded.UnmarshalBinary(c) == nil {
+		t.Errorf("Assert failure: expected error for a wrong number of rounds")
+	}
+	// Proofs with different number of rounds
+	proof.P2.Proofip.Ls = proof.P2.Proofip.Ls[1:]
+	proof.P2.Proofip.Rs = proof.P2.Proofip.Rs[1:]
+	c, _ = proof.MarshalBinary()
+	if decoded.UnmarshalBinary(c) == nil {
+		t.Errorf("Assert failure: expected error for proofs of different sizes")
+	}
+}
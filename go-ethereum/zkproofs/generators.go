@@ -0,0 +1,234 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the derivation of the Bulletproofs generators. Except for G,
+which is the base point of the curve, all of them are nothing-up-my-sleeve (NUMS)
+points obtained by hashing public seeds with MapToGroup:
+
+	H     = MapToGroup(SEEDH)
+	U     = MapToGroup(SEEDU)
+	Gg[i] = MapToGroup(SEEDH + "g" + i)
+	Hh[i] = MapToGroup(SEEDH + "h" + i)
+
+where i is written in decimal. Nobody knows the discrete logarithm of any of
+them with respect to the others. Since each generator only depends on its index,
+the generators for N are the first N generators for any larger N. The same
+derivation is used for every group, with the MapToGroup of the group. The derived
+generators are cached per group, so that they are computed only once.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+)
+
+var (
+	numsCache struct {
+		sync.Mutex
+		groups map[string]*numsGenerators
+	}
+)
+
+/*
+numsGenerators contains the generators derived for a group.
+*/
+type numsGenerators struct {
+	H Element
+	U Element
+	Gg []Element
+	Hh []Element
+}
+
+/*
+copyPoint returns a copy of p, so that the cached generators cannot be modified
+by the caller.
+*/
+func copyPoint(p *p256) (*p256) {
+	return &p256{X: new(big.Int).Set(p.X), Y: new(big.Int).Set(p.Y)}
+}
+
+/*
+copyElement returns a copy of the element. Only p256 points may be modified in
+place, the elements of the other groups are returned as they are.
+*/
+func copyElement(e Element) (Element) {
+	if p, ok := e.(*p256); ok {
+		return copyPoint(p)
+	}
+	return e
+}
+
+/*
+cachedGenerators returns the cache of the group, deriving H and U if needed. It
+must be called with the lock held.
+*/
+func cachedGenerators(grp Group) (*numsGenerators, error) {
+	var (
+		err error
+	)
+	if numsCache.groups == nil {
+		numsCache.groups = make(map[string]*numsGenerators)
+	}
+	c := numsCache.groups[grp.Name()]
+	if c != nil {
+		return c, nil
+	}
+	c = new(numsGenerators)
+	c.H, err = grp.MapToGroup(SEEDH)
+	if err != nil {
+		return nil, err
+	}
+	c.U, err = grp.MapToGroup(SEEDU)
+	if err != nil {
+		return nil, err
+	}
+	numsCache.groups[grp.Name()] = c
+	return c, nil
+}
+
+/*
+groupHU returns the generators H and U of the group.
+*/
+func groupHU(grp Group) (Element, Element, error) {
+	numsCache.Lock()
+	defer numsCache.Unlock()
+	c, err := cachedGenerators(grp)
+	if err != nil {
+		return nil, nil, err
+	}
+	return copyElement(c.H), copyElement(c.U), nil
+}
+
+/*
+groupGenerators returns the vectors of generators Gg and Hh of the group of size n.
+*/
+func groupGenerators(grp Group, n int64) ([]Element, []Element, error) {
+	var (
+		i int64
+	)
+	if n < 0 {
+		return nil, nil, errors.New("n must be non-negative")
+	}
+	numsCache.Lock()
+	defer numsCache.Unlock()
+	c, err := cachedGenerators(grp)
+	if err != nil {
+		return nil, nil, err
+	}
+	i = int64(len(c.Gg))
+	for i<n {
+		g, err := grp.MapToGroup(SEEDH+"g"+strconv.FormatInt(i, 10))
+		if err != nil {
+			return nil, nil, err
+		}
+		h, err := grp.MapToGroup(SEEDH+"h"+strconv.FormatInt(i, 10))
+		if err != nil {
+			return nil, nil, err
+		}
+		c.Gg = append(c.Gg, g)
+		c.Hh = append(c.Hh, h)
+		i = i + 1
+	}
+	gg := make([]Element, n)
+	hh := make([]Element, n)
+	i = 0
+	for i<n {
+		gg[i] = copyElement(c.Gg[i])
+		hh[i] = copyElement(c.Hh[i])
+		i = i + 1
+	}
+	return gg, hh, nil
+}
+
+/*
+numsHU returns the generators H and U of secp256k1.
+*/
+func numsHU() (*p256, *p256, error) {
+	H, U, err := groupHU(SECP256K1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return toP256(H), toP256(U), nil
+}
+
+/*
+Generators returns the vectors of generators Gg and Hh of secp256k1 of size n.
+*/
+func Generators(n int64) ([]*p256, []*p256, error) {
+	gg, hh, err := groupGenerators(SECP256K1, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p256Elements(gg), p256Elements(hh), nil
+}
+
+/*
+equalPoints returns true if and only if both vectors have the same points.
+*/
+func equalPoints(a, b []*p256) bool {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return false
+	}
+	for i=0; i<len(a); i++ {
+		if a[i] == nil || b[i] == nil || !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+CheckGenerators returns an error unless the generators of the parameters are
+exactly the ones given by the derivation above. It is used when the parameters
+are loaded, since they may come from an untrusted source.
+*/
+func (zkrp *bp) CheckGenerators() (error) {
+	if zkrp.N <= 0 || zkrp.N & (zkrp.N - 1) != 0 {
+		return errors.New("N must be a power of 2")
+	}
+	grp := zkrp.group()
+	H, U, err := groupHU(grp)
+	if err != nil {
+		return err
+	}
+	gg, hh, err := groupGenerators(grp, zkrp.N)
+	if err != nil {
+		return err
+	}
+	G := grp.Generator()
+	if zkrp.G == nil || !zkrp.G.Equals(G) || zkrp.H == nil || !zkrp.H.Equals(H) {
+		return errors.New("Generators G and H do not match the derivation.")
+	}
+	if !equalElements(zkrp.Gg, gg) || !equalElements(zkrp.Hh, hh) {
+		return errors.New("Generators Gg and Hh do not match the derivation.")
+	}
+	zkip := zkrp.Zkip
+	if zkip.N != zkrp.N || zkip.Uu == nil || !zkip.Uu.Equals(U) || zkip.H == nil || !zkip.H.Equals(H) {
+		return errors.New("Generators of the inner product do not match the derivation.")
+	}
+	if !equalElements(zkip.Gg, gg) || !equalElements(zkip.Hh, hh) {
+		return errors.New("Generators of the inner product do not match the derivation.")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+/*
+Test that the generators follow the documented derivation, with decimal indexes,
+and that the generators for N are a prefix of the generators for 2N.
+*/
+func TestGenerators(t *testing.T) {
+	gg, hh, _ := Generators(16)
+	g10, _ := MapToGroup(SEEDH+"g10")
+	h10, _ := MapToGroup(SEEDH+"h10")
+	if !gg[10].Equals(g10) || !hh[10].Equals(h10) {
+		t.Errorf("Assert failure: generators do not follow the derivation")
+	}
+	gg8, hh8, _ := Generators(8)
+	if !equalPoints(gg8, gg[:8]) || !equalPoints(hh8, hh[:8]) {
+		t.Errorf("Assert failure: generators for 8 are not a prefix of generators for 16")
+	}
+	// The caller cannot modify the cache
+	gg8[0].X.SetInt64(1)
+	gg8, _, _ = Generators(8)
+	if !gg8[0].Equals(gg[0]) {
+		t.Errorf("Assert failure: cached generators were modified")
+	}
+}
+
+/*
+Test that CheckGenerators rejects parameters with a modified generator.
+*/
+func TestCheckGenerators(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(0, 16)
+	err := zkrp.CheckGenerators()
+	if err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	zkrp.Hh[3] = zkrp.Gg[3]
+	err = zkrp.CheckGenerators()
+	if err == nil {
+		t.Errorf("Assert failure: expected error for a modified generator")
+	}
+	zkrp.Setup(0, 16)
+	zkrp.Zkip.Uu = zkrp.H
+	err = zkrp.CheckGenerators()
+	if err == nil {
+		t.Errorf("Assert failure: expected error for a modified generator U")
+	}
+}
+
+/*
+Test SetupPre with the precomputed parameters in setup.dat, which has 8 generators.
+*/
+func TestSetupPre(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	err := zkrp.SetupPre(18, 30, "setup.dat")
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	if zkrp.N != 4 || len(zkrp.Gg) != 4 || zkrp.Zkip.Uu == nil {
+		t.Errorf("Assert failure: expected N = 4, actual: %d", zkrp.N)
+	}
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(20))
+	ok, _ := zkrp.Verify(proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	err = zkrp.SetupPre(0, 1000, "setup.dat")
+	if err == nil {
+		t.Errorf("Assert failure: expected error for not enough generators")
+	}
+}
+
+/*
+Test that loading parameters whose generators were tampered with fails.
+*/
+func TestLoadTamperedParams(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	dir, err := ioutil.TempDir("", "zkproofs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	zkrp.Setup(0, 16)
+	zkrp.Gg[0] = new(p256).ScalarBaseMult(new(big.Int).SetInt64(2))
+	zkrp.Zkip.Gg = zkrp.Gg
+	store := NewDirStore(dir)
+	store.SaveParams("setup.dat", &zkrp)
+	_, err = LoadParamFromDisk(filepath.Join(dir, "setup.dat"))
+	if err == nil {
+		t.Errorf("Assert failure: expected error for tampered generators")
+	}
+}
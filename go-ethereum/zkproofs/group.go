@@ -0,0 +1,466 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the abstraction of the prime order group used by Bulletproofs,
+so that the same prover and verifier work over different elliptic curves. A Group
+provides the operations on its Elements, and the field of exponents modulo the
+order of the group, which is given by Scalar. The available groups are:
+
+	SECP256K1  secp256k1, whose elements are p256 points (group_secp256k1.go)
+	NISTP256   NIST P-256 from crypto/elliptic (group_nist.go)
+	BN256G1    G1 of the bn256 pairing (group_bn256.go)
+
+Following the rest of the package, the group operation is written as a product
+in the comments, e.g. g^a.h^b, although Add computes the sum of curve points.
+Elements of different groups must not be mixed: the operations of a group panic
+when they receive an element of another group.
+*/
+
+package zkproofs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strconv"
+)
+
+var (
+	SECP256K1 Group = secp256k1Group{}
+	NISTP256 Group = newNistGroup()
+	BN256G1 Group = bn256Group{}
+)
+
+/*
+Element is an element of a group.
+*/
+type Element interface {
+	// IsZero returns true if and only if the element is the identity
+	IsZero() bool
+	// Equals returns true if and only if both elements are equal
+	Equals(a Element) bool
+	// MarshalBinary returns the compressed encoding in Group.ElementSize bytes
+	MarshalBinary() ([]byte, error)
+	String() string
+}
+
+/*
+Scalar is the field of exponents of a group, namely the integers modulo the order
+q of the group. Exponents are big integers, which are reduced to [0,q).
+*/
+type Scalar interface {
+	// Order returns q
+	Order() *big.Int
+	// Random returns a uniformly random exponent
+	Random() (*big.Int, error)
+	// Reduce returns x mod q
+	Reduce(x *big.Int) *big.Int
+	// Inverse returns x^-1 mod q
+	Inverse(x *big.Int) *big.Int
+}
+
+/*
+Group is a prime order group in which the discrete logarithm problem is hard.
+*/
+type Group interface {
+	// Name identifies the group, e.g. in the cache of generators
+	Name() string
+	Scalar() Scalar
+	Identity() Element
+	Generator() Element
+	Add(a, b Element) Element
+	Neg(a Element) Element
+	// ScalarMult and MultiExp run in variable time, then the exponents must be public
+	ScalarMult(a Element, n *big.Int) Element
+	MultiExp(a []Element, b []*big.Int) (Element, error)
+	// ScalarMultCT and MultiExpCT must be used when the exponents are secret
+	ScalarMultCT(a Element, n *big.Int) Element
+	MultiExpCT(a []Element, b []*big.Int) (Element, error)
+	// MapToGroup hashes m to an element with no known discrete logarithm
+	MapToGroup(m string) (Element, error)
+	// Unmarshal decodes the encoding given by MarshalBinary, which must be canonical
+	Unmarshal(data []byte) (Element, error)
+	// Contains returns true if and only if e is a valid element of the group
+	Contains(e Element) bool
+	ElementSize() int
+}
+
+/*
+vectorGroup is implemented by the groups that compute the vector operations of
+the inner product argument faster than one element at a time.
+*/
+type vectorGroup interface {
+	foldElements(lo, hi []Element, a, b *big.Int) []Element
+	mulElements(points []Element, scalars []*big.Int) []Element
+}
+
+/*
+scalarField is the field of the integers modulo q, which implements Scalar.
+Besides the methods of Scalar, it provides the vector operations used by the
+provers and verifiers.
+*/
+type scalarField struct {
+	q *big.Int
+}
+
+/*
+Order returns q.
+*/
+func (f *scalarField) Order() *big.Int {
+	return f.q
+}
+
+/*
+Random returns a uniformly random element of [0,q).
+*/
+func (f *scalarField) Random() (*big.Int, error) {
+	return rand.Int(rand.Reader, f.q)
+}
+
+/*
+Reduce returns x mod q.
+*/
+func (f *scalarField) Reduce(x *big.Int) *big.Int {
+	return Mod(x, f.q)
+}
+
+/*
+Inverse returns x^-1 mod q.
+*/
+func (f *scalarField) Inverse(x *big.Int) *big.Int {
+	return ModInverse(x, f.q)
+}
+
+/*
+field returns the scalar field of the group g.
+*/
+func field(g Group) *scalarField {
+	return &scalarField{q: g.Scalar().Order()}
+}
+
+/*
+vectorAdd computes vector addition componentwisely.
+*/
+func (f *scalarField) vectorAdd(a, b []*big.Int) ([]*big.Int, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := make([]*big.Int, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = Mod(Add(a[i], b[i]), f.q)
+	}
+	return result, nil
+}
+
+/*
+vectorSub computes vector subtraction componentwisely.
+*/
+func (f *scalarField) vectorSub(a, b []*big.Int) ([]*big.Int, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := make([]*big.Int, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = Mod(Sub(a[i], b[i]), f.q)
+	}
+	return result, nil
+}
+
+/*
+vectorScalarMul multiplies every component of a by b.
+*/
+func (f *scalarField) vectorScalarMul(a []*big.Int, b *big.Int) ([]*big.Int) {
+	var (
+		i int
+	)
+	result := make([]*big.Int, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = Mod(Multiply(a[i], b), f.q)
+	}
+	return result
+}
+
+/*
+vectorMul computes vector multiplication componentwisely.
+*/
+func (f *scalarField) vectorMul(a, b []*big.Int) ([]*big.Int, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := make([]*big.Int, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = Mod(Multiply(a[i], b[i]), f.q)
+	}
+	return result, nil
+}
+
+/*
+scalarProduct returns the inner product between a and b.
+*/
+func (f *scalarField) scalarProduct(a, b []*big.Int) (*big.Int, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := new(big.Int).SetInt64(0)
+	for i=0; i<len(a); i++ {
+		result.Add(result, Multiply(a[i], b[i]))
+		result = Mod(result, f.q)
+	}
+	return result, nil
+}
+
+/*
+powerOf returns the vector of the first n powers of x.
+*/
+func (f *scalarField) powerOf(x *big.Int, n int64) ([]*big.Int) {
+	var (
+		i int64
+	)
+	result := make([]*big.Int, n)
+	current := new(big.Int).SetInt64(1)
+	for i=0; i<n; i++ {
+		result[i] = current
+		current = Mod(Multiply(current, x), f.q)
+	}
+	return result
+}
+
+/*
+ipScalars computes the exponents of the final generators of the inner product
+argument, as described in IPScalars.
+*/
+func (f *scalarField) ipScalars(x []*big.Int, n int64) ([]*big.Int, error) {
+	var (
+		i int64
+		j int
+	)
+	logn := len(x)
+	if int64(1) << uint(logn) != n {
+		return nil, errors.New("Number of challenges must be equal to log(n).")
+	}
+	xinv := make([]*big.Int, logn)
+	for j=0; j<logn; j++ {
+		xinv[j] = ModInverse(x[j], f.q)
+	}
+	s := make([]*big.Int, n)
+	for i=0; i<n; i++ {
+		s[i] = new(big.Int).SetInt64(1)
+		for j=0; j<logn; j++ {
+			if (i >> uint(logn-1-j)) & 1 == 1 {
+				s[i] = Mod(Multiply(s[i], x[j]), f.q)
+			} else {
+				s[i] = Mod(Multiply(s[i], xinv[j]), f.q)
+			}
+		}
+	}
+	return s, nil
+}
+
+/*
+foldElements computes lo[i]^a.hi[i]^b for each i, which is the folding of the
+generators in each round of the inner product argument.
+*/
+func foldElements(g Group, lo, hi []Element, a, b *big.Int) ([]Element) {
+	var (
+		i int
+	)
+	if vg, ok := g.(vectorGroup); ok {
+		return vg.foldElements(lo, hi, a, b)
+	}
+	result := make([]Element, len(lo))
+	for i=0; i<len(lo); i++ {
+		result[i] = g.Add(g.ScalarMult(lo[i], a), g.ScalarMult(hi[i], b))
+	}
+	return result
+}
+
+/*
+mulElements computes points[i]^scalars[i] for each i.
+*/
+func mulElements(g Group, points []Element, scalars []*big.Int) ([]Element) {
+	var (
+		i int
+	)
+	if vg, ok := g.(vectorGroup); ok {
+		return vg.mulElements(points, scalars)
+	}
+	result := make([]Element, len(points))
+	for i=0; i<len(points); i++ {
+		result[i] = g.ScalarMult(points[i], scalars[i])
+	}
+	return result
+}
+
+/*
+multiExpGeneric computes prod_i a[i]^b[i] with one scalar multiplication per element.
+It is used by the groups that have no multi-exponentiation, and it runs in constant
+time if ScalarMult does.
+*/
+func multiExpGeneric(g Group, mult func(Element, *big.Int) Element, a []Element, b []*big.Int) (Element, error) {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	result := g.Identity()
+	for i=0; i<len(a); i++ {
+		result = g.Add(result, mult(a[i], b[i]))
+	}
+	return result, nil
+}
+
+/*
+groupOf returns the group of the element, or an error if the element is nil or
+does not belong to the groups of this package.
+*/
+func groupOf(e Element) (Group, error) {
+	switch e.(type) {
+	case *p256:
+		return SECP256K1, nil
+	case *nistPoint:
+		return NISTP256, nil
+	case *bnPoint:
+		return BN256G1, nil
+	}
+	return nil, errors.New("Element does not belong to a known group.")
+}
+
+/*
+equalElements returns true if and only if both vectors have the same elements.
+*/
+func equalElements(a, b []Element) bool {
+	var (
+		i int
+	)
+	if len(a) != len(b) {
+		return false
+	}
+	for i=0; i<len(a); i++ {
+		if a[i] == nil || b[i] == nil || !a[i].Equals(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+weierstrass is the curve y^2 = x^3 + a.x + b over the prime field of size p,
+where p = 3 mod 4. It provides the compressed encoding and the hash to the curve
+of the groups that are not implemented in this package.
+*/
+type weierstrass struct {
+	p, a, b *big.Int
+}
+
+/*
+rhs returns x^3 + a.x + b mod p.
+*/
+func (c *weierstrass) rhs(x *big.Int) (*big.Int) {
+	r := new(big.Int).Mul(x, x)
+	r.Add(r, c.a)
+	r.Mul(r, x)
+	r.Add(r, c.b)
+	return r.Mod(r, c.p)
+}
+
+/*
+compress returns the encoding of (x, y) in 33 bytes, namely 0x02 or 0x03, according
+to the parity of y, followed by x. The identity is encoded as 33 zero bytes.
+*/
+func (c *weierstrass) compress(x, y *big.Int, infinity bool) ([]byte) {
+	buf := make([]byte, POINTSIZE)
+	if infinity {
+		return buf
+	}
+	buf[0] = 0x02 + byte(y.Bit(0))
+	b := x.Bytes()
+	copy(buf[POINTSIZE-len(b):], b)
+	return buf
+}
+
+/*
+decompress decodes the encoding given by compress. It returns nil coordinates for
+the identity, and an error if the encoding is not canonical or if the point is not
+on the curve.
+*/
+func (c *weierstrass) decompress(data []byte) (*big.Int, *big.Int, error) {
+	var (
+		i int
+	)
+	if len(data) != POINTSIZE {
+		return nil, nil, errors.New("Point encoding must have 33 bytes.")
+	}
+	if data[0] == 0 {
+		for i=1; i<POINTSIZE; i++ {
+			if data[i] != 0 {
+				return nil, nil, errors.New("Invalid encoding of the point at infinity.")
+			}
+		}
+		return nil, nil, nil
+	}
+	if data[0] != 0x02 && data[0] != 0x03 {
+		return nil, nil, errors.New("Invalid point prefix.")
+	}
+	x := new(big.Int).SetBytes(data[1:])
+	if x.Cmp(c.p) >= 0 {
+		return nil, nil, errors.New("Point coordinate is not reduced.")
+	}
+	y := new(big.Int).ModSqrt(c.rhs(x), c.p)
+	if y == nil {
+		return nil, nil, errors.New("Point is not on the curve.")
+	}
+	if y.Bit(0) != uint(data[0] - 0x02) {
+		y.Sub(c.p, y)
+	}
+	return x, y, nil
+}
+
+/*
+mapToCurve hashes m to a point of the curve as MapToGroup does for secp256k1.
+*/
+func (c *weierstrass) mapToCurve(m string) (*big.Int, *big.Int, error) {
+	var (
+		i int
+		buffer bytes.Buffer
+	)
+	for i=0; i<256; i++ {
+		buffer.Reset()
+		buffer.WriteString(strconv.Itoa(i))
+		buffer.WriteString(m)
+		x, _ := HashToInt(buffer)
+		x = Mod(x, c.p)
+		y := new(big.Int).ModSqrt(c.rhs(x), c.p)
+		if y != nil && (x.Sign() != 0 || y.Sign() != 0) {
+			return x, y, nil
+		}
+	}
+	return nil, nil, errors.New("Failed to Hash-to-point.")
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the group G1 of the bn256 pairing, which is the curve
+y^2 = x^3 + 3 used by the precompiled contracts of Ethereum. Note that bn256 has
+no constant-time scalar multiplication, then ScalarMultCT is the same as ScalarMult
+and the prover is not protected against timing attacks over this group.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+var (
+	bnField = &scalarField{q: bn256.Order}
+	bnCurve = &weierstrass{p: bn256.P, a: new(big.Int).SetInt64(0), b: new(big.Int).SetInt64(3)}
+)
+
+/*
+bnPoint is an element of G1. Every bnPoint is created in affine coordinates, so that
+reading it never modifies the underlying bn256.G1.
+*/
+type bnPoint struct {
+	p *bn256.G1
+}
+
+/*
+bn256Group implements Group.
+*/
+type bn256Group struct{}
+
+/*
+newBnPoint returns the element p, which is converted to affine coordinates.
+*/
+func newBnPoint(p *bn256.G1) (*bnPoint) {
+	if !p.IsZero() {
+		p.Marshal()
+	}
+	return &bnPoint{p: p}
+}
+
+/*
+coordinates returns the affine coordinates of the element, which is not the
+point at infinity.
+*/
+func (e *bnPoint) coordinates() (*big.Int, *big.Int) {
+	b := e.p.Marshal()
+	return new(big.Int).SetBytes(b[:32]), new(big.Int).SetBytes(b[32:])
+}
+
+func (e *bnPoint) IsZero() bool {
+	return e.p.IsZero()
+}
+
+func (e *bnPoint) Equals(a Element) bool {
+	b, ok := a.(*bnPoint)
+	if !ok || b == nil {
+		return false
+	}
+	if e.IsZero() || b.IsZero() {
+		return e.IsZero() && b.IsZero()
+	}
+	x1, y1 := e.coordinates()
+	x2, y2 := b.coordinates()
+	return x1.Cmp(x2) == 0 && y1.Cmp(y2) == 0
+}
+
+func (e *bnPoint) MarshalBinary() ([]byte, error) {
+	if e.IsZero() {
+		return bnCurve.compress(nil, nil, true), nil
+	}
+	x, y := e.coordinates()
+	return bnCurve.compress(x, y, false), nil
+}
+
+func (e *bnPoint) String() string {
+	if e.IsZero() {
+		return "bn256.G1(infinity)"
+	}
+	x, y := e.coordinates()
+	return "bn256.G1(" + x.String() + "," + y.String() + ")"
+}
+
+/*
+toG1 returns the bn256 point of the element, which must belong to G1.
+*/
+func toG1(a Element) (*bn256.G1) {
+	e, ok := a.(*bnPoint)
+	if !ok {
+		panic("Element does not belong to bn256 G1.")
+	}
+	return e.p
+}
+
+func (bn256Group) Name() string {
+	return "bn256G1"
+}
+
+func (bn256Group) Scalar() Scalar {
+	return bnField
+}
+
+func (bn256Group) Identity() Element {
+	return newBnPoint(new(bn256.G1).SetInfinity())
+}
+
+func (bn256Group) Generator() Element {
+	return newBnPoint(new(bn256.G1).ScalarBaseMult(new(big.Int).SetInt64(1)))
+}
+
+func (bn256Group) Add(a, b Element) Element {
+	return newBnPoint(new(bn256.G1).Add(toG1(a), toG1(b)))
+}
+
+func (bn256Group) Neg(a Element) Element {
+	return newBnPoint(new(bn256.G1).Neg(toG1(a)))
+}
+
+func (bn256Group) ScalarMult(a Element, n *big.Int) Element {
+	return newBnPoint(new(bn256.G1).ScalarMult(toG1(a), bnField.Reduce(n)))
+}
+
+func (g bn256Group) MultiExp(a []Element, b []*big.Int) (Element, error) {
+	return multiExpGeneric(g, g.ScalarMult, a, b)
+}
+
+func (g bn256Group) ScalarMultCT(a Element, n *big.Int) Element {
+	return g.ScalarMult(a, n)
+}
+
+func (g bn256Group) MultiExpCT(a []Element, b []*big.Int) (Element, error) {
+	return multiExpGeneric(g, g.ScalarMultCT, a, b)
+}
+
+func (g bn256Group) MapToGroup(m string) (Element, error) {
+	x, y, err := bnCurve.mapToCurve(m)
+	if err != nil {
+		return nil, err
+	}
+	return g.fromCoordinates(x, y)
+}
+
+func (g bn256Group) Unmarshal(data []byte) (Element, error) {
+	x, y, err := bnCurve.decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	if x == nil {
+		return g.Identity(), nil
+	}
+	return g.fromCoordinates(x, y)
+}
+
+/*
+fromCoordinates returns the element with affine coordinates (x, y), which must be
+on the curve.
+*/
+func (bn256Group) fromCoordinates(x, y *big.Int) (Element, error) {
+	buf := make([]byte, 64)
+	bx := x.Bytes()
+	by := y.Bytes()
+	copy(buf[32-len(bx):32], bx)
+	copy(buf[64-len(by):], by)
+	p, ok := new(bn256.G1).Unmarshal(buf)
+	if !ok {
+		return nil, errors.New("Point is not on the curve.")
+	}
+	return &bnPoint{p: p}, nil
+}
+
+/*
+Contains only checks that the element is not empty, since a bn256.G1 can only be
+obtained from the group operations or from Unmarshal, which checks the curve equation.
+*/
+func (bn256Group) Contains(e Element) bool {
+	p, ok := e.(*bnPoint)
+	return ok && p != nil && p.p != nil
+}
+
+func (bn256Group) ElementSize() int {
+	return POINTSIZE
+}
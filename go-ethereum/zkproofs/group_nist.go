@@ -0,0 +1,205 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the group NIST P-256, which is implemented by crypto/elliptic.
+The scalar multiplication of crypto/elliptic for P-256 runs in constant time, then
+it is used for both public and secret exponents.
+*/
+
+package zkproofs
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+/*
+nistPoint is a point of NIST P-256 in affine coordinates. The point at infinity
+has nil coordinates.
+*/
+type nistPoint struct {
+	X, Y *big.Int
+}
+
+/*
+nistGroup implements Group.
+*/
+type nistGroup struct {
+	curve elliptic.Curve
+	field *scalarField
+	w *weierstrass
+}
+
+/*
+newNistGroup returns the group NIST P-256.
+*/
+func newNistGroup() (*nistGroup) {
+	params := elliptic.P256().Params()
+	return &nistGroup{
+		curve: elliptic.P256(),
+		field: &scalarField{q: params.N},
+		w: &weierstrass{p: params.P, a: new(big.Int).SetInt64(-3), b: params.B},
+	}
+}
+
+func (p *nistPoint) IsZero() bool {
+	return p.X == nil || p.Y == nil
+}
+
+func (p *nistPoint) Equals(e Element) bool {
+	a, ok := e.(*nistPoint)
+	if !ok || a == nil {
+		return false
+	}
+	if p.IsZero() || a.IsZero() {
+		return p.IsZero() && a.IsZero()
+	}
+	return p.X.Cmp(a.X) == 0 && p.Y.Cmp(a.Y) == 0
+}
+
+func (p *nistPoint) MarshalBinary() ([]byte, error) {
+	return NISTP256.(*nistGroup).w.compress(p.X, p.Y, p.IsZero()), nil
+}
+
+func (p *nistPoint) String() string {
+	if p.IsZero() {
+		return "P256(infinity)"
+	}
+	return "P256(" + p.X.String() + "," + p.Y.String() + ")"
+}
+
+/*
+point returns the point of the element, which must belong to NIST P-256.
+*/
+func (g *nistGroup) point(a Element) (*nistPoint) {
+	p, ok := a.(*nistPoint)
+	if !ok {
+		panic("Element does not belong to NIST P-256.")
+	}
+	return p
+}
+
+/*
+newPoint returns the point (x, y), where (0, 0) is the point at infinity for
+crypto/elliptic.
+*/
+func (g *nistGroup) newPoint(x, y *big.Int) (*nistPoint) {
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return &nistPoint{}
+	}
+	return &nistPoint{X: x, Y: y}
+}
+
+func (g *nistGroup) Name() string {
+	return "P-256"
+}
+
+func (g *nistGroup) Scalar() Scalar {
+	return g.field
+}
+
+func (g *nistGroup) Identity() Element {
+	return &nistPoint{}
+}
+
+func (g *nistGroup) Generator() Element {
+	params := g.curve.Params()
+	return &nistPoint{X: params.Gx, Y: params.Gy}
+}
+
+func (g *nistGroup) Add(a, b Element) Element {
+	p := g.point(a)
+	q := g.point(b)
+	if p.IsZero() {
+		return q
+	}
+	if q.IsZero() {
+		return p
+	}
+	if p.X.Cmp(q.X) == 0 && p.Y.Cmp(q.Y) != 0 {
+		// q = -p
+		return &nistPoint{}
+	}
+	return g.newPoint(g.curve.Add(p.X, p.Y, q.X, q.Y))
+}
+
+func (g *nistGroup) Neg(a Element) Element {
+	p := g.point(a)
+	if p.IsZero() {
+		return p
+	}
+	return &nistPoint{X: p.X, Y: new(big.Int).Sub(g.curve.Params().P, p.Y)}
+}
+
+func (g *nistGroup) ScalarMult(a Element, n *big.Int) Element {
+	p := g.point(a)
+	if p.IsZero() {
+		return p
+	}
+	k := g.field.Reduce(n)
+	if k.Sign() == 0 {
+		return &nistPoint{}
+	}
+	return g.newPoint(g.curve.ScalarMult(p.X, p.Y, k.Bytes()))
+}
+
+func (g *nistGroup) MultiExp(a []Element, b []*big.Int) (Element, error) {
+	return multiExpGeneric(g, g.ScalarMult, a, b)
+}
+
+func (g *nistGroup) ScalarMultCT(a Element, n *big.Int) Element {
+	return g.ScalarMult(a, n)
+}
+
+func (g *nistGroup) MultiExpCT(a []Element, b []*big.Int) (Element, error) {
+	return multiExpGeneric(g, g.ScalarMultCT, a, b)
+}
+
+func (g *nistGroup) MapToGroup(m string) (Element, error) {
+	x, y, err := g.w.mapToCurve(m)
+	if err != nil {
+		return nil, err
+	}
+	return &nistPoint{X: x, Y: y}, nil
+}
+
+func (g *nistGroup) Unmarshal(data []byte) (Element, error) {
+	x, y, err := g.w.decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	return &nistPoint{X: x, Y: y}, nil
+}
+
+func (g *nistGroup) Contains(e Element) bool {
+	p, ok := e.(*nistPoint)
+	if !ok || p == nil {
+		return false
+	}
+	if p.IsZero() {
+		return true
+	}
+	P := g.curve.Params().P
+	if p.X.Sign() < 0 || p.X.Cmp(P) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(P) >= 0 {
+		return false
+	}
+	return g.curve.IsOnCurve(p.X, p.Y)
+}
+
+func (g *nistGroup) ElementSize() int {
+	return POINTSIZE
+}
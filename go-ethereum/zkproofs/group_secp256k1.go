@@ -0,0 +1,173 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the group secp256k1, whose elements are p256 points. It uses
+the arithmetic of this package, namely the multi-exponentiation, the constant-time
+scalar multiplication and the vector operations on affine points.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+)
+
+var (
+	secpField = &scalarField{q: CURVE.N}
+)
+
+/*
+secp256k1Group implements Group.
+*/
+type secp256k1Group struct{}
+
+/*
+toP256 returns the p256 point of the element, which must belong to secp256k1.
+*/
+func toP256(a Element) (*p256) {
+	p, ok := a.(*p256)
+	if !ok {
+		panic("Element does not belong to secp256k1.")
+	}
+	return p
+}
+
+/*
+Elements converts a vector of p256 points to a vector of elements.
+*/
+func Elements(a []*p256) ([]Element) {
+	var (
+		i int
+	)
+	result := make([]Element, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = a[i]
+	}
+	return result
+}
+
+/*
+p256Elements converts a vector of elements of secp256k1 to a vector of p256 points.
+*/
+func p256Elements(a []Element) ([]*p256) {
+	var (
+		i int
+	)
+	result := make([]*p256, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = toP256(a[i])
+	}
+	return result
+}
+
+func (secp256k1Group) Name() string {
+	return "secp256k1"
+}
+
+func (secp256k1Group) Scalar() Scalar {
+	return secpField
+}
+
+func (secp256k1Group) Identity() Element {
+	return new(p256).SetInfinity()
+}
+
+func (secp256k1Group) Generator() Element {
+	return &p256{X: GX, Y: GY}
+}
+
+func (secp256k1Group) Add(a, b Element) Element {
+	return new(p256).Multiply(toP256(a), toP256(b))
+}
+
+func (secp256k1Group) Neg(a Element) Element {
+	p := toP256(a)
+	r := &p256{X: p.X, Y: p.Y}
+	return r.Neg(r)
+}
+
+func (secp256k1Group) ScalarMult(a Element, n *big.Int) Element {
+	return new(p256).ScalarMult(toP256(a), n)
+}
+
+func (secp256k1Group) MultiExp(a []Element, b []*big.Int) (Element, error) {
+	return MultiExp(p256Elements(a), b)
+}
+
+func (secp256k1Group) ScalarMultCT(a Element, n *big.Int) Element {
+	return new(p256).ScalarMultCT(toP256(a), n)
+}
+
+func (secp256k1Group) MultiExpCT(a []Element, b []*big.Int) (Element, error) {
+	return MultiExpCT(p256Elements(a), b)
+}
+
+func (secp256k1Group) MapToGroup(m string) (Element, error) {
+	return MapToGroup(m)
+}
+
+func (secp256k1Group) Unmarshal(data []byte) (Element, error) {
+	p := new(p256)
+	if err := p.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (secp256k1Group) Contains(e Element) bool {
+	p, ok := e.(*p256)
+	if !ok || p == nil {
+		return false
+	}
+	if p.IsZero() {
+		return true
+	}
+	if p.X.Sign() < 0 || p.X.Cmp(CURVE.P) >= 0 || p.Y.Sign() < 0 || p.Y.Cmp(CURVE.P) >= 0 {
+		return false
+	}
+	return CURVE.IsOnCurve(p.X, p.Y)
+}
+
+func (secp256k1Group) ElementSize() int {
+	return POINTSIZE
+}
+
+/*
+foldElements folds the generators with the affine vectors of p256vector.go.
+*/
+func (secp256k1Group) foldElements(lo, hi []Element, a, b *big.Int) []Element {
+	var (
+		sa, sb scalar
+	)
+	sa.setBig(a)
+	sb.setBig(b)
+	return Elements(p256Vector(foldVector(affineVector(p256Elements(lo)), affineVector(p256Elements(hi)), &sa, &sb)))
+}
+
+/*
+mulElements computes points[i]^scalars[i] with the affine vectors of p256vector.go.
+*/
+func (secp256k1Group) mulElements(points []Element, scalars []*big.Int) []Element {
+	var (
+		i int
+	)
+	s := make([]scalar, len(scalars))
+	for i=0; i<len(scalars); i++ {
+		s[i].setBig(scalars[i])
+	}
+	return Elements(p256Vector(mulEach(affineVector(p256Elements(points)), s)))
+}
@@ -0,0 +1,220 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+var (
+	groups = []Group{SECP256K1, NISTP256, BN256G1}
+)
+
+/*
+Test the group laws and the multi-exponentiation of every group.
+*/
+func TestGroupArithmetic(t *testing.T) {
+	for _, g := range groups {
+		G := g.Generator()
+		a, _ := g.Scalar().Random()
+		b, _ := g.Scalar().Random()
+		if !g.Add(G, g.Neg(G)).IsZero() {
+			t.Errorf("%s: G - G is not the identity", g.Name())
+		}
+		if !g.ScalarMult(G, g.Scalar().Order()).IsZero() {
+			t.Errorf("%s: G^q is not the identity", g.Name())
+		}
+		if !g.Add(G, g.Identity()).Equals(G) || !g.Add(G, G).Equals(g.ScalarMult(G, new(big.Int).SetInt64(2))) {
+			t.Errorf("%s: wrong addition", g.Name())
+		}
+		Ga := g.ScalarMult(G, a)
+		if !g.ScalarMultCT(G, a).Equals(Ga) {
+			t.Errorf("%s: ScalarMultCT differs from ScalarMult", g.Name())
+		}
+		H, _ := g.MapToGroup(SEEDH)
+		expected := g.Add(Ga, g.ScalarMult(H, b))
+		r1, _ := g.MultiExp([]Element{G, H}, []*big.Int{a, b})
+		r2, _ := g.MultiExpCT([]Element{G, H}, []*big.Int{a, b})
+		if !r1.Equals(expected) || !r2.Equals(expected) {
+			t.Errorf("%s: wrong multi-exponentiation", g.Name())
+		}
+	}
+}
+
+/*
+Test that the encoding of the elements is decoded to the same element, and that
+invalid encodings are rejected.
+*/
+func TestGroupEncoding(t *testing.T) {
+	for _, g := range groups {
+		H, err := g.MapToGroup(SEEDU)
+		if err != nil || H.IsZero() {
+			t.Errorf("%s: MapToGroup failed: %v", g.Name(), err)
+			continue
+		}
+		for _, e := range []Element{g.Identity(), g.Generator(), H, g.Neg(H)} {
+			data, _ := e.MarshalBinary()
+			if len(data) != g.ElementSize() {
+				t.Errorf("%s: encoding has %d bytes", g.Name(), len(data))
+			}
+			d, err := g.Unmarshal(data)
+			if err != nil || !d.Equals(e) {
+				t.Errorf("%s: decoding of %s failed: %v", g.Name(), e, err)
+			}
+		}
+		data, _ := H.MarshalBinary()
+		data[0] = 0x05
+		if _, err := g.Unmarshal(data); err == nil {
+			t.Errorf("%s: invalid prefix accepted", g.Name())
+		}
+		if _, err := g.Unmarshal(data[1:]); err == nil {
+			t.Errorf("%s: short encoding accepted", g.Name())
+		}
+	}
+}
+
+/*
+Test the range proof over every group.
+*/
+func TestGroupBulletproofs(t *testing.T) {
+	for _, g := range groups {
+		zkrp := bp{Group: g}
+		if err := zkrp.Setup(18, 200); err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		if err := zkrp.CheckGenerators(); err != nil {
+			t.Errorf("%s: %v", g.Name(), err)
+		}
+		proof, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+		ok, _ := zkrp.Verify(proof)
+		if ok != true {
+			t.Errorf("%s: assert failure: expected true, actual: %t", g.Name(), ok)
+		}
+		data, _ := proof.MarshalBinary()
+		decoded, err := zkrp.UnmarshalProof(data)
+		if err != nil {
+			t.Errorf("%s: %v", g.Name(), err)
+		}
+		ok, _ = zkrp.Verify(decoded)
+		if ok != true {
+			t.Errorf("%s: decoded proof: expected true, actual: %t", g.Name(), ok)
+		}
+		proof, _ = zkrp.Prove(new(big.Int).SetInt64(17))
+		ok, _ = zkrp.Verify(proof)
+		if ok != false {
+			t.Errorf("%s: assert failure: expected false, actual: %t", g.Name(), ok)
+		}
+	}
+}
+
+/*
+Test the aggregated range proof over every group, and that a proof computed over
+one group is rejected by the parameters of another.
+*/
+func TestGroupAggregatedBulletproofs(t *testing.T) {
+	var (
+		other bpAgg
+	)
+	other.Setup(8, 2)
+	for _, g := range groups {
+		zkrp := bpAgg{Group: g}
+		if err := zkrp.Setup(8, 2); err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		proof, _ := zkrp.Prove([]*big.Int{big.NewInt(42), big.NewInt(255)})
+		ok, err := zkrp.Verify(proof)
+		if ok != true || err != nil {
+			t.Errorf("%s: assert failure: expected true, actual: %t, %v", g.Name(), ok, err)
+		}
+		if g != SECP256K1 {
+			if ok, err = other.Verify(proof); ok != false || err == nil {
+				t.Errorf("%s: assert failure: expected false and an error, actual: %t, %v", g.Name(), ok, err)
+			}
+		}
+		proof, _ = zkrp.Prove([]*big.Int{big.NewInt(42), big.NewInt(256)})
+		ok, _ = zkrp.Verify(proof)
+		if ok != false {
+			t.Errorf("%s: assert failure: expected false, actual: %t", g.Name(), ok)
+		}
+	}
+}
+
+/*
+Test the arithmetic circuit proof over every group.
+*/
+func TestGroupCircuit(t *testing.T) {
+	for _, g := range groups {
+		zkrp := bpCircuit{Group: g}
+		if err := zkrp.Setup(16); err != nil {
+			t.Fatalf("%s: %v", g.Name(), err)
+		}
+		V, proof := provePolicy(&zkrp, 42, 31)
+		if ok := verifyPolicy(&zkrp, V, proof); ok != true {
+			t.Errorf("%s: assert failure: expected true, actual: %t", g.Name(), ok)
+		}
+		V, proof = provePolicy(&zkrp, 17, 31)
+		if ok := verifyPolicy(&zkrp, V, proof); ok != false {
+			t.Errorf("%s: assert failure: expected false, actual: %t", g.Name(), ok)
+		}
+	}
+}
+
+/*
+Test the batch verification over every group.
+*/
+func TestGroupVerifyBatch(t *testing.T) {
+	for _, g := range groups {
+		zkrp := bp{Group: g}
+		zkrp.Setup(18, 66)
+		values := []int64{20, 17, 30}
+		proofs := make([]proofBP, len(values))
+		for i := range values {
+			proofs[i], _ = zkrp.Prove(new(big.Int).SetInt64(values[i]))
+		}
+		ok, invalid, _ := zkrp.VerifyBatch(proofs)
+		if ok != false || len(invalid) != 1 || invalid[0] != 1 {
+			t.Errorf("%s: expected false and [1], actual: %t, %v", g.Name(), ok, invalid)
+		}
+	}
+}
+
+/*
+Test the inner product argument over every group.
+*/
+func TestGroupInnerProduct(t *testing.T) {
+	for _, g := range groups {
+		var (
+			zkip bip
+		)
+		a := []*big.Int{new(big.Int).SetInt64(2), new(big.Int).SetInt64(-1), new(big.Int).SetInt64(10), new(big.Int).SetInt64(6)}
+		b := []*big.Int{new(big.Int).SetInt64(1), new(big.Int).SetInt64(2), new(big.Int).SetInt64(10), new(big.Int).SetInt64(7)}
+		f := field(g)
+		c, _ := f.scalarProduct(a, b)
+		H, _, _ := groupHU(g)
+		gg, hh, _ := groupGenerators(g, 4)
+		zkip.Group = g
+		zkip.Setup(H, gg, hh)
+		P, _ := zkip.Commit(a, b)
+		proof, _ := zkip.Prove(a, b, P, c)
+		ok, _ := zkip.Verify(P, c, proof)
+		ok2, _ := zkip.VerifyMultiExp(P, c, proof)
+		if ok != true || ok2 != true {
+			t.Errorf("%s: assert failure: expected true, actual: %t, %t", g.Name(), ok, ok2)
+		}
+	}
+}
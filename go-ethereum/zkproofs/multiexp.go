@@ -0,0 +1,214 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the multi-exponentiation prod_i a[i]^b[i]. Small inputs are
+computed with Straus' algorithm, which shares the doublings among all the points,
+and large inputs with Pippenger's bucket method. Both of them work on Jacobian
+coordinates, so that no modular inversion is computed until the end.
+*/
+
+package zkproofs
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+const (
+	// Window size of Straus' algorithm
+	strausWindow = uint(4)
+	// Minimum number of points for which Pippenger's algorithm is used
+	pippengerMin = 96
+)
+
+/*
+scalar is an exponent reduced modulo ORDER, represented by four 64-bit limbs in
+little-endian order.
+*/
+type scalar [4]uint64
+
+/*
+setBig converts the big integer x, reduced modulo ORDER, to a scalar. The
+conversion does not depend on the value of x when 0 <= x < 2^256.
+*/
+func (s *scalar) setBig(x *big.Int) *scalar {
+	var (
+		f fe
+	)
+	feSetBigMod(&f, x, &feOrder, ORDER)
+	*s = scalar(f)
+	return s
+}
+
+/*
+isZero returns true if and only if s is equal to zero.
+*/
+func (s *scalar) isZero() bool {
+	return (s[0] | s[1] | s[2] | s[3]) == 0
+}
+
+/*
+window returns the w bits of s starting at bit offset.
+*/
+func (s *scalar) window(offset, w uint) uint {
+	limb := offset / 64
+	shift := offset % 64
+	if limb >= 4 {
+		return 0
+	}
+	v := s[limb] >> shift
+	if shift + w > 64 && limb + 1 < 4 {
+		v = v | s[limb+1] << (64 - shift)
+	}
+	return uint(v & (uint64(1) << w - 1))
+}
+
+/*
+MultiExp computes prod_i a[i]^b[i], using the arithmetic backend selected at
+build time.
+*/
+func MultiExp(a []*p256, b []*big.Int) (*p256, error) {
+	if len(a) != len(b) {
+		return nil, errors.New("Size of first argument is different from size of second argument.")
+	}
+	return backendMultiExp(a, b)
+}
+
+/*
+multiExpGo computes prod_i a[i]^b[i] in pure Go. Both arguments must have the
+same size.
+*/
+func multiExpGo(a []*p256, b []*big.Int) (*p256, error) {
+	var (
+		i int
+		acc jacobian
+	)
+	points := make([]affine, 0, len(a))
+	scalars := make([]scalar, 0, len(a))
+	for i=0; i<len(a); i++ {
+		var (
+			p affine
+			s scalar
+		)
+		s.setBig(b[i])
+		if a[i].IsZero() || s.isZero() {
+			continue
+		}
+		points = append(points, *p.setP256(a[i]))
+		scalars = append(scalars, s)
+	}
+	if len(points) < pippengerMin {
+		acc = straus(points, scalars)
+	} else {
+		acc = pippenger(points, scalars)
+	}
+	return acc.toP256(), nil
+}
+
+/*
+straus computes prod_i points[i]^scalars[i] with windows of strausWindow bits.
+*/
+func straus(points []affine, scalars []scalar) jacobian {
+	return strausWith(points, scalars, strausWindow)
+}
+
+/*
+strausWith computes prod_i points[i]^scalars[i] using a table of the first multiples
+of each point, which is indexed by windows of w bits.
+*/
+func strausWith(points []affine, scalars []scalar, w uint) jacobian {
+	var (
+		i, k int
+		offset uint
+		acc, p jacobian
+	)
+	size := 1 << w - 1
+	// multiples[i*size+k-1] = points[i]^k
+	multiples := make([]jacobian, len(points) * size)
+	for i=0; i<len(points); i++ {
+		p.setAffine(&points[i])
+		multiples[i*size] = p
+		for k=2; k<=size; k++ {
+			multiples[i*size+k-1].addAffine(&multiples[i*size+k-2], &points[i])
+		}
+	}
+	table := batchToAffine(multiples)
+	acc.setInfinity()
+	// The first window may be partial when w does not divide 256
+	offset = (255 / w + 1) * w
+	for offset > 0 {
+		offset = offset - w
+		for k=0; k<int(w); k++ {
+			acc.double(&acc)
+		}
+		for i=0; i<len(points); i++ {
+			d := int(scalars[i].window(offset, w))
+			if d != 0 {
+				acc.addAffine(&acc, &table[i*size+d-1])
+			}
+		}
+	}
+	return acc
+}
+
+/*
+pippenger computes prod_i points[i]^scalars[i] by sorting the points in buckets
+according to windows of their exponents. The window size grows with the log of
+the number of points.
+*/
+func pippenger(points []affine, scalars []scalar) jacobian {
+	var (
+		i, k int
+		offset uint
+		acc, sum, total jacobian
+	)
+	w := uint(bits.Len(uint(len(points))))
+	if w > 3 {
+		w = w - 3
+	}
+	if w < 2 {
+		w = 2
+	}
+	buckets := make([]jacobian, 1 << w - 1)
+	acc.setInfinity()
+	offset = (255 / w + 1) * w
+	for offset > 0 {
+		offset = offset - w
+		for k=0; k<int(w); k++ {
+			acc.double(&acc)
+		}
+		for k=0; k<len(buckets); k++ {
+			buckets[k].setInfinity()
+		}
+		for i=0; i<len(points); i++ {
+			d := int(scalars[i].window(offset, w))
+			if d != 0 {
+				buckets[d-1].addAffine(&buckets[d-1], &points[i])
+			}
+		}
+		// total = sum_k k.buckets[k-1], computed with running sums
+		sum.setInfinity()
+		total.setInfinity()
+		for k=len(buckets)-1; k>=0; k-- {
+			sum.add(&sum, &buckets[k])
+			total.add(&total, &sum)
+		}
+		acc.add(&acc, &total)
+	}
+	return acc
+}
@@ -0,0 +1,160 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"strconv"
+	"testing"
+)
+
+/*
+naiveMultiExp computes prod_i a[i]^b[i] with one scalar multiplication per point.
+*/
+func naiveMultiExp(a []*p256, b []*big.Int) *p256 {
+	result := new(p256).SetInfinity()
+	for i := range a {
+		result.Multiply(result, new(p256).ScalarMult(a[i], b[i]))
+	}
+	return result
+}
+
+/*
+randomMultiExpInput returns n random points and exponents.
+*/
+func randomMultiExpInput(n int) ([]*p256, []*big.Int) {
+	points := make([]*p256, n)
+	scalars := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		points[i], _ = MapToGroup("multiexp" + strconv.Itoa(i))
+		scalars[i], _ = rand.Int(rand.Reader, ORDER)
+	}
+	return points, scalars
+}
+
+func TestFieldArithmetic(t *testing.T) {
+	var (
+		a, b, r fe
+	)
+	pm1 := new(big.Int).Sub(CURVE.P, new(big.Int).SetInt64(1))
+	for i := 0; i < TestCount; i++ {
+		x, _ := rand.Int(rand.Reader, CURVE.P)
+		y, _ := rand.Int(rand.Reader, CURVE.P)
+		// Also exercise the values close to the modulus
+		if i % 10 == 0 {
+			x = pm1
+		}
+		feSetBig(&a, x)
+		feSetBig(&b, y)
+		feAdd(&r, &a, &b)
+		if feBig(&r).Cmp(Mod(new(big.Int).Add(x, y), CURVE.P)) != 0 {
+			t.Errorf("Assert failure: wrong addition of %s and %s", x, y)
+		}
+		feSub(&r, &a, &b)
+		if feBig(&r).Cmp(Mod(new(big.Int).Sub(x, y), CURVE.P)) != 0 {
+			t.Errorf("Assert failure: wrong subtraction of %s and %s", x, y)
+		}
+		feMul(&r, &a, &b)
+		if feBig(&r).Cmp(Mod(new(big.Int).Mul(x, y), CURVE.P)) != 0 {
+			t.Errorf("Assert failure: wrong multiplication of %s and %s", x, y)
+		}
+		if i % 100 == 0 {
+			feInv(&r, &a)
+			if feBig(&r).Cmp(new(big.Int).ModInverse(x, CURVE.P)) != 0 {
+				t.Errorf("Assert failure: wrong inverse of %s", x)
+			}
+		}
+	}
+}
+
+func TestJacobian(t *testing.T) {
+	var (
+		p, q, r jacobian
+		aff affine
+	)
+	g, _ := MapToGroup(SEEDH)
+	h, _ := MapToGroup(SEEDU)
+	p.setP256(g)
+	q.setP256(h)
+	r.add(&p, &q)
+	if !r.toP256().Equals(new(p256).Multiply(g, h)) {
+		t.Errorf("Assert failure: wrong addition")
+	}
+	r.addAffine(&p, aff.setP256(g))
+	if !r.toP256().Equals(new(p256).ScalarMult(g, new(big.Int).SetInt64(2))) {
+		t.Errorf("Assert failure: wrong doubling in the mixed addition")
+	}
+	r.neg(&p)
+	r.add(&r, &p)
+	if !r.isInfinity() {
+		t.Errorf("Assert failure: expected infinity")
+	}
+}
+
+func TestMultiExp(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 7, 64, pippengerMin, 300} {
+		points, scalars := randomMultiExpInput(n)
+		if n > 2 {
+			// Include the corner cases: zero exponent, point at infinity,
+			// repeated and opposite points
+			scalars[0] = new(big.Int).SetInt64(0)
+			points[1] = new(p256).SetInfinity()
+			points[2] = points[3]
+			scalars[4] = new(big.Int).Sub(ORDER, scalars[3])
+			points[4] = points[3]
+			scalars[5] = new(big.Int).Sub(ORDER, new(big.Int).SetInt64(1))
+		}
+		result, _ := MultiExp(points, scalars)
+		expected := naiveMultiExp(points, scalars)
+		if !result.Equals(expected) {
+			t.Errorf("Assert failure: wrong multi-exponentiation for %d points", n)
+		}
+	}
+}
+
+/*
+Test Straus' algorithm with window sizes which do not divide 256.
+*/
+func TestStrausWindow(t *testing.T) {
+	var (
+		i int
+		w uint
+	)
+	points, scalars := randomMultiExpInput(5)
+	aff := make([]affine, len(points))
+	sc := make([]scalar, len(points))
+	for i=0; i<len(points); i++ {
+		aff[i].setP256(points[i])
+		sc[i].setBig(scalars[i])
+	}
+	expected := naiveMultiExp(points, scalars)
+	for w=1; w<=7; w++ {
+		result := strausWith(aff, sc, w)
+		if !result.toP256().Equals(expected) {
+			t.Errorf("Assert failure: wrong multi-exponentiation for window %d", w)
+		}
+	}
+}
+
+func TestMultiExpSize(t *testing.T) {
+	points, scalars := randomMultiExpInput(2)
+	_, err := MultiExp(points, scalars[:1])
+	if err == nil {
+		t.Errorf("Assert failure: expected error for vectors of different sizes")
+	}
+}
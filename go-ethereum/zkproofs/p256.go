@@ -54,36 +54,50 @@ func (p *p256) Neg(a *p256) (*p256) {
 }
 
 /*
-Input points must be distinct
+Add returns the sum of the given elliptic curve points, using the arithmetic
+backend selected at build time.
 */
 func (p *p256) Add(a,b *p256) (*p256) {
+	return backendAdd(p, a, b)
+}
+
+/*
+affineAdd sets p to a+b with the affine formulas of CURVE and returns p. The
+points may be equal or be the point at infinity. Unlike libsecp256k1, it does not
+check that the points are on the curve.
+*/
+func affineAdd(p, a, b *p256) (*p256) {
+	var (
+		resx, resy *big.Int
+	)
 	if (a.IsZero()) {
 		p.X = b.X
 		p.Y = b.Y
-		return p 
+		return p
 	} else if (b.IsZero()) {
-		p.X = b.X
-		p.Y = b.Y
-		return p 
-
-	} 
-	resx, resy := CURVE.Add(a.X, a.Y, b.X, b.Y)
+		p.X = a.X
+		p.Y = a.Y
+		return p
+	}
+	if (a.X.Cmp(b.X)==0) {
+		if (a.Y.Cmp(b.Y)!=0) {
+			// b = -a
+			return p.SetInfinity()
+		}
+		resx, resy = CURVE.Double(a.X, a.Y)
+	} else {
+		resx, resy = CURVE.Add(a.X, a.Y, b.X, b.Y)
+	}
 	p.X = resx
 	p.Y = resy
-	return p 
+	return p
 }
 
 /*
 Double returns 2*P, where P is the given elliptic curve point.
 */
 func (p *p256) Double(a *p256) (*p256) {
-	if (a.IsZero()) {
-		return p.SetInfinity()
-	}
-	resx, resy := CURVE.Double(a.X, a.Y)
-	p.X = resx
-	p.Y = resy
-	return p 
+	return backendAdd(p, a, a)
 }
 
 /*
@@ -124,29 +138,24 @@ func (p *p256) ScalarBaseMult(n *big.Int) (*p256) {
 /*
 Multiply actually is reponsible for the addition of elliptic curve points. 
 The name here is to maintain compatibility with bn256 interface.
-This algorithm verifies if the given elliptic curve points are equal, in which case it 
-returns the result of Double function, otherwise it returns the result of Add function. 
+It is the same as Add, since the backends handle equal points and the point at infinity.
 */
 func (p *p256) Multiply(a,b *p256) (*p256) {
-	if (a.IsZero()) {
-		p.X = b.X
-		p.Y = b.Y
-		return p 
-	} else if (b.IsZero()) {
-		p.X = a.X
-		p.Y = a.Y
-		return p 
-	} 
-	if (a.X.Cmp(b.X)==0 && a.Y.Cmp(b.Y)==0) {
-		resx, resy := CURVE.Double(a.X, a.Y)
-		p.X = resx
-		p.Y = resy
-		return p
+	return backendAdd(p, a, b)
+}
+
+/*
+Equals returns true if and only if e is a point of secp256k1 equal to p.
+*/
+func (p *p256) Equals(e Element) bool {
+	a, ok := e.(*p256)
+	if !ok || a == nil {
+		return false
 	}
-	resx, resy := CURVE.Add(a.X, a.Y, b.X, b.Y)
-	p.X = resx
-	p.Y = resy
-	return p
+	if (p.IsZero() || a.IsZero()) {
+		return p.IsZero() && a.IsZero()
+	}
+	return p.X.Cmp(a.X)==0 && p.Y.Cmp(a.Y)==0
 }
 
 /*
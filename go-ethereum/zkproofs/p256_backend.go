@@ -0,0 +1,49 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !libsecp256k1
+
+/*
+This file contains the pure Go arithmetic backend, which is used unless the
+libsecp256k1 build tag is given. Scalar multiplications always use the vendored
+libsecp256k1, through CURVE.ScalarMult.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+)
+
+var (
+	// Name of the arithmetic backend
+	BACKEND = "go"
+)
+
+/*
+backendAdd sets p to a+b and returns p. The points may be equal or be the point
+at infinity.
+*/
+func backendAdd(p, a, b *p256) (*p256) {
+	return affineAdd(p, a, b)
+}
+
+/*
+backendMultiExp computes prod_i a[i]^b[i] with Straus' or Pippenger's algorithm.
+*/
+func backendMultiExp(a []*p256, b []*big.Int) (*p256, error) {
+	return multiExpGo(a, b)
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build libsecp256k1
+
+/*
+This file contains the arithmetic backend based on the vendored libsecp256k1,
+which is selected with the libsecp256k1 build tag:
+	go test -tags libsecp256k1
+Point additions and multi-exponentiations are computed by the C library, the
+latter through secp256k1_ecmult_multi_var.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/secp256k1"
+)
+
+var (
+	// Name of the arithmetic backend
+	BACKEND = "libsecp256k1"
+)
+
+/*
+backendAdd sets p to a+b and returns p. The points may be equal or be the point
+at infinity. libsecp256k1 rejects the points that are not on the curve, which
+must not crash the caller: then the sum is computed with the affine formulas of
+the Go backend, and the off-curve result is rejected by the validation of the
+proofs.
+*/
+func backendAdd(p, a, b *p256) (*p256) {
+	var (
+		x1, y1, x2, y2 *big.Int
+	)
+	if (!a.IsZero()) {
+		x1, y1 = a.X, a.Y
+	}
+	if (!b.IsZero()) {
+		x2, y2 = b.X, b.Y
+	}
+	resx, resy, err := secp256k1.AddPoints(x1, y1, x2, y2)
+	if err != nil {
+		return affineAdd(p, a, b)
+	}
+	p.X = resx
+	p.Y = resy
+	return p
+}
+
+/*
+backendMultiExp computes prod_i a[i]^b[i] with libsecp256k1.
+*/
+func backendMultiExp(a []*p256, b []*big.Int) (*p256, error) {
+	var (
+		i int
+	)
+	xs := make([]*big.Int, len(a))
+	ys := make([]*big.Int, len(a))
+	scalars := make([][]byte, len(a))
+	for i=0; i<len(a); i++ {
+		if (!a[i].IsZero()) {
+			xs[i], ys[i] = a[i].X, a[i].Y
+		}
+		scalars[i] = Mod(b[i], ORDER).Bytes()
+	}
+	resx, resy, err := secp256k1.MultiScalarMult(xs, ys, scalars)
+	if err != nil {
+		return nil, err
+	}
+	return &p256{X: resx, Y: resy}, nil
+}
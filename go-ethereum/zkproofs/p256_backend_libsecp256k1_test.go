@@ -0,0 +1,58 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build libsecp256k1
+
+package zkproofs
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+/*
+Test that the addition of a point which is not on the curve, which libsecp256k1
+rejects, does not panic, and that the verifier returns an error for it.
+*/
+func TestBackendAddOffCurve(t *testing.T) {
+	var (
+		zkrp bp
+		params bp
+	)
+	offCurve := &p256{X: new(big.Int).SetInt64(1), Y: new(big.Int).SetInt64(1)}
+	a := new(p256).ScalarBaseMult(new(big.Int).SetInt64(12))
+	if !new(p256).Add(a, offCurve).Equals(affineAdd(new(p256), a, offCurve)) {
+		t.Errorf("Add of an off-curve point differs from the affine formulas.")
+	}
+	if !new(p256).Double(offCurve).Equals(affineAdd(new(p256), offCurve, offCurve)) {
+		t.Errorf("Double of an off-curve point differs from the affine formulas.")
+	}
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+	proof.V = offCurve
+	if ok, err := zkrp.Verify(proof); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if ok, invalid, _ := zkrp.VerifyBatch([]proofBP{proof}); ok != false || len(invalid) != 1 {
+		t.Errorf("Assert failure: expected the proof to be reported as invalid, actual: %t, %v", ok, invalid)
+	}
+	zkrp.H = offCurve
+	data, _ := json.Marshal(&zkrp)
+	if err := json.Unmarshal(data, &params); err == nil {
+		t.Errorf("Assert failure: expected an error for off-curve parameters")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+/*
+The tests in this file do not depend on build tags, so that both arithmetic
+backends are checked against the scalar multiplication of secp256k1:
+	go test
+	go test -tags libsecp256k1
+*/
+
+func TestBackendAdd(t *testing.T) {
+	t.Logf("Backend: %s", BACKEND)
+	for i := 0; i < TestCount; i++ {
+		x, _ := rand.Int(rand.Reader, ORDER)
+		y, _ := rand.Int(rand.Reader, ORDER)
+		a := new(p256).ScalarBaseMult(x)
+		b := new(p256).ScalarBaseMult(y)
+		expected := new(p256).ScalarBaseMult(new(big.Int).Add(x, y))
+		if !new(p256).Add(a, b).Equals(expected) {
+			t.Errorf("Add differs from the scalar multiplication.")
+		}
+		expected = new(p256).ScalarBaseMult(new(big.Int).Add(x, x))
+		if !new(p256).Add(a, a).Equals(expected) || !new(p256).Double(a).Equals(expected) {
+			t.Errorf("Add of equal points differs from the scalar multiplication.")
+		}
+		// The result may alias one of the operands
+		c := &p256{X: a.X, Y: a.Y}
+		c.Multiply(c, b)
+		if !c.Equals(new(p256).Add(a, b)) {
+			t.Errorf("Multiply differs from Add.")
+		}
+	}
+}
+
+func TestBackendAddInfinity(t *testing.T) {
+	a := new(p256).ScalarBaseMult(new(big.Int).SetInt64(12))
+	inf := new(p256).SetInfinity()
+	if !new(p256).Add(a, inf).Equals(a) || !new(p256).Add(inf, a).Equals(a) {
+		t.Errorf("The point at infinity must be the identity.")
+	}
+	if !new(p256).Add(inf, inf).IsZero() || !new(p256).Double(inf).IsZero() {
+		t.Errorf("The sum of points at infinity must be the point at infinity.")
+	}
+	b := &p256{X: a.X, Y: a.Y}
+	b.Neg(b)
+	if !new(p256).Add(a, b).IsZero() {
+		t.Errorf("The sum of a point and its inverse must be the point at infinity.")
+	}
+}
+
+func TestBackendMultiExp(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, pippengerMin + 1} {
+		a, b := randomMultiExpInput(n)
+		if n > 2 {
+			// Points at infinity, zero exponents and exponents larger than ORDER
+			a[0] = new(p256).SetInfinity()
+			b[1] = new(big.Int)
+			b[2] = new(big.Int).Add(b[2], ORDER)
+		}
+		result, err := MultiExp(a, b)
+		if err != nil {
+			t.Fatalf("MultiExp failed: %s", err)
+		}
+		if !result.Equals(naiveMultiExp(a, b)) {
+			t.Errorf("MultiExp of %d points differs from the naive computation.", n)
+		}
+	}
+}
+
+func TestBackendMultiExpCancel(t *testing.T) {
+	a := new(p256).ScalarBaseMult(new(big.Int).SetInt64(5))
+	x := new(big.Int).SetInt64(3)
+	result, _ := MultiExp([]*p256{a, a}, []*big.Int{x, new(big.Int).Sub(ORDER, x)})
+	if !result.IsZero() {
+		t.Errorf("MultiExp must return the point at infinity.")
+	}
+}
+
+func BenchmarkBackendMultiExp(b *testing.B) {
+	points, scalars := randomMultiExpInput(256)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		MultiExp(points, scalars)
+	}
+}
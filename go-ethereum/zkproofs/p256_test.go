@@ -31,7 +31,8 @@ func TestAdd(t * testing.T) {
 	A2x, A2y := curve.ScalarBaseMult(a2)
 	p2 := &p256{X:A2x, Y:A2y}
 	p3 := p1.Add(p1, p2)
-	sa := new(big.Int).SetInt64(-88).Bytes()
+	// -88 modulo the order of the curve
+	sa := new(big.Int).Sub(curve.N, new(big.Int).SetInt64(88)).Bytes()
 	sAx, sAy := curve.ScalarBaseMult(sa)
 	sp := &p256{X:sAx, Y:sAy}
 	p4 := p3.Add(p3, sp)
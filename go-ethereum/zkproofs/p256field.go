@@ -0,0 +1,576 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the arithmetic of the base field of secp256k1 using fixed
+size limbs, and the arithmetic of elliptic curve points in Jacobian coordinates,
+where (X, Y, Z) represents the affine point (X/Z^2, Y/Z^3). Contrary to p256,
+adding points in Jacobian coordinates does not require any modular inversion.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+/*
+fe is an element of the base field of secp256k1, represented by four 64-bit limbs
+in little-endian order. Every operation returns a fully reduced element.
+*/
+type fe [4]uint64
+
+var (
+	// feC is such that P = 2^256 - feC
+	feC = uint64(0x1000003D1)
+	feP = fe{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+	// Order of the group of secp256k1, namely ORDER
+	feOrder = fe{0xBFD25E8CD0364141, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+	feOne = fe{1, 0, 0, 0}
+)
+
+/*
+feReduce subtracts P from r if and only if r >= P, without branching.
+*/
+func feReduce(r *fe) {
+	feCondSub(r, &feP)
+}
+
+/*
+feCondSub subtracts m from r if and only if r >= m, without branching.
+*/
+func feCondSub(r, m *fe) {
+	var (
+		t fe
+		b uint64
+	)
+	t[0], b = bits.Sub64(r[0], m[0], 0)
+	t[1], b = bits.Sub64(r[1], m[1], b)
+	t[2], b = bits.Sub64(r[2], m[2], b)
+	t[3], b = bits.Sub64(r[3], m[3], b)
+	// mask is all ones if there was no borrow, i.e. r >= m
+	mask := b - 1
+	r[0] = (t[0] & mask) | (r[0] &^ mask)
+	r[1] = (t[1] & mask) | (r[1] &^ mask)
+	r[2] = (t[2] & mask) | (r[2] &^ mask)
+	r[3] = (t[3] & mask) | (r[3] &^ mask)
+}
+
+/*
+feAdd computes r = a + b mod P.
+*/
+func feAdd(r, a, b *fe) {
+	var c uint64
+	r0, c := bits.Add64(a[0], b[0], 0)
+	r1, c := bits.Add64(a[1], b[1], c)
+	r2, c := bits.Add64(a[2], b[2], c)
+	r3, c := bits.Add64(a[3], b[3], c)
+	// 2^256 = feC mod P
+	r0, c = bits.Add64(r0, feC & -c, 0)
+	r1, c = bits.Add64(r1, 0, c)
+	r2, c = bits.Add64(r2, 0, c)
+	r3, _ = bits.Add64(r3, 0, c)
+	r[0], r[1], r[2], r[3] = r0, r1, r2, r3
+	feReduce(r)
+}
+
+/*
+feSub computes r = a - b mod P.
+*/
+func feSub(r, a, b *fe) {
+	var c uint64
+	r0, c := bits.Sub64(a[0], b[0], 0)
+	r1, c := bits.Sub64(a[1], b[1], c)
+	r2, c := bits.Sub64(a[2], b[2], c)
+	r3, c := bits.Sub64(a[3], b[3], c)
+	// if a < b, then add P, which is the same as subtracting feC modulo 2^256
+	r0, c = bits.Sub64(r0, feC & -c, 0)
+	r1, c = bits.Sub64(r1, 0, c)
+	r2, c = bits.Sub64(r2, 0, c)
+	r3, _ = bits.Sub64(r3, 0, c)
+	r[0], r[1], r[2], r[3] = r0, r1, r2, r3
+}
+
+/*
+feNeg computes r = -a mod P.
+*/
+func feNeg(r, a *fe) {
+	var zero fe
+	feSub(r, &zero, a)
+}
+
+/*
+mulAdd computes a * b + c + d, which always fits in 128 bits, and returns the
+high and the low 64 bits.
+*/
+func mulAdd(a, b, c, d uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(a, b)
+	lo, cc := bits.Add64(lo, c, 0)
+	hi += cc
+	lo, cc = bits.Add64(lo, d, 0)
+	hi += cc
+	return hi, lo
+}
+
+/*
+feMul computes r = a * b mod P. The schoolbook multiplication is unrolled, since
+it is the bottleneck of every operation on points.
+*/
+func feMul(r, a, b *fe) {
+	var (
+		c, t0, t1, t2, t3, t4, t5, t6, t7 uint64
+	)
+	a0, a1, a2, a3 := a[0], a[1], a[2], a[3]
+	b0, b1, b2, b3 := b[0], b[1], b[2], b[3]
+	// t = a * b
+	c, t0 = bits.Mul64(a0, b0)
+	c, t1 = mulAdd(a0, b1, c, 0)
+	c, t2 = mulAdd(a0, b2, c, 0)
+	t4, t3 = mulAdd(a0, b3, c, 0)
+	c, t1 = mulAdd(a1, b0, t1, 0)
+	c, t2 = mulAdd(a1, b1, t2, c)
+	c, t3 = mulAdd(a1, b2, t3, c)
+	t5, t4 = mulAdd(a1, b3, t4, c)
+	c, t2 = mulAdd(a2, b0, t2, 0)
+	c, t3 = mulAdd(a2, b1, t3, c)
+	c, t4 = mulAdd(a2, b2, t4, c)
+	t6, t5 = mulAdd(a2, b3, t5, c)
+	c, t3 = mulAdd(a3, b0, t3, 0)
+	c, t4 = mulAdd(a3, b1, t4, c)
+	c, t5 = mulAdd(a3, b2, t5, c)
+	t7, t6 = mulAdd(a3, b3, t6, c)
+	// t = L + H.2^256 = L + H.feC mod P
+	c, r0 := mulAdd(t4, feC, t0, 0)
+	c, r1 := mulAdd(t5, feC, t1, c)
+	c, r2 := mulAdd(t6, feC, t2, c)
+	c, r3 := mulAdd(t7, feC, t3, c)
+	// fold the remaining top limb, which is smaller than 2^34
+	hi, lo := bits.Mul64(c, feC)
+	r0, c = bits.Add64(r0, lo, 0)
+	r1, c = bits.Add64(r1, hi, c)
+	r2, c = bits.Add64(r2, 0, c)
+	r3, c = bits.Add64(r3, 0, c)
+	r0, c = bits.Add64(r0, feC & -c, 0)
+	r1, c = bits.Add64(r1, 0, c)
+	r2, c = bits.Add64(r2, 0, c)
+	r3, _ = bits.Add64(r3, 0, c)
+	r[0], r[1], r[2], r[3] = r0, r1, r2, r3
+	feReduce(r)
+}
+
+/*
+feSqr computes r = a^2 mod P.
+*/
+func feSqr(r, a *fe) {
+	feMul(r, a, a)
+}
+
+/*
+feInv computes r = a^-1 mod P, using Fermat's little theorem, i.e. r = a^(P-2).
+The exponent is fixed, then the running time does not depend on a.
+*/
+func feInv(r, a *fe) {
+	var (
+		i, j int
+		res fe
+	)
+	res = feOne
+	x := *a
+	e := feP
+	e[0] = e[0] - 2
+	for i=3; i>=0; i-- {
+		for j=63; j>=0; j-- {
+			feSqr(&res, &res)
+			if (e[i] >> uint(j)) & 1 == 1 {
+				feMul(&res, &res, &x)
+			}
+		}
+	}
+	*r = res
+}
+
+/*
+feIsZero returns true if and only if a is equal to zero.
+*/
+func feIsZero(a *fe) bool {
+	return (a[0] | a[1] | a[2] | a[3]) == 0
+}
+
+/*
+feEqual returns true if and only if a is equal to b.
+*/
+func feEqual(a, b *fe) bool {
+	return ((a[0] ^ b[0]) | (a[1] ^ b[1]) | (a[2] ^ b[2]) | (a[3] ^ b[3])) == 0
+}
+
+/*
+feSetBig converts the big integer x, reduced modulo P, to a field element.
+*/
+func feSetBig(r *fe, x *big.Int) {
+	feSetBigMod(r, x, &feP, CURVE.P)
+}
+
+/*
+feSetBigMod converts the big integer x, reduced modulo m, to four limbs, where
+m = mod and 2^256 < 2m. Since x is usually secret, it is neither reduced by big.Int
+nor trimmed by Bytes: if 0 <= x < 2^256, its words are copied into a buffer of
+fixed size and m is subtracted without branching. Only negative values and values
+of more than 256 bits are reduced by Mod first.
+*/
+func feSetBigMod(r *fe, x *big.Int, m *fe, mod *big.Int) {
+	var (
+		buf [8]big.Word
+		i int
+	)
+	if x.Sign() < 0 || x.BitLen() > 256 {
+		x = Mod(x, mod)
+	}
+	copy(buf[:], x.Bits())
+	if bits.UintSize == 64 {
+		for i=0; i<4; i++ {
+			r[i] = uint64(buf[i])
+		}
+	} else {
+		for i=0; i<4; i++ {
+			r[i] = uint64(buf[2*i]) | uint64(buf[2*i+1]) << 32
+		}
+	}
+	// x < 2^256 < 2m, then a single subtraction is enough
+	feCondSub(r, m)
+}
+
+/*
+feBytes returns the big-endian representation of a in 32 bytes.
+*/
+func feBytes(a *fe) ([]byte) {
+	var (
+		i, j int
+	)
+	buf := make([]byte, 32)
+	for i=0; i<4; i++ {
+		for j=0; j<8; j++ {
+			buf[31-8*i-j] = byte(a[i] >> uint(8*j))
+		}
+	}
+	return buf
+}
+
+/*
+feBig converts the field element a to a big integer.
+*/
+func feBig(a *fe) *big.Int {
+	return new(big.Int).SetBytes(feBytes(a))
+}
+
+/*
+jacobian is an elliptic curve point in Jacobian coordinates. The point at infinity
+is represented by z = 0.
+*/
+type jacobian struct {
+	x, y, z fe
+}
+
+/*
+affine is an elliptic curve point in affine coordinates, used as the second
+argument of the mixed addition.
+*/
+type affine struct {
+	x, y fe
+	inf bool
+}
+
+/*
+setP256 converts the p256 point a to affine field elements.
+*/
+func (p *affine) setP256(a *p256) *affine {
+	if a.IsZero() {
+		p.inf = true
+		return p
+	}
+	feSetBig(&p.x, a.X)
+	feSetBig(&p.y, a.Y)
+	p.inf = false
+	return p
+}
+
+/*
+setInfinity sets p to the point at infinity.
+*/
+func (p *jacobian) setInfinity() *jacobian {
+	p.x = feOne
+	p.y = feOne
+	p.z = fe{}
+	return p
+}
+
+/*
+isInfinity returns true if and only if p is the point at infinity.
+*/
+func (p *jacobian) isInfinity() bool {
+	return feIsZero(&p.z)
+}
+
+/*
+setAffine sets p to the affine point a.
+*/
+func (p *jacobian) setAffine(a *affine) *jacobian {
+	if a.inf {
+		return p.setInfinity()
+	}
+	p.x = a.x
+	p.y = a.y
+	p.z = feOne
+	return p
+}
+
+/*
+setP256 sets p to the p256 point a.
+*/
+func (p *jacobian) setP256(a *p256) *jacobian {
+	var aff affine
+	return p.setAffine(aff.setP256(a))
+}
+
+/*
+toAffine converts p to affine coordinates.
+*/
+func (p *jacobian) toAffine() affine {
+	var (
+		r affine
+		zinv, zinv2, zinv3 fe
+	)
+	if p.isInfinity() {
+		r.inf = true
+		return r
+	}
+	feInv(&zinv, &p.z)
+	feSqr(&zinv2, &zinv)
+	feMul(&zinv3, &zinv2, &zinv)
+	feMul(&r.x, &p.x, &zinv2)
+	feMul(&r.y, &p.y, &zinv3)
+	return r
+}
+
+/*
+toP256 converts p to the p256 representation.
+*/
+func (p *jacobian) toP256() *p256 {
+	a := p.toAffine()
+	if a.inf {
+		return new(p256).SetInfinity()
+	}
+	return &p256{X: feBig(&a.x), Y: feBig(&a.y)}
+}
+
+/*
+neg sets p = -a.
+*/
+func (p *jacobian) neg(a *jacobian) *jacobian {
+	p.x = a.x
+	feNeg(&p.y, &a.y)
+	p.z = a.z
+	return p
+}
+
+/*
+double sets p = 2a.
+See http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#doubling-dbl-2009-l
+*/
+func (p *jacobian) double(a *jacobian) *jacobian {
+	var (
+		A, B, C, D, E, F, t fe
+	)
+	if a.isInfinity() {
+		return p.setInfinity()
+	}
+	feSqr(&A, &a.x)
+	feSqr(&B, &a.y)
+	feSqr(&C, &B)
+	// D = 2.((X+B)^2 - A - C)
+	feAdd(&D, &a.x, &B)
+	feSqr(&D, &D)
+	feSub(&D, &D, &A)
+	feSub(&D, &D, &C)
+	feAdd(&D, &D, &D)
+	// E = 3.A
+	feAdd(&E, &A, &A)
+	feAdd(&E, &E, &A)
+	feSqr(&F, &E)
+	// Z3 = 2.Y.Z
+	feMul(&t, &a.y, &a.z)
+	feAdd(&p.z, &t, &t)
+	// X3 = F - 2.D
+	feSub(&p.x, &F, &D)
+	feSub(&p.x, &p.x, &D)
+	// Y3 = E.(D - X3) - 8.C
+	feSub(&t, &D, &p.x)
+	feMul(&t, &E, &t)
+	feAdd(&C, &C, &C)
+	feAdd(&C, &C, &C)
+	feAdd(&C, &C, &C)
+	feSub(&p.y, &t, &C)
+	return p
+}
+
+/*
+add sets p = a + b.
+See http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-add-2007-bl
+*/
+func (p *jacobian) add(a, b *jacobian) *jacobian {
+	var (
+		z1z1, z2z2, u1, u2, s1, s2, h, i, j, r, v, t fe
+	)
+	if a.isInfinity() {
+		*p = *b
+		return p
+	}
+	if b.isInfinity() {
+		*p = *a
+		return p
+	}
+	feSqr(&z1z1, &a.z)
+	feSqr(&z2z2, &b.z)
+	feMul(&u1, &a.x, &z2z2)
+	feMul(&u2, &b.x, &z1z1)
+	feMul(&s1, &a.y, &b.z)
+	feMul(&s1, &s1, &z2z2)
+	feMul(&s2, &b.y, &a.z)
+	feMul(&s2, &s2, &z1z1)
+	feSub(&h, &u2, &u1)
+	feSub(&r, &s2, &s1)
+	if feIsZero(&h) {
+		if feIsZero(&r) {
+			return p.double(a)
+		}
+		return p.setInfinity()
+	}
+	// I = (2.H)^2, J = H.I, r = 2.(S2 - S1), V = U1.I
+	feAdd(&i, &h, &h)
+	feSqr(&i, &i)
+	feMul(&j, &h, &i)
+	feAdd(&r, &r, &r)
+	feMul(&v, &u1, &i)
+	// Z3 = ((Z1 + Z2)^2 - Z1Z1 - Z2Z2).H
+	feAdd(&t, &a.z, &b.z)
+	feSqr(&t, &t)
+	feSub(&t, &t, &z1z1)
+	feSub(&t, &t, &z2z2)
+	feMul(&p.z, &t, &h)
+	// X3 = r^2 - J - 2.V
+	feSqr(&t, &r)
+	feSub(&t, &t, &j)
+	feSub(&t, &t, &v)
+	feSub(&p.x, &t, &v)
+	// Y3 = r.(V - X3) - 2.S1.J
+	feSub(&t, &v, &p.x)
+	feMul(&t, &r, &t)
+	feMul(&s1, &s1, &j)
+	feAdd(&s1, &s1, &s1)
+	feSub(&p.y, &t, &s1)
+	return p
+}
+
+/*
+addAffine sets p = a + b, where b is given in affine coordinates.
+See http://hyperelliptic.org/EFD/g1p/auto-shortw-jacobian-0.html#addition-madd-2007-bl
+*/
+func (p *jacobian) addAffine(a *jacobian, b *affine) *jacobian {
+	var (
+		z1z1, u2, s2, h, hh, i, j, r, v, t fe
+	)
+	if b.inf {
+		*p = *a
+		return p
+	}
+	if a.isInfinity() {
+		return p.setAffine(b)
+	}
+	feSqr(&z1z1, &a.z)
+	feMul(&u2, &b.x, &z1z1)
+	feMul(&s2, &b.y, &a.z)
+	feMul(&s2, &s2, &z1z1)
+	feSub(&h, &u2, &a.x)
+	feSub(&r, &s2, &a.y)
+	if feIsZero(&h) {
+		if feIsZero(&r) {
+			return p.double(a)
+		}
+		return p.setInfinity()
+	}
+	// HH = H^2, I = 4.HH, J = H.I, r = 2.(S2 - Y1), V = X1.I
+	feSqr(&hh, &h)
+	feAdd(&i, &hh, &hh)
+	feAdd(&i, &i, &i)
+	feMul(&j, &h, &i)
+	feAdd(&r, &r, &r)
+	feMul(&v, &a.x, &i)
+	// Z3 = (Z1 + H)^2 - Z1Z1 - HH
+	feAdd(&t, &a.z, &h)
+	feSqr(&t, &t)
+	feSub(&t, &t, &z1z1)
+	feSub(&t, &t, &hh)
+	y1 := a.y
+	p.z = t
+	// X3 = r^2 - J - 2.V
+	feSqr(&t, &r)
+	feSub(&t, &t, &j)
+	feSub(&t, &t, &v)
+	feSub(&p.x, &t, &v)
+	// Y3 = r.(V - X3) - 2.Y1.J
+	feSub(&t, &v, &p.x)
+	feMul(&t, &r, &t)
+	feMul(&j, &y1, &j)
+	feAdd(&j, &j, &j)
+	feSub(&p.y, &t, &j)
+	return p
+}
+
+/*
+batchToAffine converts every point in Jacobian coordinates to affine coordinates
+using a single field inversion (Montgomery's trick).
+*/
+func batchToAffine(points []jacobian) ([]affine) {
+	var (
+		i int
+		inv, zinv, zinv2 fe
+	)
+	n := len(points)
+	result := make([]affine, n)
+	acc := make([]fe, n)
+	prod := feOne
+	for i=0; i<n; i++ {
+		acc[i] = prod
+		if !points[i].isInfinity() {
+			feMul(&prod, &prod, &points[i].z)
+		}
+	}
+	feInv(&inv, &prod)
+	for i=n-1; i>=0; i-- {
+		if points[i].isInfinity() {
+			result[i].inf = true
+			continue
+		}
+		// zinv = (z_0...z_(i-1)) . (z_0...z_i)^-1
+		feMul(&zinv, &inv, &acc[i])
+		feMul(&inv, &inv, &points[i].z)
+		feSqr(&zinv2, &zinv)
+		feMul(&result[i].x, &points[i].x, &zinv2)
+		feMul(&zinv2, &zinv2, &zinv)
+		feMul(&result[i].y, &points[i].y, &zinv2)
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the operations on vectors of elliptic curve points. Points are
+kept in Jacobian coordinates during the whole computation, and the resulting vector
+is converted to affine coordinates at once, with a single modular inversion.
+*/
+
+package zkproofs
+
+import (
+	"math/big"
+)
+
+/*
+affineVector converts the vector of p256 points a to affine field elements.
+*/
+func affineVector(a []*p256) []affine {
+	var (
+		i int
+	)
+	result := make([]affine, len(a))
+	for i=0; i<len(a); i++ {
+		result[i].setP256(a[i])
+	}
+	return result
+}
+
+/*
+toP256 converts the affine point a to the p256 representation.
+*/
+func (a *affine) toP256() *p256 {
+	if a.inf {
+		return new(p256).SetInfinity()
+	}
+	return &p256{X: feBig(&a.x), Y: feBig(&a.y)}
+}
+
+/*
+p256Vector converts the vector of affine points a to p256 points.
+*/
+func p256Vector(a []affine) []*p256 {
+	var (
+		i int
+	)
+	result := make([]*p256, len(a))
+	for i=0; i<len(a); i++ {
+		result[i] = a[i].toP256()
+	}
+	return result
+}
+
+/*
+mulEach computes points[i]^scalars[i] for each i.
+*/
+func mulEach(points []affine, scalars []scalar) []affine {
+	var (
+		i int
+	)
+	result := make([]jacobian, len(points))
+	for i=0; i<len(points); i++ {
+		if points[i].inf || scalars[i].isZero() {
+			result[i].setInfinity()
+			continue
+		}
+		result[i] = straus(points[i:i+1], scalars[i:i+1])
+	}
+	return batchToAffine(result)
+}
+
+/*
+foldVector computes lo[i]^a.hi[i]^b for each i, which is the way the generators
+are folded at each round of the inner product argument.
+*/
+func foldVector(lo, hi []affine, a, b *scalar) []affine {
+	var (
+		i int
+	)
+	result := make([]jacobian, len(lo))
+	for i=0; i<len(lo); i++ {
+		result[i] = straus([]affine{lo[i], hi[i]}, []scalar{*a, *b})
+	}
+	return batchToAffine(result)
+}
+
+/*
+hprimeVector computes the generators h'[i] = h[i]^(y^-i).
+*/
+func hprimeVector(h []*p256, y *big.Int) []*p256 {
+	var (
+		i int
+	)
+	scalars := make([]scalar, len(h))
+	yinv := ModInverse(y, ORDER)
+	expy := new(big.Int).SetInt64(1)
+	for i=0; i<len(h); i++ {
+		scalars[i].setBig(expy)
+		expy = Mod(Multiply(expy, yinv), ORDER)
+	}
+	return p256Vector(mulEach(affineVector(h), scalars))
+}
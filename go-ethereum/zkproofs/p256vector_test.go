@@ -0,0 +1,85 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+)
+
+/*
+Test VectorScalarExp and VectorECAdd against the operations on p256, including
+the point at infinity and the sum of a point with itself.
+*/
+func TestVectorPointOps(t *testing.T) {
+	var (
+		i int
+	)
+	a, s := randomMultiExpInput(9)
+	b, _ := randomMultiExpInput(9)
+	a[3] = new(p256).SetInfinity()
+	b[4] = new(p256).SetInfinity()
+	b[5] = a[5]
+	b[6] = &p256{X: a[6].X, Y: a[6].Y}
+	b[6].Neg(b[6])
+	exp, _ := VectorScalarExp(a, s[0])
+	sum, _ := VectorECAdd(a, b)
+	for i=0; i<len(a); i++ {
+		if !exp[i].Equals(new(p256).ScalarMult(a[i], s[0])) {
+			t.Errorf("Assert failure: wrong VectorScalarExp at %d", i)
+		}
+		if !sum[i].Equals(new(p256).Multiply(a[i], b[i])) {
+			t.Errorf("Assert failure: wrong VectorECAdd at %d", i)
+		}
+	}
+	_, err := VectorECAdd(a, b[1:])
+	if err == nil {
+		t.Errorf("Assert failure: expected error for different sizes")
+	}
+}
+
+/*
+Test foldVector and hprimeVector against the operations on p256.
+*/
+func TestFoldVector(t *testing.T) {
+	var (
+		i int
+		sa, sb scalar
+	)
+	lo, s := randomMultiExpInput(4)
+	hi, _ := randomMultiExpInput(4)
+	sa.setBig(s[0])
+	sb.setBig(s[1])
+	folded := p256Vector(foldVector(affineVector(lo), affineVector(hi), &sa, &sb))
+	for i=0; i<len(lo); i++ {
+		expected := new(p256).ScalarMult(lo[i], s[0])
+		expected.Multiply(expected, new(p256).ScalarMult(hi[i], s[1]))
+		if !folded[i].Equals(expected) {
+			t.Errorf("Assert failure: wrong fold at %d", i)
+		}
+	}
+	y := s[2]
+	hprime := hprimeVector(hi, y)
+	yinv := ModInverse(y, ORDER)
+	expy := new(big.Int).SetInt64(1)
+	for i=0; i<len(hi); i++ {
+		if !hprime[i].Equals(new(p256).ScalarMult(hi[i], expy)) {
+			t.Errorf("Assert failure: wrong h' at %d", i)
+		}
+		expy = Mod(Multiply(expy, yinv), ORDER)
+	}
+}
@@ -0,0 +1,286 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the storage of Bulletproofs parameters and proofs. Setup and
+Prove do not perform any I/O: the caller decides where the parameters and the
+proofs are kept by passing a ParamStore or a ProofSink. Parameters and proofs
+are stored in the same JSON format as before.
+*/
+
+package zkproofs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+)
+
+/*
+ParamStore saves and loads Bulletproofs parameters by name.
+*/
+type ParamStore interface {
+	SaveParams(name string, zkrp *bp) (error)
+	LoadParams(name string) (*bp, error)
+}
+
+/*
+ProofSink receives the Bulletproofs proofs produced by the prover.
+*/
+type ProofSink interface {
+	SaveProof(name string, proof *proofBP) (error)
+}
+
+/*
+decodeParams decodes parameters in JSON format and checks that the generators are
+the ones given by their derivation.
+*/
+func decodeParams(data []byte) (*bp, error) {
+	var result bp
+	if len(data) == 0 {
+		return nil, errors.New("Could not load generators.")
+	}
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	err = result.CheckGenerators()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+/*
+decodeProof decodes a proof in JSON format.
+*/
+func decodeProof(data []byte) (*proofBP, error) {
+	var result proofBP
+	if len(data) == 0 {
+		return nil, errors.New("Could not load proof.")
+	}
+	err := json.Unmarshal(data, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+/*
+MemoryStore keeps parameters and proofs in memory. It is safe for concurrent use.
+*/
+type MemoryStore struct {
+	mu sync.Mutex
+	params map[string][]byte
+	proofs map[string][]byte
+}
+
+/*
+NewMemoryStore returns an empty MemoryStore.
+*/
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{params: make(map[string][]byte), proofs: make(map[string][]byte)}
+}
+
+/*
+SaveParams stores the parameters under the given name.
+*/
+func (s *MemoryStore) SaveParams(name string, zkrp *bp) (error) {
+	data, err := json.Marshal(zkrp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.params[name] = data
+	s.mu.Unlock()
+	return nil
+}
+
+/*
+LoadParams returns a copy of the parameters stored under the given name.
+*/
+func (s *MemoryStore) LoadParams(name string) (*bp, error) {
+	s.mu.Lock()
+	data, ok := s.params[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.New("Parameters not found.")
+	}
+	return decodeParams(data)
+}
+
+/*
+SaveProof stores the proof under the given name.
+*/
+func (s *MemoryStore) SaveProof(name string, proof *proofBP) (error) {
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.proofs[name] = data
+	s.mu.Unlock()
+	return nil
+}
+
+/*
+LoadProof returns a copy of the proof stored under the given name.
+*/
+func (s *MemoryStore) LoadProof(name string) (*proofBP, error) {
+	s.mu.Lock()
+	data, ok := s.proofs[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, errors.New("Proof not found.")
+	}
+	return decodeProof(data)
+}
+
+/*
+DirStore keeps parameters and proofs as files in the directory Dir, one file per
+name, such as setup.dat and proof.dat.
+*/
+type DirStore struct {
+	Dir string
+}
+
+/*
+NewDirStore returns a DirStore for the given directory, which must already exist.
+*/
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{Dir: dir}
+}
+
+/*
+path returns the path of the file for the given name. Names must not refer to
+other directories.
+*/
+func (s *DirStore) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", errors.New("Invalid name.")
+	}
+	return filepath.Join(s.Dir, name), nil
+}
+
+/*
+write encodes v in JSON format and writes it to the file for the given name.
+*/
+func (s *DirStore) write(name string, v interface{}) (error) {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+/*
+read returns the contents of the file for the given name.
+*/
+func (s *DirStore) read(name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+/*
+SaveParams writes the parameters to the file for the given name.
+*/
+func (s *DirStore) SaveParams(name string, zkrp *bp) (error) {
+	return s.write(name, zkrp)
+}
+
+/*
+LoadParams reads the parameters from the file for the given name.
+*/
+func (s *DirStore) LoadParams(name string) (*bp, error) {
+	data, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeParams(data)
+}
+
+/*
+SaveProof writes the proof to the file for the given name.
+*/
+func (s *DirStore) SaveProof(name string, proof *proofBP) (error) {
+	return s.write(name, proof)
+}
+
+/*
+LoadProof reads the proof from the file for the given name.
+*/
+func (s *DirStore) LoadProof(name string) (*proofBP, error) {
+	data, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+	return decodeProof(data)
+}
+
+/*
+WriterSink writes every proof to W in JSON format, one proof per line. The name
+is not written. Writes are serialized, so that concurrent provers may share it.
+*/
+type WriterSink struct {
+	mu sync.Mutex
+	W io.Writer
+}
+
+/*
+NewWriterSink returns a WriterSink that writes to w.
+*/
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{W: w}
+}
+
+/*
+SaveProof writes the proof to W.
+*/
+func (s *WriterSink) SaveProof(name string, proof *proofBP) (error) {
+	data, err := json.Marshal(proof)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.W.Write(data)
+	return err
+}
+
+/*
+LoadParamFromDisk reads the parameters from the file s.
+*/
+func LoadParamFromDisk(s string) (*bp, error) {
+	return NewDirStore(filepath.Dir(s)).LoadParams(filepath.Base(s))
+}
+
+/*
+LoadProofFromDisk reads the proof from the file s.
+*/
+func LoadProofFromDisk(s string) (*proofBP, error) {
+	return NewDirStore(filepath.Dir(s)).LoadProof(filepath.Base(s))
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+/*
+storeRoundTrip saves fresh parameters and a proof, loads them back and checks
+that the proof is still valid.
+*/
+func storeRoundTrip(t *testing.T, store ParamStore, sink ProofSink, load func(string) (*proofBP, error)) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(42))
+	err := store.SaveParams("setup.dat", &zkrp)
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	err = sink.SaveProof("proof.dat", &proof)
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	params, err := store.LoadParams("setup.dat")
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	loaded, err := load("proof.dat")
+	if err != nil {
+		t.Fatalf("Assert failure: unexpected error: %s", err)
+	}
+	ok, _ := params.Verify(*loaded)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	_, err = store.LoadParams("missing.dat")
+	if err == nil {
+		t.Errorf("Assert failure: expected error for missing parameters")
+	}
+}
+
+/*
+Test the in-memory store.
+*/
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	storeRoundTrip(t, store, store, store.LoadProof)
+}
+
+/*
+Test the filesystem store, and that Setup and Prove do not write to the working
+directory.
+*/
+func TestDirStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zkproofs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Run in an empty directory, so that any side effect is visible
+	empty, err := ioutil.TempDir("", "zkproofs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(empty)
+	os.Chdir(empty)
+	defer os.Chdir(wd)
+
+	store := NewDirStore(dir)
+	storeRoundTrip(t, store, store, store.LoadProof)
+	files, _ := ioutil.ReadDir(empty)
+	if len(files) != 0 {
+		t.Errorf("Assert failure: expected no files in the working directory, actual: %d", len(files))
+	}
+	_, err = os.Stat(filepath.Join(dir, "proof.dat"))
+	if err != nil {
+		t.Errorf("Assert failure: proof not saved: %s", err)
+	}
+	err = store.SaveParams("../setup.dat", nil)
+	if err == nil {
+		t.Errorf("Assert failure: expected error for a name outside the directory")
+	}
+	p, err := LoadProofFromDisk(filepath.Join(dir, "proof.dat"))
+	if err != nil || p.V == nil {
+		t.Errorf("Assert failure: could not load proof from disk: %v", err)
+	}
+}
+
+/*
+Test the io.Writer sink, which writes one JSON proof per line.
+*/
+func TestWriterSink(t *testing.T) {
+	var (
+		buf bytes.Buffer
+	)
+	store := NewMemoryStore()
+	sink := NewWriterSink(&buf)
+	storeRoundTrip(t, store, sink, func(name string) (*proofBP, error) {
+		line, err := buf.ReadBytes('\n')
+		if err != nil {
+			return nil, err
+		}
+		var proof proofBP
+		err = json.Unmarshal(line, &proof)
+		return &proof, err
+	})
+	if buf.Len() != 0 {
+		t.Errorf("Assert failure: expected a single line, remaining: %d bytes", buf.Len())
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the transcript used to apply the Fiat-Shamir heuristic, in the
+same spirit as Merlin (https://merlin.cool). Every message sent by the prover, as
+well as the statement, is appended to the transcript with a label, and every
+challenge is derived from the whole transcript up to that point. Then each
+challenge depends on the statement and on everything that was sent before it.
+*/
+
+package zkproofs
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"math/big"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+Transcript keeps the running state of the Fiat-Shamir heuristic. The state is
+the SHA-256 hash chain of all the labelled messages appended so far.
+*/
+type Transcript struct {
+	state []byte
+}
+
+/*
+writeLabelled writes the label and the message to the digest, both prefixed
+by their length, such that different sequences of messages never collide.
+*/
+func writeLabelled(digest hash.Hash, label string, m []byte) {
+	var (
+		size [8]byte
+	)
+	binary.BigEndian.PutUint64(size[:], uint64(len(label)))
+	digest.Write(size[:])
+	digest.Write([]byte(label))
+	binary.BigEndian.PutUint64(size[:], uint64(len(m)))
+	digest.Write(size[:])
+	digest.Write(m)
+}
+
+/*
+NewTranscript returns a transcript for the protocol identified by label, which
+works as domain separator.
+*/
+func NewTranscript(label string) *Transcript {
+	t := new(Transcript)
+	t.state = make([]byte, sha256.Size)
+	t.AppendMessage("dom-sep", []byte(label))
+	return t
+}
+
+/*
+AppendMessage appends the message m to the transcript.
+*/
+func (t *Transcript) AppendMessage(label string, m []byte) {
+	digest := sha256.New()
+	digest.Write(t.state)
+	writeLabelled(digest, label, m)
+	t.state = digest.Sum(nil)
+}
+
+/*
+AppendInt64 appends the integer v to the transcript.
+*/
+func (t *Transcript) AppendInt64(label string, v int64) {
+	var (
+		buf [8]byte
+	)
+	binary.BigEndian.PutUint64(buf[:], uint64(v))
+	t.AppendMessage(label, buf[:])
+}
+
+/*
+AppendScalar appends the non-negative integer s to the transcript.
+*/
+func (t *Transcript) AppendScalar(label string, s *big.Int) {
+	t.AppendMessage(label, s.Bytes())
+}
+
+/*
+p256Bytes returns the coordinates X and Y of p in 32 bytes each. The point at
+infinity is encoded as 64 zero bytes.
+*/
+func p256Bytes(p *p256) ([]byte) {
+	buf := make([]byte, 64)
+	if p.IsZero() {
+		return buf
+	}
+	x := p.X.Bytes()
+	y := p.Y.Bytes()
+	copy(buf[32-len(x):32], x)
+	copy(buf[64-len(y):], y)
+	return buf
+}
+
+/*
+elementBytes returns the encoding of the element in the transcript. Points of
+secp256k1 are encoded with both coordinates, the elements of other groups with
+their compressed encoding.
+*/
+func elementBytes(e Element) ([]byte) {
+	if p, ok := e.(*p256); ok {
+		return p256Bytes(p)
+	}
+	b, _ := e.MarshalBinary()
+	return b
+}
+
+/*
+AppendPoint appends the group element p to the transcript.
+*/
+func (t *Transcript) AppendPoint(label string, p Element) {
+	t.AppendMessage(label, elementBytes(p))
+}
+
+/*
+AppendPoints appends the vector of elliptic curve points ps to the transcript
+as a single message.
+*/
+func (t *Transcript) AppendPoints(label string, ps []*p256) {
+	var (
+		buf []byte
+	)
+	for _, p := range ps {
+		buf = append(buf, p256Bytes(p)...)
+	}
+	t.AppendMessage(label, buf)
+}
+
+/*
+AppendElements appends the vector of group elements es to the transcript as a
+single message.
+*/
+func (t *Transcript) AppendElements(label string, es []Element) {
+	var (
+		buf []byte
+	)
+	for _, e := range es {
+		buf = append(buf, elementBytes(e)...)
+	}
+	t.AppendMessage(label, buf)
+}
+
+/*
+AppendG1 appends the bn256 point p to the transcript.
+*/
+func (t *Transcript) AppendG1(label string, p *bn256.G1) {
+	t.AppendMessage(label, p.Marshal())
+}
+
+/*
+AppendG2 appends the bn256 point p to the transcript.
+*/
+func (t *Transcript) AppendG2(label string, p *bn256.G2) {
+	t.AppendMessage(label, p.Marshal())
+}
+
+/*
+AppendGT appends the bn256 target group element a to the transcript.
+*/
+func (t *Transcript) AppendGT(label string, a *bn256.GT) {
+	t.AppendMessage(label, a.Marshal())
+}
+
+/*
+ChallengeScalar returns a challenge modulo q derived from the transcript and
+appends it to the transcript. In order to make the bias negligible, the challenge
+is computed from 512 bits.
+*/
+func (t *Transcript) ChallengeScalar(label string, q *big.Int) (*big.Int) {
+	var (
+		buf []byte
+		i byte
+	)
+	for i=0; i<2; i++ {
+		digest := sha256.New()
+		digest.Write(t.state)
+		writeLabelled(digest, label, []byte{'c', 'h', 'a', 'l', 'l', 'e', 'n', 'g', 'e', i})
+		buf = digest.Sum(buf)
+	}
+	c := Mod(new(big.Int).SetBytes(buf), q)
+	t.AppendScalar(label, c)
+	return c
+}
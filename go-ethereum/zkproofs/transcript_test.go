@@ -0,0 +1,89 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"math/big"
+	"testing"
+)
+
+/*
+Test that the same messages produce the same challenges.
+*/
+func TestTranscriptDeterministic(t *testing.T) {
+	g := new(p256).ScalarBaseMult(new(big.Int).SetInt64(1))
+	t1 := NewTranscript("test")
+	t2 := NewTranscript("test")
+	t1.AppendPoint("P", g)
+	t2.AppendPoint("P", g)
+	c1 := t1.ChallengeScalar("c", ORDER)
+	c2 := t2.ChallengeScalar("c", ORDER)
+	if c1.Cmp(c2) != 0 {
+		t.Errorf("Assert failure: expected equal challenges, actual: %s, %s", c1, c2)
+	}
+	// A second challenge must be different from the first one
+	c3 := t1.ChallengeScalar("c", ORDER)
+	if c3.Cmp(c1) == 0 {
+		t.Errorf("Assert failure: expected different challenges")
+	}
+}
+
+/*
+Test that the challenges depend on the domain separator, labels and messages.
+*/
+func TestTranscriptSeparation(t *testing.T) {
+	challenge := func(dom, label string, m []byte) *big.Int {
+		tr := NewTranscript(dom)
+		tr.AppendMessage(label, m)
+		return tr.ChallengeScalar("c", ORDER)
+	}
+	c := challenge("test", "m", []byte("message"))
+	if c.Cmp(challenge("other", "m", []byte("message"))) == 0 {
+		t.Errorf("Assert failure: challenge does not depend on the domain separator")
+	}
+	if c.Cmp(challenge("test", "n", []byte("message"))) == 0 {
+		t.Errorf("Assert failure: challenge does not depend on the label")
+	}
+	if c.Cmp(challenge("test", "m", []byte("massage"))) == 0 {
+		t.Errorf("Assert failure: challenge does not depend on the message")
+	}
+	// Moving bytes between the label and the message must change the challenge
+	if challenge("test", "ab", []byte("c")).Cmp(challenge("test", "a", []byte("bc"))) == 0 {
+		t.Errorf("Assert failure: label and message are not separated")
+	}
+}
+
+/*
+Test that a range proof is rejected when it is verified against other parameters,
+since the challenges depend on the statement.
+*/
+func TestTranscriptBindsStatement(t *testing.T) {
+	var (
+		zkrp, other bp
+	)
+	zkrp.Setup(18, 200)
+	other.Setup(10, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(42))
+	ok, _ := zkrp.Verify(proof)
+	if ok != true {
+		t.Errorf("Assert failure: expected true, actual: %t", ok)
+	}
+	ok, _ = other.Verify(proof)
+	if ok != false {
+		t.Errorf("Assert failure: expected false, actual: %t", ok)
+	}
+}
@@ -18,8 +18,6 @@ package zkproofs
 
 import (
 	"math/big"
-	"crypto/sha256"
-	"github.com/ing-bank/zkproofs/go-ethereum/byteconversion"
 	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
 )
 
@@ -67,39 +65,8 @@ CommitG1 method corresponds to the Pedersen commitment scheme. Namely, given inp
 message x, and randomness r, it outputs g^x.h^r.
 */
 func CommitG1(x,r *big.Int, h *p256) (*p256, error) {
-	var (
-		C *p256
-	)
-	C = new(p256).ScalarBaseMult(x)
-	Hr := new(p256).ScalarMult(h, r)
-	C.Add(C, Hr)
-	return C, nil
-}
-
-/*
-HashSet is responsible for the computing a Zp element given elements from GT and G2.
-*/
-func HashSet(a *bn256.GT, D *bn256.G2) (*big.Int, error) {
-	digest := sha256.New()
-	digest.Write([]byte(a.String()))
-	digest.Write([]byte(D.String()))
-	output := digest.Sum(nil)
-	tmp := output[0: len(output)]
-	return byteconversion.FromByteArray(tmp)
-}
-
-/*
-Hash is responsible for the computing a Zp element given elements from GT and G2.
-*/
-func Hash(a []*bn256.GT, D *bn256.G2) (*big.Int, error) {
-	digest := sha256.New()
-	for i := range a {
-		digest.Write([]byte(a[i].String()))
-	}
-	digest.Write([]byte(D.String()))
-	output := digest.Sum(nil)
-	tmp := output[0: len(output)]
-	return byteconversion.FromByteArray(tmp)
+	// x and r are usually secret, then the commitment is computed in constant time
+	return MultiExpCT([]*p256{&p256{X: GX, Y: GY}, h}, []*big.Int{x, r})
 }
 
 /*
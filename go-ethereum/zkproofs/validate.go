@@ -0,0 +1,773 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+/*
+This file contains the validation of parameters and proofs, which may come from
+an untrusted source, e.g. decoded from JSON or from the binary encoding. Validate
+checks the shape of a value: every point is present and belongs to its group,
+every scalar is reduced and the sizes are consistent. It does not check that a
+proof is valid, which is done by Verify. Every Verify runs Validate first, so
+that malformed values are rejected with a descriptive error instead of making
+the verifier panic.
+*/
+
+package zkproofs
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"strconv"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+checkPower2 returns an error unless n is a power of 2 that the inner product
+argument supports.
+*/
+func checkPower2(n int64, name string) (error) {
+	if n <= 0 || n & (n - 1) != 0 || n > int64(1) << uint(MAXROUNDS) {
+		return errors.New(name + " must be a power of 2, actual: " + strconv.FormatInt(n, 10) + ".")
+	}
+	return nil
+}
+
+/*
+checkElement returns an error unless e is an element of the group.
+*/
+func checkElement(grp Group, e Element, name string) (error) {
+	if e == nil || !grp.Contains(e) {
+		return errors.New("Point " + name + " is missing or is not an element of " + grp.Name() + ".")
+	}
+	return nil
+}
+
+/*
+checkElements returns an error unless es has n elements of the group.
+*/
+func checkElements(grp Group, es []Element, n int64, name string) (error) {
+	var (
+		i int
+	)
+	if int64(len(es)) != n {
+		return errors.New("Vector " + name + " must have " + strconv.FormatInt(n, 10) + " points, actual: " + strconv.Itoa(len(es)) + ".")
+	}
+	for i=0; i<len(es); i++ {
+		if err := checkElement(grp, es[i], name + "[" + strconv.Itoa(i) + "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+checkScalar returns an error unless s belongs to [0,q).
+*/
+func checkScalar(q, s *big.Int, name string) (error) {
+	if s == nil {
+		return errors.New("Scalar " + name + " is missing.")
+	}
+	if s.Sign() < 0 || s.Cmp(q) >= 0 {
+		return errors.New("Scalar " + name + " is not reduced.")
+	}
+	return nil
+}
+
+/*
+checkScalars returns an error unless s has n elements of [0,q).
+*/
+func checkScalars(q *big.Int, s []*big.Int, n int64, name string) (error) {
+	var (
+		i int
+	)
+	if int64(len(s)) != n {
+		return errors.New("Vector " + name + " must have " + strconv.FormatInt(n, 10) + " scalars, actual: " + strconv.Itoa(len(s)) + ".")
+	}
+	for i=0; i<len(s); i++ {
+		if err := checkScalar(q, s[i], name + "[" + strconv.Itoa(i) + "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+checkRounds returns an error unless the inner product proof is for vectors of size n.
+*/
+func checkRounds(p *proofBip, n int64) (error) {
+	if int64(1) << uint(len(p.Ls)) != n {
+		return errors.New("Inner product proof does not match the parameters.")
+	}
+	return nil
+}
+
+//////////////////////////////////// Bulletproofs ////////////////////////////////////
+
+/*
+validate checks the inner product proof over the group grp.
+*/
+func (p *proofBip) validate(grp Group) (error) {
+	var (
+		err error
+	)
+	k := len(p.Ls)
+	if len(p.Rs) != k {
+		return errors.New("Ls and Rs of the inner product proof must have the same size.")
+	}
+	if k > MAXROUNDS {
+		return errors.New("Invalid number of rounds of the inner product proof.")
+	}
+	if p.N != int64(1) << uint(k) {
+		return errors.New("N of the inner product proof must be 2^len(Ls), actual: " + strconv.FormatInt(p.N, 10) + ".")
+	}
+	if err = checkElements(grp, p.Ls, int64(k), "Ls"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, p.Rs, int64(k), "Rs"); err != nil {
+		return err
+	}
+	q := grp.Scalar().Order()
+	if err = checkScalar(q, p.A, "a"); err != nil {
+		return err
+	}
+	return checkScalar(q, p.B, "b")
+}
+
+/*
+Validate checks the inner product proof, whose group is given by its points.
+*/
+func (p *proofBip) Validate() (error) {
+	if len(p.Ls) == 0 {
+		return p.validate(SECP256K1)
+	}
+	grp, err := groupOf(p.Ls[0])
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+validate checks the proof for [0,2^N) over the group grp.
+*/
+func (p *proofBPUL) validate(grp Group) (error) {
+	var (
+		err error
+	)
+	if err = checkElements(grp, []Element{p.A, p.S, p.T1, p.T2}, 4, "A,S,T1,T2"); err != nil {
+		return err
+	}
+	q := grp.Scalar().Order()
+	if err = checkScalar(q, p.Taux, "taux"); err != nil {
+		return err
+	}
+	if err = checkScalar(q, p.Mu, "mu"); err != nil {
+		return err
+	}
+	if err = checkScalar(q, p.Tprime, "tprime"); err != nil {
+		return err
+	}
+	return p.Proofip.validate(grp)
+}
+
+/*
+Validate checks the proof for [0,2^N), whose group is given by its points.
+*/
+func (p *proofBPUL) Validate() (error) {
+	grp, err := groupOf(p.A)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+validate checks the proof for [a,b) over the group grp.
+*/
+func (p *proofBP) validate(grp Group) (error) {
+	if err := checkElement(grp, p.V, "V"); err != nil {
+		return err
+	}
+	if err := p.P1.validate(grp); err != nil {
+		return err
+	}
+	if err := p.P2.validate(grp); err != nil {
+		return err
+	}
+	if len(p.P1.Proofip.Ls) != len(p.P2.Proofip.Ls) {
+		return errors.New("Both proofs must have the same number of rounds.")
+	}
+	return nil
+}
+
+/*
+Validate checks the proof for [a,b), whose group is given by its points.
+*/
+func (p *proofBP) Validate() (error) {
+	grp, err := groupOf(p.V)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+Validate checks the parameters of the inner product argument.
+*/
+func (zkip *bip) Validate() (error) {
+	var (
+		err error
+	)
+	grp := zkip.group()
+	if err = checkPower2(zkip.N, "N"); err != nil {
+		return err
+	}
+	if err = checkElement(grp, zkip.Uu, "U"); err != nil {
+		return err
+	}
+	if err = checkElement(grp, zkip.H, "H"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkip.Gg, zkip.N, "Gg"); err != nil {
+		return err
+	}
+	return checkElements(grp, zkip.Hh, zkip.N, "Hh")
+}
+
+/*
+Validate checks the Bulletproofs parameters.
+*/
+func (zkrp *bp) Validate() (error) {
+	var (
+		err error
+	)
+	grp := zkrp.group()
+	if zkrp.A >= zkrp.B {
+		return errors.New("a must be less than b")
+	}
+	if err = checkPower2(zkrp.N, "N"); err != nil {
+		return err
+	}
+	if rangeSize(zkrp.A, zkrp.B) > zkrp.N {
+		return errors.New("N is too small for the interval [a,b).")
+	}
+	if err = checkElement(grp, zkrp.G, "G"); err != nil {
+		return err
+	}
+	if err = checkElement(grp, zkrp.H, "H"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Gg, zkrp.N, "Gg"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Hh, zkrp.N, "Hh"); err != nil {
+		return err
+	}
+	if zkrp.Zkip.group().Name() != grp.Name() || zkrp.Zkip.N != zkrp.N {
+		return errors.New("Parameters of the inner product do not match the parameters.")
+	}
+	return zkrp.Zkip.Validate()
+}
+
+/*
+validateUL checks that the proof for [0,2^N) matches the parameters.
+*/
+func (zkrp *bp) validateUL(proof *proofBPUL) (error) {
+	if err := proof.validate(zkrp.group()); err != nil {
+		return err
+	}
+	return checkRounds(&proof.Proofip, zkrp.N)
+}
+
+/*
+validateVerify checks the parameters, the commitment V and the proofs for [0,2^N)
+before they are verified.
+*/
+func (zkrp *bp) validateVerify(V Element, proofs ...*proofBPUL) (error) {
+	if err := zkrp.Validate(); err != nil {
+		return err
+	}
+	if err := checkElement(zkrp.group(), V, "V"); err != nil {
+		return err
+	}
+	for _, proof := range proofs {
+		if err := zkrp.validateUL(proof); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+validateVerify checks the parameters, the commitment P, the inner product c and
+the inner product proof before they are verified.
+*/
+func (zkip *bip) validateVerify(P Element, c *big.Int, proof *proofBip) (error) {
+	if err := zkip.Validate(); err != nil {
+		return err
+	}
+	if err := checkElement(zkip.group(), P, "P"); err != nil {
+		return err
+	}
+	if err := checkScalar(zkip.group().Scalar().Order(), c, "c"); err != nil {
+		return err
+	}
+	if err := proof.validate(zkip.group()); err != nil {
+		return err
+	}
+	return checkRounds(proof, zkip.N)
+}
+
+/*
+validate checks the weighted inner product proof over the group grp.
+*/
+func (p *proofWIP) validate(grp Group) (error) {
+	var (
+		err error
+	)
+	k := len(p.Ls)
+	if len(p.Rs) != k {
+		return errors.New("Ls and Rs of the weighted inner product proof must have the same size.")
+	}
+	if k > MAXROUNDS {
+		return errors.New("Invalid number of rounds of the weighted inner product proof.")
+	}
+	if err = checkElements(grp, p.Ls, int64(k), "Ls"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, p.Rs, int64(k), "Rs"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, []Element{p.A, p.B}, 2, "A,B"); err != nil {
+		return err
+	}
+	return checkScalars(grp.Scalar().Order(), []*big.Int{p.Rprime, p.Sprime, p.Dprime}, 3, "r',s',delta'")
+}
+
+/*
+validate checks the Bulletproofs+ proof for [0,2^N) over the group grp.
+*/
+func (p *proofBPPUL) validate(grp Group) (error) {
+	if err := checkElement(grp, p.A, "A"); err != nil {
+		return err
+	}
+	return p.Proofwip.validate(grp)
+}
+
+/*
+Validate checks the Bulletproofs+ proof for [0,2^N), whose group is given by its points.
+*/
+func (p *proofBPPUL) Validate() (error) {
+	grp, err := groupOf(p.A)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+validate checks the Bulletproofs+ proof for [a,b) over the group grp.
+*/
+func (p *proofBPP) validate(grp Group) (error) {
+	if err := checkElement(grp, p.V, "V"); err != nil {
+		return err
+	}
+	if err := p.P1.validate(grp); err != nil {
+		return err
+	}
+	if err := p.P2.validate(grp); err != nil {
+		return err
+	}
+	if len(p.P1.Proofwip.Ls) != len(p.P2.Proofwip.Ls) {
+		return errors.New("Both proofs must have the same number of rounds.")
+	}
+	return nil
+}
+
+/*
+Validate checks the Bulletproofs+ proof for [a,b), whose group is given by its points.
+*/
+func (p *proofBPP) Validate() (error) {
+	grp, err := groupOf(p.V)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+Validate checks the Bulletproofs+ parameters.
+*/
+func (zkrp *bpp) Validate() (error) {
+	var (
+		err error
+	)
+	grp := zkrp.group()
+	if zkrp.A >= zkrp.B {
+		return errors.New("a must be less than b")
+	}
+	if err = checkPower2(zkrp.N, "N"); err != nil {
+		return err
+	}
+	if rangeSize(zkrp.A, zkrp.B) > zkrp.N {
+		return errors.New("N is too small for the interval [a,b).")
+	}
+	if err = checkElements(grp, []Element{zkrp.G, zkrp.H}, 2, "G,H"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Gg, zkrp.N, "Gg"); err != nil {
+		return err
+	}
+	return checkElements(grp, zkrp.Hh, zkrp.N, "Hh")
+}
+
+/*
+validateVerify checks the parameters, the commitment V and the Bulletproofs+
+proofs for [0,2^N) before they are verified.
+*/
+func (zkrp *bpp) validateVerify(V Element, proofs ...*proofBPPUL) (error) {
+	if err := zkrp.Validate(); err != nil {
+		return err
+	}
+	if err := checkElement(zkrp.group(), V, "V"); err != nil {
+		return err
+	}
+	for _, proof := range proofs {
+		if err := proof.validate(zkrp.group()); err != nil {
+			return err
+		}
+		if int64(1) << uint(len(proof.Proofwip.Ls)) != zkrp.N {
+			return errors.New("Weighted inner product proof does not match the parameters.")
+		}
+	}
+	return nil
+}
+
+/*
+Validate checks the aggregated Bulletproofs parameters.
+*/
+func (zkrp *bpAgg) Validate() (error) {
+	var (
+		err error
+	)
+	grp := zkrp.group()
+	if err = checkPower2(zkrp.N, "N"); err != nil {
+		return err
+	}
+	if err = checkPower2(zkrp.M, "M"); err != nil {
+		return err
+	}
+	if err = checkPower2(zkrp.N * zkrp.M, "N.M"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, []Element{zkrp.G, zkrp.H}, 2, "G,H"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Gg, zkrp.N * zkrp.M, "Gg"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Hh, zkrp.N * zkrp.M, "Hh"); err != nil {
+		return err
+	}
+	if zkrp.Zkip.group().Name() != grp.Name() || zkrp.Zkip.N != zkrp.N * zkrp.M {
+		return errors.New("Parameters of the inner product do not match the parameters.")
+	}
+	return zkrp.Zkip.Validate()
+}
+
+/*
+validate checks the aggregated Bulletproofs proof over the group grp.
+*/
+func (p *proofBPAgg) validate(grp Group) (error) {
+	var (
+		err error
+	)
+	if len(p.V) == 0 {
+		return errors.New("Proof must have at least one commitment.")
+	}
+	if err = checkElements(grp, p.V, int64(len(p.V)), "V"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, []Element{p.A, p.S, p.T1, p.T2}, 4, "A,S,T1,T2"); err != nil {
+		return err
+	}
+	if err = checkScalars(grp.Scalar().Order(), []*big.Int{p.Taux, p.Mu, p.Tprime}, 3, "taux,mu,tprime"); err != nil {
+		return err
+	}
+	return p.Proofip.validate(grp)
+}
+
+/*
+Validate checks the aggregated Bulletproofs proof, whose group is given by its points.
+*/
+func (p *proofBPAgg) Validate() (error) {
+	grp, err := groupOf(p.A)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+/*
+Validate checks the arithmetic circuit parameters.
+*/
+func (zkrp *bpCircuit) Validate() (error) {
+	var (
+		err error
+	)
+	grp := zkrp.group()
+	if err = checkPower2(zkrp.N, "N"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, []Element{zkrp.G, zkrp.H}, 2, "G,H"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Gg, zkrp.N, "Gg"); err != nil {
+		return err
+	}
+	if err = checkElements(grp, zkrp.Hh, zkrp.N, "Hh"); err != nil {
+		return err
+	}
+	if zkrp.Zkip.group().Name() != grp.Name() || zkrp.Zkip.N != zkrp.N {
+		return errors.New("Parameters of the inner product do not match the parameters.")
+	}
+	return zkrp.Zkip.Validate()
+}
+
+/*
+validate checks the arithmetic circuit proof over the group grp.
+*/
+func (p *proofBPCircuit) validate(grp Group) (error) {
+	var (
+		err error
+	)
+	points := []Element{p.AI, p.AO, p.S, p.T1, p.T3, p.T4, p.T5, p.T6}
+	if err = checkElements(grp, points, 8, "AI,AO,S,T1,T3,T4,T5,T6"); err != nil {
+		return err
+	}
+	if err = checkScalars(grp.Scalar().Order(), []*big.Int{p.Taux, p.Mu, p.Tprime}, 3, "taux,mu,tprime"); err != nil {
+		return err
+	}
+	return p.Proofip.validate(grp)
+}
+
+/*
+Validate checks the arithmetic circuit proof, whose group is given by its points.
+*/
+func (p *proofBPCircuit) Validate() (error) {
+	grp, err := groupOf(p.AI)
+	if err != nil {
+		return err
+	}
+	return p.validate(grp)
+}
+
+//////////////////////////////////// CCS08 ////////////////////////////////////
+
+/*
+checkG2 returns an error if the bn256 point is missing.
+*/
+func checkG2(p *bn256.G2, name string) (error) {
+	if p == nil {
+		return errors.New("Point " + name + " is missing.")
+	}
+	return nil
+}
+
+/*
+inGT returns true iff a belongs to the subgroup of order bn256.Order, namely a^Order == 1.
+*/
+func inGT(a *bn256.GT) (bool) {
+	return new(bn256.GT).ScalarMult(a, bn256.Order).IsOne()
+}
+
+/*
+checkGT returns an error if the element of the target group is missing or does
+not belong to the subgroup of order bn256.Order.
+*/
+func checkGT(a *bn256.GT, name string) (error) {
+	if a == nil {
+		return errors.New("Element " + name + " is missing.")
+	}
+	if !inGT(a) {
+		return errors.New("Element " + name + " does not belong to GT.")
+	}
+	return nil
+}
+
+/*
+Validate checks the parameters of the set membership proof.
+*/
+func (p *paramsSet) Validate() (error) {
+	vp := p.VerifierParams()
+	if err := vp.validateSet(); err != nil {
+		return err
+	}
+	if len(p.signatures) == 0 {
+		return errors.New("Set must not be empty.")
+	}
+	for k, sig := range p.signatures {
+		if err := checkG2(sig, "signature of " + strconv.FormatInt(k, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+Validate checks the parameters of the proof for [0,u^l): there must be a signature
+for each digit in [0,u).
+*/
+func (p *ProverParams) Validate() (error) {
+	var (
+		i int64
+	)
+	vp := p.VerifierParams()
+	if err := vp.Validate(); err != nil {
+		return err
+	}
+	if int64(len(p.signatures)) != p.u {
+		return errors.New("Parameters must have u signatures.")
+	}
+	for i=0; i<p.u; i++ {
+		if err := checkG2(p.signatures[strconv.FormatInt(i, 10)], "signature of " + strconv.FormatInt(i, 10)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+validateSet checks the public parameters of the set membership proof.
+*/
+func (p *VerifierParams) validateSet() (error) {
+	if err := checkG2(p.H, "H"); err != nil {
+		return err
+	}
+	if p.pubk == nil {
+		return errors.New("Public key is missing.")
+	}
+	return nil
+}
+
+/*
+Validate checks the public parameters of the proof for [0,u^l).
+*/
+func (p *VerifierParams) Validate() (error) {
+	if p.u < 2 || p.l < 1 {
+		return errors.New("u must be at least 2 and l at least 1.")
+	}
+	return p.validateSet()
+}
+
+/*
+Validate checks the set membership proof.
+*/
+func (p *proofSet) Validate() (error) {
+	var (
+		err error
+	)
+	if err = checkG2(p.V, "V"); err != nil {
+		return err
+	}
+	if err = checkG2(p.D, "D"); err != nil {
+		return err
+	}
+	if err = checkG2(p.C, "C"); err != nil {
+		return err
+	}
+	if err = checkGT(p.a, "a"); err != nil {
+		return err
+	}
+	return checkScalars(bn256.Order, []*big.Int{p.c, p.zr, p.zsig, p.zv}, 4, "c,zr,zsig,zv")
+}
+
+/*
+Validate checks the proof for [0,u^l), whose vectors must have the same size.
+The commitment C is checked by VerifyUL, since the proofs of ccs08 do not carry it.
+*/
+func (p *proofUL) Validate() (error) {
+	var (
+		i int
+		err error
+	)
+	l := int64(len(p.V))
+	if l == 0 || int64(len(p.a)) != l {
+		return errors.New("V and a must have the same positive size.")
+	}
+	for i=0; i<len(p.V); i++ {
+		if err = checkG2(p.V[i], "V[" + strconv.Itoa(i) + "]"); err != nil {
+			return err
+		}
+		if err = checkGT(p.a[i], "a[" + strconv.Itoa(i) + "]"); err != nil {
+			return err
+		}
+	}
+	if err = checkG2(p.D, "D"); err != nil {
+		return err
+	}
+	if err = checkScalars(bn256.Order, p.zsig, l, "zsig"); err != nil {
+		return err
+	}
+	if err = checkScalars(bn256.Order, p.zv, l, "zv"); err != nil {
+		return err
+	}
+	return checkScalars(bn256.Order, []*big.Int{p.c, p.zr}, 2, "c,zr")
+}
+
+/*
+Validate checks the proof for [a,b), which is composed by two proofs for [0,u^l).
+*/
+func (p *proof) Validate() (error) {
+	if err := p.p1.Validate(); err != nil {
+		return err
+	}
+	return p.p2.Validate()
+}
+
+/*
+Validate checks the parameters for [a,b).
+*/
+func (p *params) Validate() (error) {
+	if p.v == nil {
+		return errors.New("Parameters for [0,u^l) are missing.")
+	}
+	if p.a > p.b {
+		return errors.New("a must be less than or equal to b")
+	}
+	if err := p.v.Validate(); err != nil {
+		return err
+	}
+	if p.p == nil {
+		return nil
+	}
+	// The prover parameters must match the public parameters
+	if p.p.u != p.v.u || p.p.l != p.v.l || !bytes.Equal(p.p.H.Marshal(), p.v.H.Marshal()) ||
+		!bytes.Equal(p.p.pubk.Marshal(), p.v.pubk.Marshal()) {
+		return errors.New("Prover parameters do not match the verifier parameters.")
+	}
+	return p.p.Validate()
+}
+
+/*
+Validate checks the CCS08 parameters.
+*/
+func (zkrp *ccs08) Validate() (error) {
+	if zkrp.p == nil {
+		return errors.New("Parameters are missing, Setup must be called first.")
+	}
+	return zkrp.p.Validate()
+}
@@ -0,0 +1,228 @@
+// Copyright 2018 ING Bank N.V.
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package zkproofs
+
+import (
+	"testing"
+	"math/big"
+	"crypto/rand"
+	"encoding/json"
+	"github.com/ing-bank/zkproofs/go-ethereum/crypto/bn256"
+)
+
+/*
+Test that Verify rejects malformed Bulletproofs proofs with an error instead of
+panicking.
+*/
+func TestValidateBulletproofs(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 200)
+	valid, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	offCurve := &p256{X: new(big.Int).SetInt64(1), Y: new(big.Int).SetInt64(1)}
+	tamper := []func(p *proofBP){
+		func(p *proofBP) { p.V = nil },
+		func(p *proofBP) { p.P1.A = offCurve },
+		func(p *proofBP) { p.P2.T1 = (*p256)(nil) },
+		func(p *proofBP) { p.P1.Taux = new(big.Int).Set(ORDER) },
+		func(p *proofBP) { p.P2.Mu = new(big.Int).SetInt64(-1) },
+		func(p *proofBP) { p.P1.Tprime = nil },
+		func(p *proofBP) { p.P1.Proofip.Rs = p.P1.Proofip.Rs[1:] },
+		func(p *proofBP) { p.P2.Proofip.N = 6 },
+		func(p *proofBP) { p.P2.Proofip.Ls = append([]Element{}, p.P2.Proofip.Ls...); p.P2.Proofip.Ls[0] = offCurve },
+		func(p *proofBP) { p.P1.Proofip.B = new(big.Int).Add(ORDER, big.NewInt(1)) },
+		func(p *proofBP) {
+			p.P1.Proofip.Ls = p.P1.Proofip.Ls[1:]
+			p.P1.Proofip.Rs = p.P1.Proofip.Rs[1:]
+			p.P1.Proofip.N = p.P1.Proofip.N / 2
+		},
+	}
+	for i, f := range tamper {
+		proof := valid
+		f(&proof)
+		ok, err := zkrp.Verify(proof)
+		if ok != false || err == nil {
+			t.Errorf("Assert failure for case %d: expected false and an error, actual: %t, %v", i, ok, err)
+		}
+		ok, _, err = zkrp.VerifyBatch([]proofBP{valid, proof})
+		if ok != false {
+			t.Errorf("Assert failure for case %d: batch expected false, actual: %t, %v", i, ok, err)
+		}
+	}
+	ok, err := zkrp.Verify(valid)
+	if ok != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", ok, err)
+	}
+}
+
+/*
+Test that the JSON decoding rejects off-curve points.
+*/
+func TestValidateBulletproofsJSON(t *testing.T) {
+	var (
+		zkrp bp
+		decoded proofBP
+	)
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+	proof.P2.S = &p256{X: new(big.Int).SetInt64(1), Y: new(big.Int).SetInt64(1)}
+	data, _ := json.Marshal(&proof)
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		t.Errorf("Assert failure: expected an error for an off-curve point")
+	}
+}
+
+/*
+Test the validation of the Bulletproofs parameters.
+*/
+func TestValidateBulletproofsParams(t *testing.T) {
+	var (
+		zkrp bp
+	)
+	zkrp.Setup(18, 200)
+	proof, _ := zkrp.Prove(new(big.Int).SetInt64(40))
+	if err := zkrp.Validate(); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	tamper := []func(p *bp){
+		func(p *bp) { p.N = 6 },
+		func(p *bp) { p.N = 4 },
+		func(p *bp) { p.Gg = p.Gg[1:] },
+		func(p *bp) { p.H = nil },
+		func(p *bp) { p.Zkip.Uu = &p256{X: new(big.Int).SetInt64(1), Y: new(big.Int).SetInt64(1)} },
+		func(p *bp) { p.Zkip.Group = NISTP256 },
+	}
+	for i, f := range tamper {
+		params := zkrp
+		f(&params)
+		ok, err := params.Verify(proof)
+		if ok != false || err == nil {
+			t.Errorf("Assert failure for case %d: expected false and an error, actual: %t, %v", i, ok, err)
+		}
+	}
+}
+
+/*
+Test that the inner product verifiers reject malformed proofs.
+*/
+func TestValidateInnerProduct(t *testing.T) {
+	var (
+		zkip bip
+	)
+	gg, hh, _ := groupGenerators(SECP256K1, 4)
+	H, _, _ := groupHU(SECP256K1)
+	a := []*big.Int{big.NewInt(2), big.NewInt(3), big.NewInt(4), big.NewInt(5)}
+	b := []*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1), big.NewInt(1)}
+	c := big.NewInt(14)
+	zkip.Setup(H, gg, hh)
+	P, _ := zkip.Commit(a, b)
+	valid, _ := zkip.Prove(a, b, P, c)
+	proof := valid
+	proof.Ls = proof.Ls[:1]
+	if ok, err := zkip.Verify(P, c, proof); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if ok, err := zkip.VerifyMultiExp(P, c, proof); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if ok, err := zkip.Verify(nil, c, valid); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if ok, err := zkip.VerifyMultiExp(P, nil, valid); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	if ok, err := zkip.VerifyMultiExp(P, c, valid); ok != true || err != nil {
+		t.Errorf("Assert failure: expected true, actual: %t, %v", ok, err)
+	}
+}
+
+/*
+outsideGT returns the constant 2 of the field with p^12 elements, which does not
+belong to the subgroup of order bn256.Order.
+*/
+func outsideGT() (*bn256.GT) {
+	b := make([]byte, GTSIZE)
+	b[GTSIZE - 1] = 2
+	a, _ := new(bn256.GT).Unmarshal(b)
+	return a
+}
+
+/*
+Test that the CCS08 verifiers reject malformed proofs and parameters.
+*/
+func TestValidateCCS08(t *testing.T) {
+	p, _ := SetupUL(10, 3)
+	r, _ := rand.Int(rand.Reader, bn256.Order)
+	valid, _ := ProveUL(new(big.Int).SetInt64(421), r, p)
+	vp := p.VerifierParams()
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Assert failure: unexpected error: %s", err)
+	}
+	tamper := []func(p *proofUL){
+		func(p *proofUL) { p.V = p.V[1:] },
+		func(p *proofUL) { p.D = nil },
+		func(p *proofUL) { p.zsig = append([]*big.Int{}, p.zsig...); p.zsig[1] = new(big.Int).Set(bn256.Order) },
+		func(p *proofUL) { p.c = nil },
+		func(p *proofUL) { p.a = append([]*bn256.GT{}, p.a...); p.a[2] = nil },
+		func(p *proofUL) { p.a = append([]*bn256.GT{}, p.a...); p.a[0] = outsideGT() },
+	}
+	for i, f := range tamper {
+		proof := valid
+		f(&proof)
+		ok, err := VerifyUL(&proof, &vp)
+		if ok != false || err == nil {
+			t.Errorf("Assert failure for case %d: expected false and an error, actual: %t, %v", i, ok, err)
+		}
+	}
+	// Missing signature in the parameters
+	params := p
+	params.signatures = make(map[string]*bn256.G2)
+	for k, v := range p.signatures {
+		params.signatures[k] = v
+	}
+	delete(params.signatures, "3")
+	if err := params.Validate(); err == nil {
+		t.Errorf("Assert failure: expected an error for a missing signature")
+	}
+	// Missing public key in the verifier parameters
+	missing := vp
+	missing.pubk = nil
+	if ok, err := VerifyUL(&valid, &missing); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	// Verify before Setup
+	var zkrp ccs08
+	if ok, err := zkrp.Verify(); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+	s, _ := SetupSet([]int64{12, 42})
+	set, _ := ProveSet(42, r, s)
+	bad := set
+	bad.a = outsideGT()
+	if err := bad.Validate(); err == nil {
+		t.Errorf("Assert failure: expected an error for an element outside GT")
+	}
+	set.zv = nil
+	svp := s.VerifierParams()
+	if ok, err := VerifySet(&set, &svp); ok != false || err == nil {
+		t.Errorf("Assert failure: expected false and an error, actual: %t, %v", ok, err)
+	}
+}